@@ -0,0 +1,156 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+// AOTProcessorClass is the main class of the Spring Boot AOT processor, generating the AOT sources, resources and
+// classes of an application.
+const AOTProcessorClass = "org.springframework.boot.SpringApplicationAotProcessor"
+
+// generateAOT runs the Spring AOT processor against the exploded application, compiles the generated sources and adds
+// them, with the generated resources and classes, to the classes of the application.
+func (s SpringPerformance) generateAOT() error {
+	startClass, ok, err := ManifestValue(s.Manifest, "Start-Class")
+	if err != nil {
+		return fmt.Errorf("invalid application manifest\n%w", err)
+	} else if !ok {
+		return WithHint(fmt.Errorf("manifest does not contain Start-Class"), HintStartClass)
+	}
+	classes, ok := s.Manifest.Get("Spring-Boot-Classes")
+	if !ok {
+		return fmt.Errorf("manifest does not contain Spring-Boot-Classes")
+	}
+
+	classpath, err := NativeImageClasspath{ApplicationPath: s.AppPath, Manifest: s.Manifest}.classpathEntries()
+	if err != nil {
+		return fmt.Errorf("unable to compute the classpath of the AOT processor\n%w", err)
+	}
+
+	dir, err := s.temp.MkdirTemp("spring-aot")
+	if err != nil {
+		return fmt.Errorf("unable to create temp directory for the AOT processor\n%w", err)
+	}
+	sources, resources, generated := filepath.Join(dir, "sources"), filepath.Join(dir, "resources"), filepath.Join(dir, "classes")
+
+	// the group identifies the native image resources of the application, the package of its start class is used
+	group := "application"
+	if i := strings.LastIndex(startClass, "."); i != -1 {
+		group = startClass[:i]
+	}
+
+	s.log().Bodyf("Generating AOT sources of %s", startClass)
+	args := []string{"-cp", strings.Join(classpath, string(filepath.ListSeparator)), AOTProcessorClass,
+		startClass, sources, resources, generated, group, "application"}
+	s.log().Debugf("Running %s %s", s.Config.JavaCommand(), strings.Join(args, " "))
+	if err := s.Executor.Execute(effect.Execution{
+		Command: s.Config.JavaCommand(),
+		Args:    args,
+		Dir:     s.AppPath,
+		Env:     s.Config.JavaEnv(nil),
+		Stdout:  s.log().InfoWriter(),
+		Stderr:  s.log().InfoWriter(),
+	}); err != nil {
+		err = fmt.Errorf("error running the AOT processor\n%w", err)
+		if commandNotFound(err) {
+			return WithHint(err, HintJDK)
+		}
+		return err
+	}
+
+	var files []string
+	if err := filepath.WalkDir(sources, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".java") {
+			files = append(files, path)
+		}
+		return nil
+	}); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to list the AOT sources in %s\n%w", sources, err)
+	}
+
+	if len(files) > 0 {
+		// the sources are listed in an argument file, they may be too many for the command line
+		argFile := filepath.Join(dir, "sources.txt")
+		if err := os.WriteFile(argFile, []byte(strings.Join(files, "\n")+"\n"), 0644); err != nil {
+			return fmt.Errorf("unable to write %s\n%w", argFile, err)
+		}
+
+		s.log().Bodyf("Compiling %d AOT sources", len(files))
+		if err := s.Executor.Execute(effect.Execution{
+			Command: s.Config.JavacCommand(),
+			Args: []string{"-d", generated, "-parameters",
+				"-cp", strings.Join(append(classpath, generated), string(filepath.ListSeparator)), "@" + argFile},
+			Dir:    s.AppPath,
+			Env:    s.Config.JavaEnv(nil),
+			Stdout: s.log().InfoWriter(),
+			Stderr: s.log().InfoWriter(),
+		}); err != nil {
+			err = fmt.Errorf("error compiling the AOT sources\n%w", err)
+			if commandNotFound(err) {
+				return WithHint(err, HintJDK)
+			}
+			return err
+		}
+	}
+
+	for _, d := range []string{resources, generated} {
+		if err := mergeDir(d, filepath.Join(s.AppPath, classes)); err != nil {
+			return fmt.Errorf("unable to add the AOT output to the application\n%w", err)
+		}
+	}
+	return nil
+}
+
+// mergeDir copies the files of source into destination, keeping the files of destination that source does not
+// contain. Nothing is copied if source does not exist.
+func mergeDir(source string, destination string) error {
+	return filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) && path == source {
+			return filepath.SkipDir
+		} else if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(destination, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("unable to open %s\n%w", path, err)
+		}
+		defer in.Close()
+		return sherpa.CopyFile(in, target)
+	})
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+const (
+	// CachedArchiveName is the CDS archive kept in the performance layer, relative to the layer, with
+	// BP_JVM_CDS_CACHE_ARCHIVE for the platform to restore it on the next build.
+	CachedArchiveName = "cache/application.jsa"
+
+	// ArchiveKeyMetadata is the layer metadata recording what the cached CDS archive was trained on.
+	ArchiveKeyMetadata = "cds_archive_key"
+
+	// ArchiveDigestMetadata is the layer metadata recording the SHA-256 digest of the cached CDS archive.
+	ArchiveDigestMetadata = "cds_archive_sha256"
+)
+
+// ArchiveCacheKey returns the key of a CDS archive created by the training run of the application whose contents
+// digest is appDigest, with the JDK of version jdkVersion and the arguments args. A cached archive is only used if
+// its key is the one of the training run it replaces.
+func ArchiveCacheKey(appDigest string, jdkVersion string, args []string) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\x00%s\x00%s", appDigest, jdkVersion, strings.Join(args, "\x00"))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedArchive is a CDS archive restored by the platform from a previous build.
+type cachedArchive struct {
+	path string
+	key  string
+}
+
+// takeCachedArchive moves the CDS archive cached in layer out of it, as the layer is reset before it is contributed
+// again. The archive is ignored if the layer metadata does not record its key, or if it is not the archive recorded.
+func (s SpringPerformance) takeCachedArchive(layer libcnb.Layer) (*cachedArchive, error) {
+	if !s.Config.CacheArchive || !s.DoTrainingRun {
+		return nil, nil
+	}
+
+	cached := filepath.Join(layer.Path, CachedArchiveName)
+	if _, err := os.Stat(cached); os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to stat %s\n%w", cached, err)
+	}
+
+	key, _ := layer.Metadata[ArchiveKeyMetadata].(string)
+	expected, _ := layer.Metadata[ArchiveDigestMetadata].(string)
+	if digest, err := FileDigest(cached); err != nil {
+		return nil, err
+	} else if key == "" || digest != expected {
+		s.log().Bodyf("Ignoring cached CDS archive %s, its digest sha256:%s is not the one recorded", cached, digest)
+		return nil, nil
+	}
+
+	dir, err := s.temp.MkdirTemp("cds-cache")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create temp directory for the cached CDS archive\n%w", err)
+	}
+	path := filepath.Join(dir, filepath.Base(cached))
+	if err := moveArchive(cached, path); err != nil {
+		return nil, fmt.Errorf("unable to move the cached CDS archive\n%w", err)
+	}
+	return &cachedArchive{path: path, key: key}, nil
+}
+
+// cacheArchive copies archive into layer for the platform to restore it on the next build, and returns its digest.
+func cacheArchive(layer libcnb.Layer, archive string) (string, error) {
+	digest, err := FileDigest(archive)
+	if err != nil {
+		return "", err
+	}
+
+	in, err := os.Open(archive)
+	if err != nil {
+		return "", fmt.Errorf("unable to open %s\n%w", archive, err)
+	}
+	defer in.Close()
+
+	cached := filepath.Join(layer.Path, CachedArchiveName)
+	if err := sherpa.CopyFile(in, cached); err != nil {
+		return "", fmt.Errorf("unable to copy %s to %s\n%w", archive, cached, err)
+	}
+	return digest, nil
+}
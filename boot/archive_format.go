@@ -0,0 +1,98 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultArchiveFormat is the name of the ArchiveFormat used when BP_JVM_CDS_ARCHIVE_FORMAT is not set.
+const DefaultArchiveFormat = "dynamic"
+
+// ArchiveFormat is the way the CDS archive is created from the training run, as the flags and the files of the archive
+// change with the features of the JDK. A format is selected by its name with $BP_JVM_CDS_ARCHIVE_FORMAT.
+type ArchiveFormat interface {
+
+	// Name is the value of $BP_JVM_CDS_ARCHIVE_FORMAT selecting the format.
+	Name() string
+
+	// TrainingArgs returns the arguments of the training run creating the archive dump, which may list the classes it
+	// loads to classList.
+	TrainingArgs(dump string, classList string) []string
+
+	// DumpArgs returns the arguments of the run dumping the archive from the classes listed in classList, loaded from
+	// classpath, once the training run is done, or nil if the training run dumps the archive itself.
+	DumpArgs(dump string, classList string, classpath string) []string
+}
+
+// DynamicArchive is the dynamic archive the JVM dumps when the training run exits, with the classes it loaded on top
+// of the default archive of the JDK.
+type DynamicArchive struct{}
+
+func (DynamicArchive) Name() string {
+	return "dynamic"
+}
+
+func (DynamicArchive) TrainingArgs(dump string, _ string) []string {
+	return []string{fmt.Sprintf("-XX:ArchiveClassesAtExit=%s", dump)}
+}
+
+func (DynamicArchive) DumpArgs(string, string, string) []string {
+	return nil
+}
+
+// ClassicArchive is the static archive dumped, once the training run listed the classes it loads, by a run of the JVM
+// with -Xshare:dump. The archive also holds the classes of the JDK and does not depend on its default archive.
+type ClassicArchive struct{}
+
+func (ClassicArchive) Name() string {
+	return "classic"
+}
+
+func (ClassicArchive) TrainingArgs(_ string, classList string) []string {
+	return []string{fmt.Sprintf("-XX:DumpLoadedClassList=%s", classList)}
+}
+
+func (ClassicArchive) DumpArgs(dump string, classList string, classpath string) []string {
+	return StaticDumpArgs(classList, dump, classpath)
+}
+
+// ArchiveFormats are the formats that can be selected, a new format is added with its strategy.
+var ArchiveFormats = []ArchiveFormat{ClassicArchive{}, DynamicArchive{}}
+
+// plannedArchiveFormats are the formats that are recognized but not implemented yet.
+var plannedArchiveFormats = []string{"leyden"}
+
+// ParseArchiveFormat returns the ArchiveFormat named by value, ignoring case.
+func ParseArchiveFormat(value string) (ArchiveFormat, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	var names []string
+	for _, f := range ArchiveFormats {
+		if f.Name() == value {
+			return f, nil
+		}
+		names = append(names, f.Name())
+	}
+	for _, planned := range plannedArchiveFormats {
+		if planned == value {
+			return nil, fmt.Errorf("the %s archive format is not supported yet, use one of %s", value, strings.Join(names, ", "))
+		}
+	}
+	return nil, fmt.Errorf("%q is not one of %s", value, strings.Join(names, ", "))
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testArchiveFormat(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	context("ParseArchiveFormat", func() {
+		it("selects a format by its name", func() {
+			Expect(boot.ParseArchiveFormat("classic")).To(Equal(boot.ClassicArchive{}))
+			Expect(boot.ParseArchiveFormat(" Dynamic ")).To(Equal(boot.DynamicArchive{}))
+			Expect(boot.ParseArchiveFormat(boot.DefaultArchiveFormat)).To(Equal(boot.DynamicArchive{}))
+		})
+
+		it("rejects a format that is not supported yet", func() {
+			_, err := boot.ParseArchiveFormat("leyden")
+			Expect(err).To(MatchError("the leyden archive format is not supported yet, use one of classic, dynamic"))
+		})
+
+		it("rejects an unknown format", func() {
+			_, err := boot.ParseArchiveFormat("static")
+			Expect(err).To(MatchError(`"static" is not one of classic, dynamic`))
+		})
+	})
+
+	context("DynamicArchive", func() {
+		it("archives the classes loaded when the training run exits", func() {
+			Expect(boot.DynamicArchive{}.TrainingArgs("application.jsa", "classes.lst")).To(Equal([]string{"-XX:ArchiveClassesAtExit=application.jsa"}))
+			Expect(boot.DynamicArchive{}.DumpArgs("application.jsa", "classes.lst", "runner.jar")).To(BeNil())
+		})
+	})
+
+	context("ClassicArchive", func() {
+		it("lists the classes loaded by the training run and dumps them once it is done", func() {
+			Expect(boot.ClassicArchive{}.TrainingArgs("application.jsa", "classes.lst")).To(Equal([]string{"-XX:DumpLoadedClassList=classes.lst"}))
+			Expect(boot.ClassicArchive{}.DumpArgs("application.jsa", "classes.lst", "runner.jar")).To(Equal([]string{
+				"-Xshare:dump", "-XX:SharedClassListFile=classes.lst", "-XX:SharedArchiveFile=application.jsa", "-cp", "runner.jar",
+			}))
+		})
+	})
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ArchiveRegionsMetadata is the layer metadata key of the sizes of the regions of the CDS archive mapped by the JVM.
+const ArchiveRegionsMetadata = "cds_archive_regions"
+
+// ArchiveRegionLogArgs are the JVM arguments logging the regions of the CDS archives as the JVM maps them.
+var ArchiveRegionLogArgs = []string{"-Xlog:cds=info,cds+heap=info"}
+
+// archiveRegionPattern matches a region of a CDS archive logged by the JVM as it maps or loads it, such as
+// "[info][cds] Mapped static  region #0 at base 0x0000000800000000 top 0x0000000800410000 (ReadWrite)".
+var archiveRegionPattern = regexp.MustCompile(`(?:Mapped|Loaded)\s+(\w+)\s+region #(\d+) at base 0x([0-9a-fA-F]+) top 0x([0-9a-fA-F]+) \((\w+)\)`)
+
+// ArchiveRegion is a region of a CDS archive mapped by the JVM.
+type ArchiveRegion struct {
+
+	// Archive is the archive of the region, static for the base archive of the JDK and dynamic for the archive of the
+	// application, or heap for the archived objects.
+	Archive string
+
+	// Index is the index of the region in its archive.
+	Index int
+
+	// Name is the name of the region logged by the JVM, such as ReadWrite, ReadOnly or Bitmap, rw or ro for older ones.
+	Name string
+
+	// Size is the size in bytes of the region once mapped.
+	Size int64
+}
+
+// ReadOnly returns whether the region is mapped read-only, shared by the JVMs mapping the archive.
+func (r ArchiveRegion) ReadOnly() bool {
+	return strings.EqualFold(r.Name, "ReadOnly") || strings.EqualFold(r.Name, "ro")
+}
+
+// ReadWrite returns whether the region is mapped read-write, copied on write by each JVM mapping the archive.
+func (r ArchiveRegion) ReadWrite() bool {
+	return strings.EqualFold(r.Name, "ReadWrite") || strings.EqualFold(r.Name, "rw")
+}
+
+// ArchiveRegions are the regions of the CDS archives mapped by a JVM.
+type ArchiveRegions []ArchiveRegion
+
+// ParseArchiveRegions returns the regions of the CDS archives logged by a JVM run with ArchiveRegionLogArgs, in the
+// order they were mapped. A region logged again, once mapped and once loaded, is only returned once.
+func ParseArchiveRegions(output string) ArchiveRegions {
+	var regions ArchiveRegions
+	seen := map[string]bool{}
+	for _, m := range archiveRegionPattern.FindAllStringSubmatch(output, -1) {
+		index, err := strconv.Atoi(m[2])
+		if err != nil {
+			continue
+		}
+		base, err := strconv.ParseUint(m[3], 16, 64)
+		if err != nil {
+			continue
+		}
+		top, err := strconv.ParseUint(m[4], 16, 64)
+		if err != nil || top < base {
+			continue
+		}
+		r := ArchiveRegion{Archive: m[1], Index: index, Name: m[5], Size: int64(top - base)}
+		if key := fmt.Sprintf("%s.%d", r.Archive, r.Index); !seen[key] {
+			seen[key] = true
+			regions = append(regions, r)
+		}
+	}
+	return regions
+}
+
+// ReadOnlySize returns the total size in bytes of the read-only regions.
+func (r ArchiveRegions) ReadOnlySize() int64 {
+	var size int64
+	for _, region := range r {
+		if region.ReadOnly() {
+			size += region.Size
+		}
+	}
+	return size
+}
+
+// ReadWriteSize returns the total size in bytes of the read-write regions.
+func (r ArchiveRegions) ReadWriteSize() int64 {
+	var size int64
+	for _, region := range r {
+		if region.ReadWrite() {
+			size += region.Size
+		}
+	}
+	return size
+}
+
+// Metadata returns the summary of the regions recorded as ArchiveRegionsMetadata: the size of the regions by archive
+// and name, such as static_readwrite_bytes, and the total sizes of the read-only and read-write regions.
+func (r ArchiveRegions) Metadata() map[string]interface{} {
+	sizes := map[string]int64{}
+	for _, region := range r {
+		sizes[strings.ToLower(fmt.Sprintf("%s_%s_bytes", region.Archive, region.Name))] += region.Size
+	}
+
+	metadata := map[string]interface{}{
+		"read_only_bytes":  r.ReadOnlySize(),
+		"read_write_bytes": r.ReadWriteSize(),
+	}
+	for key, size := range sizes {
+		metadata[key] = size
+	}
+	return metadata
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testArchiveRegions(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	context("ParseArchiveRegions", func() {
+		it("parses the regions mapped by the JVM", func() {
+			output, err := os.ReadFile(filepath.Join("testdata", "cds", "regions", "jdk21.log"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(boot.ParseArchiveRegions(string(output))).To(Equal(boot.ArchiveRegions{
+				{Archive: "static", Index: 0, Name: "ReadWrite", Size: 0x3f0000},
+				{Archive: "static", Index: 1, Name: "ReadOnly", Size: 0x660000},
+				{Archive: "dynamic", Index: 0, Name: "ReadWrite", Size: 0x1c4000},
+				{Archive: "dynamic", Index: 1, Name: "ReadOnly", Size: 0x523000},
+				{Archive: "static", Index: 2, Name: "Bitmap", Size: 0x5d000},
+				{Archive: "dynamic", Index: 2, Name: "Bitmap", Size: 0x2a000},
+			}))
+		})
+
+		it("parses a region once when mapped and loaded", func() {
+			Expect(boot.ParseArchiveRegions(`[info][cds] Mapped dynamic region #0 at base 0x0000000800c66000 top 0x0000000800c67000 (rw)
+[info][cds] Loaded dynamic region #0 at base 0x0000000800c66000 top 0x0000000800c67000 (rw)`)).To(Equal(boot.ArchiveRegions{
+				{Archive: "dynamic", Index: 0, Name: "rw", Size: 0x1000},
+			}))
+		})
+
+		it("does not parse an output without regions", func() {
+			Expect(boot.ParseArchiveRegions("Number of shared classes = 11562")).To(BeEmpty())
+		})
+	})
+
+	it("summarizes the regions by access", func() {
+		regions := boot.ArchiveRegions{
+			{Archive: "static", Index: 0, Name: "ReadWrite", Size: 100},
+			{Archive: "static", Index: 1, Name: "ReadOnly", Size: 200},
+			{Archive: "dynamic", Index: 0, Name: "rw", Size: 10},
+			{Archive: "dynamic", Index: 1, Name: "ro", Size: 20},
+			{Archive: "dynamic", Index: 2, Name: "Bitmap", Size: 5},
+		}
+
+		Expect(regions.ReadOnlySize()).To(Equal(int64(220)))
+		Expect(regions.ReadWriteSize()).To(Equal(int64(110)))
+		Expect(regions.Metadata()).To(Equal(map[string]interface{}{
+			"read_only_bytes":        int64(220),
+			"read_write_bytes":       int64(110),
+			"static_readwrite_bytes": int64(100),
+			"static_readonly_bytes":  int64(200),
+			"dynamic_rw_bytes":       int64(10),
+			"dynamic_ro_bytes":       int64(20),
+			"dynamic_bitmap_bytes":   int64(5),
+		}))
+	})
+}
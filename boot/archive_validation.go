@@ -0,0 +1,55 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+// ArchiveValidationArgs returns the arguments of a launch of startClass with the CDS archive and the classpath of the
+// launch at runtime, requiring the archive and exiting once the JVM has mapped it rather than running the application.
+func ArchiveValidationArgs(aotEnabled bool, archiveFile string, classpath []string, startClass string) []string {
+	args := append(PerformanceLaunchArguments(aotEnabled, true, archiveFile), "-Xshare:on", "-XX:+PrintSharedArchiveAndExit")
+	return append(args, "-cp", strings.Join(classpath, string(filepath.ListSeparator)), startClass)
+}
+
+// validateArchive launches the application from its directory with the classpath of the launch at runtime, preceded by
+// logArgs, and returns its output. It returns an error if the JVM rejects the CDS archive, which it silently ignores at
+// runtime, such as when the archive records the classpath at other paths.
+func (s SpringPerformance) validateArchive(javaCommand string, classpath []string, startClass string, logArgs []string) (string, error) {
+	output := &bytes.Buffer{}
+	args := append(slices.Clone(logArgs), ArchiveValidationArgs(s.AotEnabled, s.Config.ArchiveFile(), classpath, startClass)...)
+	s.log().Debugf("Running %s %s", javaCommand, strings.Join(args, " "))
+
+	if err := s.Executor.Execute(effect.Execution{
+		Command: javaCommand,
+		Args:    args,
+		Dir:     s.AppPath,
+		Env:     s.Config.JavaEnv(nil),
+		Stdout:  output,
+		Stderr:  output,
+	}); err != nil {
+		return "", fmt.Errorf("the JVM rejected the CDS archive %s when launched from %s\n%w\n%s", s.Config.ArchiveFile(), s.AppPath, err, strings.TrimSpace(output.String()))
+	}
+	return output.String(), nil
+}
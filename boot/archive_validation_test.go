@@ -0,0 +1,46 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testArchiveValidation(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("launches the application with the archive required and exits once it is mapped", func() {
+		Expect(boot.ArchiveValidationArgs(false, "application.jsa", []string{"runner.jar", "lib/test.jar"}, "com.example.Application")).To(Equal([]string{
+			"-XX:SharedArchiveFile=application.jsa", "-Xshare:on", "-XX:+PrintSharedArchiveAndExit",
+			"-cp", "runner.jar:lib/test.jar", "com.example.Application",
+		}))
+	})
+
+	it("launches the application with the AOT flag of the runtime", func() {
+		Expect(boot.ArchiveValidationArgs(true, "/layers/cds/application.jsa", []string{"runner.jar"}, "com.example.Application")).To(Equal([]string{
+			"-XX:SharedArchiveFile=/layers/cds/application.jsa", "-Dspring.aot.enabled=true", "-Xshare:on", "-XX:+PrintSharedArchiveAndExit",
+			"-cp", "runner.jar", "com.example.Application",
+		}))
+	})
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// DefaultDumpGrace is how long the CDS archive is waited for by default once the training run exits.
+	DefaultDumpGrace = 5 * time.Second
+
+	// DefaultShutdownTimeout is how long the shutdown of the training run may take by default before the JVM is halted
+	// to dump the CDS archive, when BP_JVM_CDS_FORCE_DUMP is set without BP_JVM_CDS_SHUTDOWN_TIMEOUT.
+	DefaultShutdownTimeout = 30 * time.Second
+
+	// dumpPollInterval is the interval between two checks of the CDS archive being dumped.
+	dumpPollInterval = 250 * time.Millisecond
+)
+
+// WaitForArchive waits up to grace for the file at path to exist and to be unchanged between two checks an interval
+// apart. It returns whether the file is stable, false if it is still missing or being written once grace has elapsed.
+// The file is only checked for once with no grace.
+func WaitForArchive(path string, grace time.Duration, interval time.Duration) (bool, error) {
+	if grace <= 0 {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return false, nil
+		} else if err != nil {
+			return false, fmt.Errorf("unable to check for %s\n%w", path, err)
+		}
+		return true, nil
+	}
+
+	deadline := time.Now().Add(grace)
+
+	var previous os.FileInfo
+	for {
+		info, err := os.Stat(path)
+		if err != nil && !os.IsNotExist(err) {
+			return false, fmt.Errorf("unable to check for %s\n%w", path, err)
+		}
+
+		if info != nil && previous != nil && info.Size() == previous.Size() && info.ModTime().Equal(previous.ModTime()) {
+			return true, nil
+		}
+		previous = info
+
+		if !time.Now().Add(interval).Before(deadline) {
+			return false, nil
+		}
+		time.Sleep(interval)
+	}
+}
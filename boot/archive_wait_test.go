@@ -0,0 +1,101 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testWaitForArchive(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path string
+	)
+
+	it.Before(func() {
+		path = filepath.Join(t.TempDir(), "application.jsa")
+	})
+
+	// dump writes the archive in chunks, as the JVM would, and closes done once it is written
+	dump := func(delay time.Duration, chunks int) chan struct{} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			time.Sleep(delay)
+			f, err := os.Create(path)
+			if err != nil {
+				panic(err)
+			}
+			defer f.Close()
+			for i := 0; i < chunks; i++ {
+				if _, err := f.Write(make([]byte, 1024)); err != nil {
+					panic(err)
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+		}()
+		return done
+	}
+
+	it("waits for the archive to be written", func() {
+		done := dump(0, 10)
+
+		stable, err := boot.WaitForArchive(path, 5*time.Second, 50*time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stable).To(BeTrue())
+		Expect(done).To(BeClosed())
+		Expect(path).To(BeARegularFile())
+	})
+
+	it("waits for the archive to appear", func() {
+		done := dump(150*time.Millisecond, 1)
+
+		stable, err := boot.WaitForArchive(path, 5*time.Second, 50*time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stable).To(BeTrue())
+		<-done
+	})
+
+	it("gives up once the grace period elapsed", func() {
+		start := time.Now()
+
+		stable, err := boot.WaitForArchive(path, 200*time.Millisecond, 50*time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stable).To(BeFalse())
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	})
+
+	it("only checks for the archive once without grace", func() {
+		stable, err := boot.WaitForArchive(path, 0, 50*time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stable).To(BeFalse())
+
+		Expect(os.WriteFile(path, []byte{}, 0644)).To(Succeed())
+		stable, err = boot.WaitForArchive(path, 0, 50*time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stable).To(BeTrue())
+	})
+}
@@ -0,0 +1,41 @@
+//go:build linux
+
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"syscall"
+)
+
+const (
+	// minArgMax is the lower bound Linux applies to ARG_MAX, regardless of the stack size limit.
+	minArgMax = 128 * 1024
+
+	// maxArgMax is the upper bound Linux applies to ARG_MAX, three quarters of the 8 MiB default stack size limit.
+	maxArgMax = 6 * 1024 * 1024
+)
+
+// ArgMax returns the ARG_MAX of the platform. Linux allows a quarter of the stack size limit for the arguments and
+// environment of a process, within the bounds of minArgMax and maxArgMax.
+func ArgMax() int {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_STACK, &limit); err != nil {
+		return DefaultArgMax
+	}
+	return int(max(min(limit.Cur/4, maxArgMax), minArgMax))
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// BeanCountMetadata is the layer metadata key of the number of beans of the context refreshed by the training run.
+const BeanCountMetadata = "training_bean_count"
+
+// beanFactoryPattern matches the description of a bean factory logged by Spring when it pre-instantiates its singletons,
+// such as "DefaultListableBeanFactory@1b2c3d4: defining beans [application,dataSource]; root of factory hierarchy".
+var beanFactoryPattern = regexp.MustCompile(`defining beans \[([^\]]*)\]`)
+
+// ParseBeanCount returns the number of beans of the largest bean factory described in output, if output describes
+// one. An application with a management or child context describes several.
+func ParseBeanCount(output string) (int, bool) {
+	count, found := 0, false
+	for _, match := range beanFactoryPattern.FindAllStringSubmatch(output, -1) {
+		n := 0
+		if names := strings.TrimSpace(match[1]); names != "" {
+			n = strings.Count(names, ",") + 1
+		}
+		count, found = max(count, n), true
+	}
+	return count, found
+}
+
+// BeanCounter is an io.Writer parsing the number of beans of the bean factories described by the lines written to it,
+// so that the output of the training run does not have to be kept.
+type BeanCounter struct {
+	mu      sync.Mutex
+	partial []byte
+	count   int
+	found   bool
+}
+
+func (c *BeanCounter) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.partial = append(c.partial, p...)
+	if i := bytes.LastIndexByte(c.partial, '\n'); i != -1 {
+		c.parse(string(c.partial[:i]))
+		c.partial = append([]byte(nil), c.partial[i+1:]...)
+	}
+	return len(p), nil
+}
+
+// Count returns the number of beans of the largest bean factory described by the lines written, including the last
+// one if it is not terminated, false if none was described.
+func (c *BeanCounter) Count() (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.partial) > 0 {
+		c.parse(string(c.partial))
+		c.partial = nil
+	}
+	return c.count, c.found
+}
+
+func (c *BeanCounter) parse(lines string) {
+	if n, ok := ParseBeanCount(lines); ok {
+		c.count, c.found = max(c.count, n), true
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testBeanCount(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	context("ParseBeanCount", func() {
+		it("counts the beans of the bean factory", func() {
+			count, ok := boot.ParseBeanCount(`2024-06-01T12:30:00.000Z TRACE 1 --- [main] o.s.b.f.s.DefaultListableBeanFactory : Pre-instantiating singletons in org.springframework.beans.factory.support.DefaultListableBeanFactory@1b2c3d4: defining beans [application,dataSource,org.springframework.boot.autoconfigure.AutoConfigurationPackages]; root of factory hierarchy
+2024-06-01T12:30:01.000Z  INFO 1 --- [main] com.example.Application : Started Application in 1.5 seconds (process running for 1.8)`)
+			Expect(ok).To(BeTrue())
+			Expect(count).To(Equal(3))
+		})
+
+		it("counts the beans of the largest bean factory", func() {
+			count, ok := boot.ParseBeanCount(`Pre-instantiating singletons in DefaultListableBeanFactory@1: defining beans [a,b,c,d]; root of factory hierarchy
+Pre-instantiating singletons in DefaultListableBeanFactory@2: defining beans [management]; parent: DefaultListableBeanFactory@1`)
+			Expect(ok).To(BeTrue())
+			Expect(count).To(Equal(4))
+		})
+
+		it("counts a bean factory without beans", func() {
+			count, ok := boot.ParseBeanCount("Pre-instantiating singletons in DefaultListableBeanFactory@1: defining beans []; root of factory hierarchy")
+			Expect(ok).To(BeTrue())
+			Expect(count).To(BeZero())
+		})
+
+		it("does not count an output without bean factory", func() {
+			_, ok := boot.ParseBeanCount("Started Application in 1.5 seconds (process running for 1.8)")
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	context("BeanCounter", func() {
+		it("counts the beans of lines written in several parts", func() {
+			counter := &boot.BeanCounter{}
+			_, err := fmt.Fprint(counter, "Started\nPre-instantiating singletons in DefaultListableBeanFactory@1: defining be")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = fmt.Fprint(counter, "ans [a,b]; root of factory hierarchy\nDone\n")
+			Expect(err).NotTo(HaveOccurred())
+
+			count, ok := counter.Count()
+			Expect(ok).To(BeTrue())
+			Expect(count).To(Equal(2))
+		})
+
+		it("counts the beans of an unterminated last line", func() {
+			counter := &boot.BeanCounter{}
+			_, err := fmt.Fprint(counter, "DefaultListableBeanFactory@1: defining beans [a,b,c]")
+			Expect(err).NotTo(HaveOccurred())
+
+			count, ok := counter.Count()
+			Expect(ok).To(BeTrue())
+			Expect(count).To(Equal(3))
+		})
+
+		it("does not count an output without bean factory", func() {
+			counter := &boot.BeanCounter{}
+			_, err := fmt.Fprint(counter, "Started Application in 1.5 seconds\n")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, ok := counter.Count()
+			Expect(ok).To(BeFalse())
+		})
+	})
+}
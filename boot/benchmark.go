@@ -0,0 +1,91 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+var startedPattern = regexp.MustCompile(`Started \S+ in ([0-9]+(?:\.[0-9]+)?) seconds`)
+
+// ParseStartupTime returns the startup time logged by Spring Boot in its "Started ... in X seconds" line, if output
+// contains one.
+func ParseStartupTime(output string) (time.Duration, bool) {
+	match := startedPattern.FindStringSubmatch(output)
+	if match == nil {
+		return 0, false
+	}
+
+	seconds, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// StartupImprovement returns how much faster, in percent, the startup with CDS is compared to the baseline.
+func StartupImprovement(baseline time.Duration, cds time.Duration) float64 {
+	if baseline <= 0 {
+		return 0
+	}
+	return float64(baseline-cds) / float64(baseline) * 100
+}
+
+// measureStartup launches the application once and returns its startup time: the one logged by Spring Boot if any,
+// the time the process took to exit otherwise.
+func (s SpringPerformance) measureStartup(execution effect.Execution) (time.Duration, error) {
+	output := &bytes.Buffer{}
+	execution.Stdout, execution.Stderr = output, output
+
+	start := time.Now()
+	if err := s.Executor.Execute(Sandboxed(s.Config.TrainingSandboxCommand(), execution)); err != nil {
+		return 0, fmt.Errorf("unable to launch the application\n%w\n%s", err, output.String())
+	}
+	elapsed := time.Since(start)
+
+	if startup, ok := ParseStartupTime(output.String()); ok {
+		return startup, nil
+	}
+	return elapsed, nil
+}
+
+// benchmark measures a cold start of the application without and with the CDS archive and logs the improvement.
+func (s SpringPerformance) benchmark(baseline effect.Execution, archive string) error {
+	baselineStartup, err := s.measureStartup(baseline)
+	if err != nil {
+		return fmt.Errorf("unable to measure startup without CDS\n%w", err)
+	}
+
+	cds := baseline
+	cds.Args = append([]string{fmt.Sprintf("-XX:SharedArchiveFile=%s", archive)}, baseline.Args...)
+	cdsStartup, err := s.measureStartup(cds)
+	if err != nil {
+		return fmt.Errorf("unable to measure startup with CDS\n%w", err)
+	}
+
+	s.Metrics.RecordDuration("benchmark without cds", baselineStartup)
+	s.Metrics.RecordDuration("benchmark with cds", cdsStartup)
+	s.log().Bodyf("CDS benchmark: startup without archive %s, with archive %s, estimated improvement %.1f%%",
+		baselineStartup.Round(time.Millisecond), cdsStartup.Round(time.Millisecond), StartupImprovement(baselineStartup, cdsStartup))
+	return nil
+}
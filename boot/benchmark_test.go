@@ -0,0 +1,58 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testBenchmark(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	context("ParseStartupTime", func() {
+		it("parses the startup time logged by Spring Boot", func() {
+			startup, ok := boot.ParseStartupTime("2024-07-01T10:00:00.000Z  INFO 1 --- [main] c.e.Application : " +
+				"Started Application in 1.234 seconds (process running for 1.5)\n")
+			Expect(ok).To(BeTrue())
+			Expect(startup).To(Equal(1234 * time.Millisecond))
+		})
+
+		it("returns false without a startup line", func() {
+			_, ok := boot.ParseStartupTime("Starting Application using Java 21\n")
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	context("StartupImprovement", func() {
+		it("computes the improvement in percent", func() {
+			Expect(boot.StartupImprovement(2*time.Second, 1500*time.Millisecond)).To(BeNumerically("~", 25.0))
+			Expect(boot.StartupImprovement(time.Second, 2*time.Second)).To(BeNumerically("~", -100.0))
+		})
+
+		it("returns 0 without a baseline", func() {
+			Expect(boot.StartupImprovement(0, time.Second)).To(BeZero())
+		})
+	})
+}
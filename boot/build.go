@@ -27,6 +27,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -60,7 +61,7 @@ type Build struct {
 func (b Build) Build(context libcnb.BuildContext) (libcnb.BuildResult, error) {
 
 	result := libcnb.NewBuildResult()
-	bootJarFound, reZipExplodedJar := false, false
+	bootJarFound := false
 
 	manifest, err := libjvm.NewManifest(context.Application.Path)
 	if err != nil {
@@ -84,9 +85,7 @@ func (b Build) Build(context libcnb.BuildContext) (libcnb.BuildResult, error) {
 	mainClass, _ := manifest.Get("Main-Class")
 
 	if trainingRun {
-		if bootCDSExtractionSupported(version) {
-			reZipExplodedJar = true
-		} else {
+		if !bootCDSExtractionSupported(version) {
 			b.Logger.Bodyf("You enabled CDS optimization with BP_JVM_CDS_ENABLED=true but your Spring Boot app version is: %s, you need to upgrade to Spring Boot >= 3.3 first!\nCancelling CDS optimization", version)
 			trainingRun = false
 		}
@@ -133,7 +132,10 @@ func (b Build) Build(context libcnb.BuildContext) (libcnb.BuildResult, error) {
 		return libcnb.BuildResult{}, fmt.Errorf("unable to generate dependencies from %s\n%w", context.Application.Path, err)
 	}
 	var additionalLibs []string
-	var classpathString string
+	var classpath []string
+	var classpathString, launchClasspathString string
+	// the processes launch the extracted application only once the training run has actually extracted it
+	launchExtracted := false
 
 	// Native Image
 	buildNativeImage := false
@@ -204,45 +206,57 @@ func (b Build) Build(context libcnb.BuildContext) (libcnb.BuildResult, error) {
 	}
 
 	dir := filepath.Join(context.Application.Path, "META-INF", "native-image")
-	aotEnabled := false
+	aotEnabled, aotGenerate := false, false
 	aotBuild := sherpa.ResolveBool("BP_SPRING_AOT_ENABLED")
 	if aotDirExists, _ := sherpa.DirExists(dir); aotDirExists && aotBuild {
 		aotEnabled = true
+	} else if !aotDirExists && aotBuild && sherpa.ResolveBool("BP_SPRING_AOT_GENERATE") {
+		b.Logger.Bodyf("unable to find AOT processed dir %s, the AOT classes will be generated as BP_SPRING_AOT_GENERATE has been set to true", dir)
+		aotEnabled, aotGenerate = true, true
 	} else if !aotDirExists && aotBuild {
 		b.Logger.Bodyf("unable to find AOT processed dir %s, however BP_SPRING_AOT_ENABLED has been set to true. Ensure that your app is AOT processed", dir)
 	}
 
-	cdsTrainingJavaToolOptions := sherpa.GetEnvWithDefault("CDS_TRAINING_JAVA_TOOL_OPTIONS", "")
 	if trainingRun || aotEnabled {
 
-		helpers = append(helpers, "performance")
+		performanceConfig, err := NewPerformanceConfig()
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to resolve performance configuration\n%w", err)
+		}
+
+		// the optimizations are only checked, the processes and the helper are left unchanged
+		if !performanceConfig.CheckOnly {
+			helpers = append(helpers, "performance")
+		}
 
-		cdsTrainingJavaToolOptionsProvided := cdsTrainingJavaToolOptions != ""
-		if cdsTrainingJavaToolOptionsProvided && trainingRun && aotEnabled {
+		if performanceConfig.TrainingJavaToolOptions != "" && trainingRun && aotEnabled {
 			b.Logger.Infof(color.RedString("ERROR: CDS_TRAINING_JAVA_TOOL_OPTIONS is not compatible with BP_SPRING_AOT_ENABLED - as the AOT classes used during training run won't be compatible with a different set of JAVA_TOOL_OPTIONS at runtime \n" +
 				"The Spring team explains this issue in detail here: https://github.com/spring-projects/spring-boot/issues/41348 \n" +
 				"If you need to provide CDS_TRAINING_JAVA_TOOL_OPTIONS (to disable a connection to a remote service for example), you need to disable BP_SPRING_AOT_ENABLED "))
 			return libcnb.BuildResult{}, fmt.Errorf("build failed because of invalid user configuration")
 		}
-		if !cdsTrainingJavaToolOptionsProvided {
-			// in case CDS_TRAINING_JAVA_TOOL_OPTIONS was not set, we pick up JAVA_TOOL_OPTIONS by default
-			cdsTrainingJavaToolOptions = sherpa.GetEnvWithDefault("JAVA_TOOL_OPTIONS", "")
-		}
 
 		if trainingRun {
-			mainClass, _ = manifest.Get("Start-Class")
-			classpathString = "runner.jar"
-			if len(additionalLibs) > 0 {
-				cpLibs := []string{}
-				for _, lib := range additionalLibs {
-					cpLibs = append(cpLibs, fmt.Sprintf(":%s", "lib/"+lib))
-				}
-				classpathString = fmt.Sprintf(classpathString+"%s", strings.Join(cpLibs, ""))
+			classpath = []string{ApplicationArchiveName(context.Application.Path, manifest)}
+			for _, lib := range additionalLibs {
+				classpath = append(classpath, "lib/"+lib)
+			}
+			classpathString = strings.Join(classpath, string(filepath.ListSeparator))
+
+			// a check leaves the application unchanged, and the processes with the launcher they have without training run
+			if !performanceConfig.CheckOnly {
+				launchExtracted = true
+				mainClass, _ = manifest.Get("Start-Class")
+				// the JVM only maps the archive if the classpath at launch starts with the one of the training run
+				launch := slices.Concat(ResolveClasspathEntries(context.Application.Path, performanceConfig.ClasspathPrepend), classpath)
+				launchClasspathString = strings.Join(launch, string(filepath.ListSeparator))
 			}
 		}
 
-		cdsLayer := NewSpringPerformance(dc, context.Application.Path, manifest, aotEnabled, trainingRun, classpathString, reZipExplodedJar, cdsTrainingJavaToolOptions)
+		cdsLayer := NewSpringPerformance(dc, context.Application.Path, manifest, aotEnabled, trainingRun, classpathString, performanceConfig)
 		cdsLayer.Logger = b.Logger
+		cdsLayer.GenerateAOT = aotGenerate
+		cdsLayer.Classpath = classpath
 		result.Layers = append(result.Layers, cdsLayer)
 
 	}
@@ -280,7 +294,7 @@ func (b Build) Build(context libcnb.BuildContext) (libcnb.BuildResult, error) {
 	at.Logger = b.Logger
 	result.Layers = append(result.Layers, at)
 
-	if !trainingRun {
+	if !launchExtracted {
 		// Slices
 		if index, ok := manifest.Get("Spring-Boot-Layers-Index"); ok {
 			b.Logger.Header("Creating slices from layers index")
@@ -294,9 +308,9 @@ func (b Build) Build(context libcnb.BuildContext) (libcnb.BuildResult, error) {
 		result = b.contributeHelpers(context, result, helpers)
 	}
 
-	if bootJarFound || trainingRun {
+	if bootJarFound || launchExtracted {
 		if mainClass != "" {
-			result.Processes = append(result.Processes, b.setProcessTypes(mainClass, classpathString)...)
+			result.Processes = append(result.Processes, b.setProcessTypes(mainClass, launchClasspathString)...)
 		} else {
 			return libcnb.BuildResult{}, fmt.Errorf("error finding Main-Class or Start-Class manifest entry for Process Type")
 		}
@@ -589,7 +603,7 @@ func (b *Build) findSpringBootExecutableJAR(appPath string) (string, *properties
 			return nil
 		}
 
-		fullPath := appPath + "/" + path
+		fullPath := filepath.Join(appPath, filepath.FromSlash(path))
 		// get the MANIFEST of the JAR file
 		props, err = libjvm.NewManifestFromJAR(fullPath)
 		if err != nil {
@@ -614,7 +628,7 @@ func (b *Build) findSpringBootExecutableJAR(appPath string) (string, *properties
 		return "", nil, err
 	}
 
-	tempExplodedJar := os.TempDir() + "/" + fmt.Sprint(time.Now().UnixMilli()) + "/"
+	tempExplodedJar := filepath.Join(os.TempDir(), fmt.Sprint(time.Now().UnixMilli()))
 
 	jar, err := os.Open(jarPath)
 	if err != nil {
@@ -622,7 +636,9 @@ func (b *Build) findSpringBootExecutableJAR(appPath string) (string, *properties
 	}
 	defer jar.Close()
 	crush.Extract(jar, tempExplodedJar, 0)
-	os.RemoveAll(appPath)
+	if err := RemoveApplication(appPath); err != nil {
+		return "", nil, err
+	}
 	sherpa.CopyDir(tempExplodedJar, appPath)
 	jarPath = appPath
 
@@ -689,6 +689,77 @@ Spring-Boot-Lib: BOOT-INF/lib
 			Expect(result.Layers).To(HaveLen(3))
 			Expect(result.Layers[2].Name()).To(Equal("helper"))
 			Expect(result.Layers[2].(libpak.HelperLayerContributor).Names).To(Equal([]string{"performance"}))
+			Expect(result.Layers[0].(boot.SpringPerformance).Classpath).To(Equal([]string{"runner.jar"}))
+			Expect(result.Layers[0].(boot.SpringPerformance).ClasspathString).To(Equal("runner.jar"))
+		})
+
+		it("starts the classpath of the processes with BP_JVM_CDS_CLASSPATH_PREPEND", func() {
+			t.Setenv("BP_JVM_CDS_CLASSPATH_PREPEND", "/stubs.jar:stubs")
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "META-INF", "MANIFEST.MF"), []byte(`
+			Spring-Boot-Version: 3.3.1
+			Start-Class: test-class
+			Spring-Boot-Classes: BOOT-INF/classes
+			Spring-Boot-Lib: BOOT-INF/lib
+			`), 0644)).To(Succeed())
+
+			result, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			classpath := "/stubs.jar:" + filepath.Join(ctx.Application.Path, "stubs") + ":runner.jar"
+			Expect(result.Processes).To(ContainElement(
+				libcnb.Process{Type: "web", Command: "java", Arguments: []string{"-cp", classpath, "test-class"}, Direct: true, Default: true},
+			))
+			Expect(result.Layers[0].(boot.SpringPerformance).ClasspathString).To(Equal("runner.jar"))
+		})
+
+		it("leaves the processes unchanged with BP_SPRING_PERFORMANCE_CHECK_ONLY", func() {
+			t.Setenv("BP_SPRING_PERFORMANCE_CHECK_ONLY", "true")
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "META-INF", "MANIFEST.MF"), []byte(`
+			Main-Class: org.springframework.boot.loader.launch.JarLauncher
+			Spring-Boot-Version: 3.3.1
+			Start-Class: test-class
+			Spring-Boot-Classes: BOOT-INF/classes
+			Spring-Boot-Lib: BOOT-INF/lib
+			`), 0644)).To(Succeed())
+
+			result, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[0].Name()).To(Equal("Performance"))
+			Expect(result.Processes).To(BeEmpty())
+			for _, l := range result.Layers {
+				if h, ok := l.(libpak.HelperLayerContributor); ok {
+					Expect(h.Names).NotTo(ContainElement("performance"))
+				}
+			}
+		})
+
+		it("keeps the launcher of a jar'ed app with BP_SPRING_PERFORMANCE_CHECK_ONLY", func() {
+			t.Setenv("BP_SPRING_PERFORMANCE_CHECK_ONLY", "true")
+			Copy("cds", "spring-app-3.3-no-dependencies.jar", "")
+
+			result, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Processes).To(ContainElement(
+				libcnb.Process{Type: "web", Command: "java", Arguments: []string{"org.springframework.boot.loader.launch.JarLauncher"}, Direct: true, Default: true},
+			))
+		})
+
+		it("uses runner.war as the classpath of a war", func() {
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "WEB-INF", "lib"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "META-INF", "MANIFEST.MF"), []byte(`
+			Spring-Boot-Version: 3.3.1
+			Start-Class: test-class
+			Spring-Boot-Classes: WEB-INF/classes/
+			Spring-Boot-Lib: WEB-INF/lib/
+			`), 0644)).To(Succeed())
+
+			result, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[0].(boot.SpringPerformance).Classpath).To(Equal([]string{"runner.war"}))
+			Expect(result.Layers[0].(boot.SpringPerformance).ClasspathString).To(Equal("runner.war"))
 		})
 
 		it("contributes CDS layer & helper for Boot 3.3+ apps even when they're jar'ed", func() {
@@ -735,6 +806,23 @@ Spring-Boot-Lib: BOOT-INF/lib
 			Expect(result.Layers[2].(libpak.HelperLayerContributor).Names).To(Equal([]string{"performance"}))
 		})
 
+		it("generates the AOT classes of an application that is not AOT processed with BP_SPRING_AOT_GENERATE", func() {
+			t.Setenv("BP_SPRING_AOT_ENABLED", "true")
+			t.Setenv("BP_SPRING_AOT_GENERATE", "true")
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "META-INF", "MANIFEST.MF"), []byte(`
+			Spring-Boot-Version: 3.3.1
+			Start-Class: test-class
+			Spring-Boot-Classes: BOOT-INF/classes
+			Spring-Boot-Lib: BOOT-INF/lib
+			`), 0644)).To(Succeed())
+
+			result, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[0].(boot.SpringPerformance).GenerateAOT).To(BeTrue())
+			Expect(result.Layers[0].(boot.SpringPerformance).AotEnabled).To(BeTrue())
+		})
+
 		it("fails the build because CDS_TRAINING_JAVA_TOOL_OPTIONS was provided with BP_SPRING_AOT_ENABLED", func() {
 			t.Setenv("BP_SPRING_AOT_ENABLED", "true")
 			t.Setenv("CDS_TRAINING_JAVA_TOOL_OPTIONS", "user-cds-opt")
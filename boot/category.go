@@ -0,0 +1,113 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"errors"
+)
+
+// Category is the kind of a failure of the contribution of SpringPerformance, for platforms acting on it, such as
+// retrying a build only on TrainingFailed. A Category is an error, so that errors.Is(err, TrainingFailed) reports
+// whether err is of the category.
+type Category int
+
+const (
+	// ExtractFailed is a failure to extract or verify the layout of the application jar.
+	ExtractFailed Category = iota + 1
+
+	// TrainingFailed is a failure of the training run.
+	TrainingFailed
+
+	// ValidationFailed is an application or a configuration that cannot be trained.
+	ValidationFailed
+
+	// ArchiveMissing is a training run that succeeded without creating the CDS archive.
+	ArchiveMissing
+
+	// JavaNotFound is a java command that cannot be found to extract the application or to perform the training run.
+	JavaNotFound
+
+	// ArchiveRejected is a CDS archive that the JVM rejects when the application is launched as at runtime.
+	ArchiveRejected
+)
+
+func (c Category) Error() string {
+	return c.String()
+}
+
+func (c Category) String() string {
+	switch c {
+	case ExtractFailed:
+		return "ExtractFailed"
+	case TrainingFailed:
+		return "TrainingFailed"
+	case ValidationFailed:
+		return "ValidationFailed"
+	case ArchiveMissing:
+		return "ArchiveMissing"
+	case JavaNotFound:
+		return "JavaNotFound"
+	case ArchiveRejected:
+		return "ArchiveRejected"
+	default:
+		return "Unknown"
+	}
+}
+
+// CategoryError is an error of a Category.
+type CategoryError struct {
+	Err      error
+	Category Category
+}
+
+// WithCategory returns err of category, or nil if err is nil.
+func WithCategory(err error, category Category) error {
+	if err == nil {
+		return nil
+	}
+	return CategoryError{Err: err, Category: category}
+}
+
+func (c CategoryError) Error() string {
+	return c.Err.Error()
+}
+
+func (c CategoryError) Unwrap() error {
+	return c.Err
+}
+
+func (c CategoryError) Is(target error) bool {
+	return target == c.Category
+}
+
+// executionCategory returns the category of the failure err of a java command, JavaNotFound if the command cannot be
+// found and category otherwise.
+func executionCategory(err error, category Category) Category {
+	if commandNotFound(err) {
+		return JavaNotFound
+	}
+	return category
+}
+
+// ErrorCategory returns the category of the first CategoryError wrapped by err, if any.
+func ErrorCategory(err error) (Category, bool) {
+	var c CategoryError
+	if errors.As(err, &c) {
+		return c.Category, true
+	}
+	return 0, false
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testCategory(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("matches the category of a wrapped error", func() {
+		err := fmt.Errorf("unable to contribute\n%w", boot.WithCategory(errors.New("test-error"), boot.TrainingFailed))
+
+		Expect(errors.Is(err, boot.TrainingFailed)).To(BeTrue())
+		Expect(errors.Is(err, boot.ExtractFailed)).To(BeFalse())
+		Expect(err).To(MatchError("unable to contribute\ntest-error"))
+
+		var c boot.CategoryError
+		Expect(errors.As(err, &c)).To(BeTrue())
+		Expect(c.Category).To(Equal(boot.TrainingFailed))
+	})
+
+	it("keeps the hint of the error", func() {
+		err := boot.WithCategory(boot.WithHint(errors.New("test-error"), boot.HintJDK), boot.JavaNotFound)
+
+		hint, ok := boot.ErrorHint(err)
+		Expect(ok).To(BeTrue())
+		Expect(hint).To(Equal(boot.HintJDK))
+	})
+
+	it("returns the category of the outermost error", func() {
+		err := boot.WithCategory(boot.WithCategory(errors.New("test-error"), boot.ExtractFailed), boot.ValidationFailed)
+
+		category, ok := boot.ErrorCategory(err)
+		Expect(ok).To(BeTrue())
+		Expect(category).To(Equal(boot.ValidationFailed))
+	})
+
+	it("returns no category for an error without", func() {
+		_, ok := boot.ErrorCategory(errors.New("test-error"))
+		Expect(ok).To(BeFalse())
+		Expect(boot.WithCategory(nil, boot.TrainingFailed)).To(BeNil())
+	})
+
+	it("names the categories", func() {
+		Expect(boot.ArchiveMissing.String()).To(Equal("ArchiveMissing"))
+		Expect(boot.JavaNotFound.Error()).To(Equal("JavaNotFound"))
+		Expect(boot.ArchiveRejected.String()).To(Equal("ArchiveRejected"))
+		Expect(boot.Category(0).String()).To(Equal("Unknown"))
+	})
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	CDSStaticArchiveMagic  uint32 = 0xf00baba2
+	CDSDynamicArchiveMagic uint32 = 0xf00baba8
+
+	// DefaultLaunchDir is the location of the application in the run image.
+	DefaultLaunchDir = "/workspace"
+
+	// BundledArchiveName is the location in the application of a CDS archive bundled with it.
+	BundledArchiveName = "META-INF/cds/application.jsa"
+
+	// CDSActiveMetadata is the layer metadata recording whether the CDS archive is used at launch, true only if the
+	// archive exists and the launch environment enables it.
+	CDSActiveMetadata = "cds_active"
+
+	// cdsArchiveHeaderSize is the size of the start of a CDS archive holding its header.
+	cdsArchiveHeaderSize = 4096
+)
+
+// ReadCDSArchiveMagic returns the magic number found at the start of the CDS archive at path.
+func ReadCDSArchiveMagic(path string) (uint32, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("unable to open %s\n%w", path, err)
+	}
+	defer in.Close()
+
+	var magic uint32
+	if err := binary.Read(in, binary.NativeEndian, &magic); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return 0, fmt.Errorf("%s is too small to be a CDS archive", path)
+	} else if err != nil {
+		return 0, fmt.Errorf("unable to read %s\n%w", path, err)
+	}
+	return magic, nil
+}
+
+// ValidateBaseArchive checks that the archive at path can be used as the base of a dynamic CDS archive.
+//
+// The JVM only layers dynamic archives on top of a static archive, so a previous dynamic archive (such as an
+// application.jsa from an earlier training run) cannot be used as a base.
+func ValidateBaseArchive(path string) error {
+	magic, err := ReadCDSArchiveMagic(path)
+	if err != nil {
+		return err
+	}
+
+	switch magic {
+	case CDSStaticArchiveMagic:
+		return nil
+	case CDSDynamicArchiveMagic:
+		return fmt.Errorf("%s is a dynamic CDS archive, only static archives can be used as a base", path)
+	default:
+		return fmt.Errorf("%s is not a CDS archive", path)
+	}
+}
+
+// ValidateArchiveJDK checks that the CDS archive at path was created by a JVM of the given version, such as 21.0.3.
+//
+// The header of the archive identifies the JVM that created it, for instance OpenJDK 64-Bit Server VM (21.0.3+9-LTS),
+// and another JVM disables the archive at launch.
+func ValidateArchiveJDK(path string, version string) error {
+	magic, err := ReadCDSArchiveMagic(path)
+	if err != nil {
+		return err
+	} else if magic != CDSStaticArchiveMagic && magic != CDSDynamicArchiveMagic {
+		return fmt.Errorf("%s is not a CDS archive", path)
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open %s\n%w", path, err)
+	}
+	defer in.Close()
+
+	header, err := io.ReadAll(io.LimitReader(in, cdsArchiveHeaderSize))
+	if err != nil {
+		return fmt.Errorf("unable to read %s\n%w", path, err)
+	}
+
+	// the version is followed by its build number, its pre-release identifier or the end of the identification
+	for _, suffix := range []string{"+", "-", ")"} {
+		if bytes.Contains(header, []byte("("+version+suffix)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s was not created by JDK %s", path, version)
+}
+
+// ValidateArchiveChain checks that the dynamic archive at overlay can be layered on top of the static archive at base.
+//
+// The JVM cannot merge CDS archives into a single file. A dynamic archive is instead loaded on top of the static archive
+// it was created from, by listing both in the -XX:SharedArchiveFile option, as returned by ArchiveChain. Only one
+// dynamic archive can be layered on a static archive, so per module archives cannot be chained together.
+func ValidateArchiveChain(base string, overlay string) error {
+	if err := ValidateBaseArchive(base); err != nil {
+		return fmt.Errorf("invalid base archive\n%w", err)
+	}
+
+	magic, err := ReadCDSArchiveMagic(overlay)
+	if err != nil {
+		return fmt.Errorf("invalid overlay archive\n%w", err)
+	}
+	switch magic {
+	case CDSDynamicArchiveMagic:
+		return nil
+	case CDSStaticArchiveMagic:
+		return fmt.Errorf("%s is a static CDS archive, only a dynamic archive can be layered on %s", overlay, base)
+	default:
+		return fmt.Errorf("%s is not a CDS archive", overlay)
+	}
+}
+
+// ArchiveChain returns the value of the -XX:SharedArchiveFile option loading the dynamic archive at overlay on top of
+// the static archive at base.
+func ArchiveChain(base string, overlay string) string {
+	return base + string(filepath.ListSeparator) + overlay
+}
+
+// ValidateArchivePortability checks that an archive created by a training run of the application at buildDir can be
+// used when the application is launched from launchDir.
+//
+// The archive records the paths of the classpath it was created with and the JVM silently disables it at launch when
+// they do not match, so the application must be at the same location in the build and the run images.
+func ValidateArchivePortability(buildDir string, launchDir string) error {
+	if filepath.Clean(buildDir) != filepath.Clean(launchDir) {
+		return fmt.Errorf("the archive records the classpath of the application at %s, it will be ignored unless the application is launched from the same location instead of %s", buildDir, launchDir)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testCDSArchive(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dir  string
+		path string
+	)
+
+	it.Before(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "cds-archive")
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(dir, "base.jsa")
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	writeArchive := func(magic uint32) {
+		content := binary.NativeEndian.AppendUint32(nil, magic)
+		Expect(os.WriteFile(path, append(content, 0, 0, 0, 0), 0644)).To(Succeed())
+	}
+
+	context("ValidateArchiveJDK", func() {
+		bundled := filepath.Join("testdata", "cds", "bundled", "META-INF", "cds", "application.jsa")
+
+		it("accepts an archive created by the JDK", func() {
+			Expect(boot.ValidateArchiveJDK(bundled, "21.0.3")).To(Succeed())
+		})
+
+		it("rejects an archive created by another JDK", func() {
+			Expect(boot.ValidateArchiveJDK(bundled, "21.0")).To(MatchError(ContainSubstring("was not created by JDK 21.0")))
+			Expect(boot.ValidateArchiveJDK(bundled, "17.0.11")).To(MatchError(ContainSubstring("was not created by JDK 17.0.11")))
+		})
+
+		it("rejects a file that is not an archive", func() {
+			writeArchive(0xcafebabe)
+
+			Expect(boot.ValidateArchiveJDK(path, "21.0.3")).To(MatchError(ContainSubstring("is not a CDS archive")))
+		})
+	})
+
+	it("accepts a static archive", func() {
+		writeArchive(boot.CDSStaticArchiveMagic)
+
+		Expect(boot.ValidateBaseArchive(path)).To(Succeed())
+	})
+
+	it("rejects a dynamic archive", func() {
+		writeArchive(boot.CDSDynamicArchiveMagic)
+
+		Expect(boot.ValidateBaseArchive(path)).To(MatchError(ContainSubstring("is a dynamic CDS archive")))
+	})
+
+	it("rejects a file that is not an archive", func() {
+		writeArchive(0xcafebabe)
+
+		Expect(boot.ValidateBaseArchive(path)).To(MatchError(ContainSubstring("is not a CDS archive")))
+	})
+
+	it("rejects a truncated file", func() {
+		Expect(os.WriteFile(path, []byte{0xa2}, 0644)).To(Succeed())
+
+		Expect(boot.ValidateBaseArchive(path)).To(MatchError(ContainSubstring("too small")))
+	})
+
+	it("rejects a missing file", func() {
+		Expect(boot.ValidateBaseArchive(path)).To(MatchError(ContainSubstring("unable to open")))
+	})
+
+	context("ValidateArchiveChain", func() {
+		var overlay string
+
+		it.Before(func() {
+			overlay = filepath.Join(dir, "application.jsa")
+			content := binary.NativeEndian.AppendUint32(nil, boot.CDSDynamicArchiveMagic)
+			Expect(os.WriteFile(overlay, append(content, 0, 0, 0, 0), 0644)).To(Succeed())
+		})
+
+		it("accepts a dynamic archive on top of a static archive", func() {
+			writeArchive(boot.CDSStaticArchiveMagic)
+
+			Expect(boot.ValidateArchiveChain(path, overlay)).To(Succeed())
+			Expect(boot.ArchiveChain(path, overlay)).To(Equal(path + string(filepath.ListSeparator) + overlay))
+		})
+
+		it("rejects a dynamic base archive", func() {
+			writeArchive(boot.CDSDynamicArchiveMagic)
+
+			Expect(boot.ValidateArchiveChain(path, overlay)).To(MatchError(ContainSubstring("invalid base archive")))
+		})
+
+		it("rejects a static overlay archive", func() {
+			writeArchive(boot.CDSStaticArchiveMagic)
+
+			Expect(boot.ValidateArchiveChain(path, path)).To(MatchError(ContainSubstring("is a static CDS archive")))
+		})
+
+		it("rejects an overlay that is not an archive", func() {
+			writeArchive(boot.CDSStaticArchiveMagic)
+			Expect(os.WriteFile(overlay, []byte{0xca, 0xfe, 0xba, 0xbe}, 0644)).To(Succeed())
+
+			Expect(boot.ValidateArchiveChain(path, overlay)).To(MatchError(ContainSubstring("is not a CDS archive")))
+		})
+
+		it("rejects a missing overlay", func() {
+			writeArchive(boot.CDSStaticArchiveMagic)
+
+			Expect(boot.ValidateArchiveChain(path, filepath.Join(dir, "missing.jsa"))).To(MatchError(ContainSubstring("invalid overlay archive")))
+		})
+	})
+
+	context("ValidateArchivePortability", func() {
+		it("accepts the same location", func() {
+			Expect(boot.ValidateArchivePortability("/workspace/", boot.DefaultLaunchDir)).To(Succeed())
+		})
+
+		it("detects a different location", func() {
+			Expect(boot.ValidateArchivePortability("/tmp/app", boot.DefaultLaunchDir)).
+				To(MatchError(ContainSubstring("the archive records the classpath of the application at /tmp/app")))
+		})
+	})
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+// check validates that the contribution would succeed, without modifying the application nor contributing the layer:
+// the layout, the manifest and the Spring Boot version are checked, and a copy of the application is re-zipped and
+// extracted into a temporary directory, removed once checked, to validate the classpath of the training run. A jar is
+// extracted as is.
+func (s SpringPerformance) check() error {
+	if err := ValidateApplicationLayout(s.AppPath, s.ReZip); err != nil {
+		return WithCategory(fmt.Errorf("invalid application layout\n%w", err), ValidationFailed)
+	}
+	if _, ok, err := ManifestValue(s.Manifest, "Start-Class"); err != nil {
+		return WithCategory(fmt.Errorf("invalid application manifest\n%w", err), ValidationFailed)
+	} else if !ok {
+		return WithCategory(WithHint(fmt.Errorf("manifest does not contain Start-Class"), HintStartClass), ValidationFailed)
+	}
+	if !s.DoTrainingRun {
+		return nil
+	}
+
+	if version, _ := s.Manifest.Get("Spring-Boot-Version"); !bootCDSExtractionSupported(version) {
+		return WithCategory(WithHint(fmt.Errorf("Spring Boot %q does not support the CDS extraction", version), HintJarMode), ValidationFailed)
+	}
+
+	libs, err := s.classpathIndex()
+	if err != nil {
+		return err
+	}
+
+	dir, err := s.temp.MkdirTemp("spring-performance-check")
+	if err != nil {
+		return fmt.Errorf("unable to create temp directory for the check\n%w", err)
+	}
+
+	// the copy is completed and extracted as the application would be, the application is left unchanged
+	staged := s
+	staged.AppPath = filepath.Join(dir, "application")
+	jarPath := s.AppPath
+	if s.ReZip && !s.applicationJar() {
+		if err := sherpa.CopyDir(s.AppPath, staged.AppPath); err != nil {
+			return fmt.Errorf("unable to copy %s\n%w", s.AppPath, err)
+		}
+		if err := staged.ensureLauncherMainClass(); err != nil {
+			return fmt.Errorf("error reconstructing jar manifest\n%w", err)
+		}
+		jarPath = filepath.Join(dir, ApplicationArchiveName(s.AppPath, s.Manifest))
+		if err := CreateJar(staged.AppPath+string(filepath.Separator), jarPath, s.Config.Timestamp()); err != nil {
+			return fmt.Errorf("error recreating jar\n%w", err)
+		}
+	}
+
+	staged.AppPath = filepath.Join(dir, "extracted")
+	if err := os.MkdirAll(staged.AppPath, 0755); err != nil {
+		return fmt.Errorf("unable to create %s\n%w", staged.AppPath, err)
+	}
+	if err := staged.springBootJarCDSLayoutExtract(s.Config.JavaCommand(), jarPath); err != nil {
+		return WithCategory(fmt.Errorf("error extracting Boot jar at %s\n%w", jarPath, err), executionCategory(err, ExtractFailed))
+	}
+	if _, err := staged.extractedClasspath(filepath.Base(jarPath), libs); err != nil {
+		return WithCategory(fmt.Errorf("invalid training run classpath\n%w", err), ValidationFailed)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+// CountClasses returns the number of classes in the entries of classpath, the jars and the directories of classes.
+// Relative entries are resolved against dir, missing entries are skipped.
+func CountClasses(dir string, classpath []string) (int, error) {
+	count := 0
+	for _, entry := range classpath {
+		file := entry
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, entry)
+		}
+
+		// the JVM ignores the entries of the classpath that do not exist
+		if exists, err := sherpa.Exists(file); err != nil {
+			return 0, fmt.Errorf("unable to check for %s\n%w", file, err)
+		} else if !exists {
+			continue
+		}
+
+		n, err := countEntryClasses(file)
+		if err != nil {
+			return 0, err
+		}
+		count += n
+	}
+	return count, nil
+}
+
+// countEntryClasses returns the number of classes in the jar or the directory file.
+func countEntryClasses(file string) (int, error) {
+	count := 0
+	if isDir, err := sherpa.DirExists(file); err != nil {
+		return 0, fmt.Errorf("unable to check for %s\n%w", file, err)
+	} else if isDir {
+		err := filepath.WalkDir(file, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(d.Name(), ".class") {
+				count++
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, fmt.Errorf("unable to walk %s\n%w", file, err)
+		}
+		return count, nil
+	}
+
+	r, err := zip.OpenReader(file)
+	if err != nil {
+		return 0, fmt.Errorf("unable to open %s\n%w", file, err)
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if strings.HasSuffix(f.Name, ".class") {
+			count++
+		}
+	}
+	return count, nil
+}
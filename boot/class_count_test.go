@@ -0,0 +1,66 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testClassCount(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dir string
+	)
+
+	it.Before(func() {
+		dir = t.TempDir()
+	})
+
+	it("counts the classes of the jars and the directories", func() {
+		Expect(os.MkdirAll(filepath.Join(dir, "lib"), 0755)).To(Succeed())
+		writeJarEntries(t, filepath.Join(dir, "lib", "spring-core-6.1.10.jar"), map[string]string{
+			"META-INF/MANIFEST.MF":                       "",
+			"org/springframework/core/Ordered.class":     "",
+			"org/springframework/core/SpringBoot.class":  "",
+			"org/springframework/core/spring.properties": "",
+		})
+		Expect(os.MkdirAll(filepath.Join(dir, "classes", "com", "example"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "classes", "com", "example", "Application.class"), []byte{}, 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "classes", "application.properties"), []byte{}, 0644)).To(Succeed())
+
+		Expect(boot.CountClasses(dir, []string{"lib/spring-core-6.1.10.jar", filepath.Join(dir, "classes")})).To(Equal(3))
+	})
+
+	it("skips the missing entries", func() {
+		Expect(boot.CountClasses(dir, []string{"lib/missing.jar"})).To(Equal(0))
+	})
+
+	it("fails with an entry that is not a jar", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "runner.jar"), []byte("not a jar"), 0644)).To(Succeed())
+
+		_, err := boot.CountClasses(dir, []string{"runner.jar"})
+		Expect(err).To(MatchError(ContainSubstring("unable to open")))
+	})
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/paketo-buildpacks/libjvm"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+	"gopkg.in/yaml.v3"
+)
+
+// ClasspathIndexName is the location of the classpath index in a Spring Boot jar, listing its libraries in the order
+// of the classpath of the build.
+const ClasspathIndexName = "BOOT-INF/classpath.idx"
+
+// BuildClasspath returns the classpath of a layout extracted with the tools jarmode: the application jar, or war, at
+// the root of appPath, followed by the entries of its Class-Path manifest attribute. The jarmode writes that attribute
+// in the order defined by the classpath.idx of the original jar, so the classpath is stable from one build to the next.
+func BuildClasspath(appPath string) (string, error) {
+	var jars []string
+	for _, pattern := range []string{"*.jar", "*.war"} {
+		matches, err := filepath.Glob(filepath.Join(appPath, pattern))
+		if err != nil {
+			return "", fmt.Errorf("unable to list jars in %s\n%w", appPath, err)
+		}
+		jars = append(jars, matches...)
+	}
+	if len(jars) != 1 {
+		return "", fmt.Errorf("expected a single application jar in %s, found %d", appPath, len(jars))
+	}
+
+	manifest, err := libjvm.NewManifestFromJAR(jars[0])
+	if err != nil {
+		return "", fmt.Errorf("unable to read manifest of %s\n%w", jars[0], err)
+	}
+
+	entries := []string{filepath.Base(jars[0])}
+	classPath, _ := manifest.Get("Class-Path")
+	for _, entry := range strings.Fields(classPath) {
+		entry, err = url.PathUnescape(entry)
+		if err != nil {
+			return "", fmt.Errorf("unable to decode Class-Path entry %s\n%w", entry, err)
+		}
+
+		if _, err := os.Stat(filepath.Join(appPath, entry)); err != nil {
+			return "", fmt.Errorf("unable to find Class-Path entry %s\n%w", entry, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return strings.Join(entries, string(filepath.ListSeparator)), nil
+}
+
+// ParseClasspathIndex returns the jars listed by the classpath index of the exploded application at appPath, in the
+// order of the index, relative to appPath. It returns nil if the application has no index.
+func ParseClasspathIndex(appPath string) ([]string, error) {
+	file := filepath.Join(appPath, ClasspathIndexName)
+	if exists, err := sherpa.FileExists(file); err != nil {
+		return nil, fmt.Errorf("unable to check for %s\n%w", file, err)
+	} else if !exists {
+		return nil, nil
+	}
+	return parseClasspathIndex(appPath, ClasspathIndexName, "BOOT-INF/lib")
+}
+
+// parseClasspathIndex returns the jars listed by the classpath index at index in the exploded application at appPath,
+// relative to appPath. The jars listed by name, by Spring Boot 2.3.0.M4 to 2.4.2, are in libDir.
+func parseClasspathIndex(appPath string, index string, libDir string) ([]string, error) {
+	file := filepath.Join(appPath, index)
+	in, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s\n%w", file, err)
+	}
+	defer in.Close()
+
+	var libs []string
+	if err := yaml.NewDecoder(in).Decode(&libs); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("unable to decode %s\n%w", file, err)
+	}
+
+	var jars []string
+	for _, l := range libs {
+		if dir, _ := path.Split(l); dir == "" {
+			jars = append(jars, path.Join(libDir, l))
+		} else {
+			jars = append(jars, l)
+		}
+	}
+	return jars, nil
+}
+
+// OrderClasspath returns the entries of classpath in a stable order: the entries of index first, in the order of index
+// such as the one of the classpath.idx of a jar, then the other entries sorted lexically. The order then does not
+// depend on the order in which the entries were found, and duplicated entries are removed.
+func OrderClasspath(classpath []string, index []string) []string {
+	var ordered, others []string
+	for _, entry := range index {
+		if slices.Contains(classpath, entry) && !slices.Contains(ordered, entry) {
+			ordered = append(ordered, entry)
+		}
+	}
+	for _, entry := range classpath {
+		if !slices.Contains(ordered, entry) && !slices.Contains(others, entry) {
+			others = append(others, entry)
+		}
+	}
+	slices.Sort(others)
+	return append(ordered, others...)
+}
+
+// IsLoaderEntry returns whether a classpath entry contains the Spring Boot loader: the spring-boot-loader library or the
+// spring-boot-loader directory of a layertools layout.
+func IsLoaderEntry(entry string) bool {
+	name := filepath.Base(filepath.Clean(entry))
+	return name == "spring-boot-loader" || name == "spring-boot-loader.jar" ||
+		(strings.HasPrefix(name, "spring-boot-loader-") && strings.HasSuffix(name, ".jar"))
+}
+
+// ExcludeLoader returns classpath without the entries containing the Spring Boot loader, which is not needed when the
+// start class is launched directly.
+func ExcludeLoader(classpath []string) []string {
+	var entries []string
+	for _, entry := range classpath {
+		if !IsLoaderEntry(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// ResolveClasspathEntries returns entries with the relative ones resolved against appPath, as they are put on the
+// classpath of the training run and, for BP_JVM_CDS_CLASSPATH_PREPEND, on the classpath of the application at launch.
+func ResolveClasspathEntries(appPath string, entries []string) []string {
+	var resolved []string
+	for _, entry := range entries {
+		if !filepath.IsAbs(entry) {
+			entry = filepath.Join(appPath, entry)
+		}
+		resolved = append(resolved, entry)
+	}
+	return resolved
+}
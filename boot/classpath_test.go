@@ -0,0 +1,183 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testClasspath(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appPath string
+	)
+
+	it.Before(func() {
+		var err error
+		appPath, err = os.MkdirTemp("", "classpath")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(appPath, "lib"), 0755)).To(Succeed())
+		for _, jar := range []string{"spring-core-6.1.10.jar", "spring-boot-3.3.1.jar", "my lib-1.0.0.jar"} {
+			Expect(os.WriteFile(filepath.Join(appPath, "lib", jar), []byte{}, 0644)).To(Succeed())
+		}
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(appPath)).To(Succeed())
+	})
+
+	context("BuildClasspath", func() {
+		it("orders the classpath as the application jar manifest", func() {
+			writeJarWithManifest(t, filepath.Join(appPath, "runner.jar"), "Manifest-Version: 1.0\n"+
+				"Class-Path: lib/spring-boot-3.3.1.jar lib/spring-core-6.1.10.jar lib/my%\n"+
+				" 20lib-1.0.0.jar\n")
+
+			cp, err := boot.BuildClasspath(appPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cp).To(Equal("runner.jar:lib/spring-boot-3.3.1.jar:lib/spring-core-6.1.10.jar:lib/my lib-1.0.0.jar"))
+		})
+
+		it("accepts an application war", func() {
+			writeJarWithManifest(t, filepath.Join(appPath, "runner.war"), "Class-Path: lib/spring-core-6.1.10.jar\n")
+
+			cp, err := boot.BuildClasspath(appPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cp).To(Equal("runner.war:lib/spring-core-6.1.10.jar"))
+		})
+
+		it("fails when a Class-Path entry is missing", func() {
+			writeJarWithManifest(t, filepath.Join(appPath, "runner.jar"), "Class-Path: lib/missing.jar\n")
+
+			_, err := boot.BuildClasspath(appPath)
+			Expect(err).To(MatchError(ContainSubstring("unable to find Class-Path entry lib/missing.jar")))
+		})
+
+		it("fails without an application jar", func() {
+			_, err := boot.BuildClasspath(appPath)
+			Expect(err).To(MatchError(ContainSubstring("expected a single application jar")))
+		})
+	})
+
+	context("OrderClasspath", func() {
+		it("keeps the order of the index and sorts the other entries", func() {
+			Expect(boot.OrderClasspath(
+				[]string{"runner.jar", "lib/spring-core-6.1.10.jar", "lib/z.jar", "lib/spring-boot-3.3.1.jar", "lib/a.jar", "lib/z.jar"},
+				[]string{"runner.jar", "lib/spring-core-6.1.10.jar", "lib/spring-boot-3.3.1.jar", "lib/absent.jar"},
+			)).To(Equal([]string{"runner.jar", "lib/spring-core-6.1.10.jar", "lib/spring-boot-3.3.1.jar", "lib/a.jar", "lib/z.jar"}))
+		})
+
+		it("orders two builds of the same application identically", func() {
+			build := func(libs ...string) []string {
+				app := t.TempDir()
+				Expect(os.MkdirAll(filepath.Join(app, "lib"), 0755)).To(Succeed())
+				// the libraries are written, and found, in a different order by each build
+				var found []string
+				for _, lib := range libs {
+					Expect(os.WriteFile(filepath.Join(app, "lib", lib), []byte{}, 0644)).To(Succeed())
+					found = append(found, "lib/"+lib)
+				}
+				writeJarWithManifest(t, filepath.Join(app, "runner.jar"), "Class-Path: lib/spring-boot-3.3.1.jar lib/spring-core-6.1.10.jar\n")
+
+				cp, err := boot.BuildClasspath(app)
+				Expect(err).NotTo(HaveOccurred())
+				index := filepath.SplitList(cp)
+				return boot.OrderClasspath(append(index, found...), index)
+			}
+
+			first := build("spring-core-6.1.10.jar", "spring-boot-3.3.1.jar", "bindings.jar", "agent.jar")
+			second := build("agent.jar", "bindings.jar", "spring-boot-3.3.1.jar", "spring-core-6.1.10.jar")
+			Expect(first).To(Equal([]string{"runner.jar", "lib/spring-boot-3.3.1.jar", "lib/spring-core-6.1.10.jar", "lib/agent.jar", "lib/bindings.jar"}))
+			Expect(second).To(Equal(first))
+		})
+	})
+
+	context("ParseClasspathIndex", func() {
+		it("lists the jars in the order of the index", func() {
+			Expect(boot.ParseClasspathIndex(filepath.Join("testdata", "classpath-index"))).To(Equal([]string{
+				"BOOT-INF/lib/spring-boot-3.3.1.jar",
+				"BOOT-INF/lib/spring-core-6.1.10.jar",
+				"BOOT-INF/lib/jackson-databind-2.17.1.jar",
+				"BOOT-INF/lib/spring-context-6.1.10.jar",
+			}))
+		})
+
+		it("lists jars named without a path in BOOT-INF/lib", func() {
+			Expect(os.MkdirAll(filepath.Join(appPath, "BOOT-INF"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(appPath, "BOOT-INF", "classpath.idx"),
+				[]byte("- \"spring-core-6.1.10.jar\"\n- \"spring-boot-3.3.1.jar\"\n"), 0644)).To(Succeed())
+
+			Expect(boot.ParseClasspathIndex(appPath)).To(Equal([]string{
+				"BOOT-INF/lib/spring-core-6.1.10.jar",
+				"BOOT-INF/lib/spring-boot-3.3.1.jar",
+			}))
+		})
+
+		it("returns nothing without an index", func() {
+			Expect(boot.ParseClasspathIndex(appPath)).To(BeNil())
+		})
+
+		it("fails when the index is malformed", func() {
+			Expect(os.MkdirAll(filepath.Join(appPath, "BOOT-INF"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(appPath, "BOOT-INF", "classpath.idx"), []byte("{ not: [a list"), 0644)).To(Succeed())
+
+			_, err := boot.ParseClasspathIndex(appPath)
+			Expect(err).To(MatchError(ContainSubstring("unable to decode")))
+		})
+	})
+
+	context("ExcludeLoader", func() {
+		it("excludes loader jars and directories", func() {
+			Expect(boot.ExcludeLoader([]string{
+				"runner.jar",
+				"lib/spring-boot-loader-3.3.1.jar",
+				"lib/spring-boot-3.3.1.jar",
+				"spring-boot-loader/",
+				"application/",
+				"lib/spring-boot-loader-tools-3.3.1.jar",
+			})).To(Equal([]string{
+				"runner.jar",
+				"lib/spring-boot-3.3.1.jar",
+				"application/",
+			}))
+		})
+	})
+}
+
+func writeJarWithManifest(t *testing.T, path string, manifest string) {
+	Expect := NewWithT(t).Expect
+
+	f, err := os.Create(path)
+	Expect(err).NotTo(HaveOccurred())
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	m, err := w.Create("META-INF/MANIFEST.MF")
+	Expect(err).NotTo(HaveOccurred())
+	_, err = m.Write([]byte(manifest))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(w.Close()).To(Succeed())
+}
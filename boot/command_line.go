@@ -0,0 +1,67 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+const (
+	// DefaultArgMax is the ARG_MAX of Linux with the default 8 MiB stack size limit, used when the limit of the
+	// platform cannot be determined.
+	DefaultArgMax = 2 * 1024 * 1024
+
+	// MaxArgLength is the maximum length of a single argument or environment variable on Linux, MAX_ARG_STRLEN.
+	MaxArgLength = 32 * 4096
+
+	// pointerSize is the size of the argv and envp pointers, counted by execve against ARG_MAX.
+	pointerSize = strconv.IntSize / 8
+)
+
+// CommandLineLength returns the number of bytes execve requires for the arguments and environment of a command: every
+// string, its NUL terminator and its pointer, the command itself being the first argument.
+func CommandLineLength(command string, args []string, env []string) int {
+	length := len(command) + 1 + pointerSize
+	for _, s := range args {
+		length += len(s) + 1 + pointerSize
+	}
+	for _, s := range env {
+		length += len(s) + 1 + pointerSize
+	}
+	return length
+}
+
+// ValidateCommandLine returns an error if executing a command with args and env would fail with E2BIG, either because
+// the command line exceeds argMax or because a single argument exceeds MaxArgLength.
+func ValidateCommandLine(command string, args []string, env []string, argMax int) error {
+	for i, s := range slices.Concat(args, env) {
+		if len(s) > MaxArgLength {
+			kind, index := "argument", i
+			if i >= len(args) {
+				kind, index = "environment variable", i-len(args)
+			}
+			return fmt.Errorf("%s %d of %s is %d bytes long, exceeding the limit of %d bytes", kind, index, command, len(s), MaxArgLength)
+		}
+	}
+
+	if length := CommandLineLength(command, args, env); length > argMax {
+		return fmt.Errorf("command line of %s is %d bytes long, exceeding ARG_MAX of %d bytes", command, length, argMax)
+	}
+	return nil
+}
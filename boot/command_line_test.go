@@ -0,0 +1,64 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testCommandLine(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("counts strings, terminators and pointers", func() {
+		Expect(boot.CommandLineLength("java", []string{"-cp", "a.jar"}, []string{"A=B"})).
+			To(Equal(len("java") + len("-cp") + len("a.jar") + len("A=B") + 4 + 4*8))
+	})
+
+	it("accepts a command line within ARG_MAX", func() {
+		Expect(boot.ValidateCommandLine("java", []string{"-cp", "a.jar"}, nil, boot.DefaultArgMax)).To(Succeed())
+	})
+
+	it("rejects a command line exceeding ARG_MAX", func() {
+		classpath := strings.Repeat("lib/synthetic.jar:", 1000)
+		Expect(boot.ValidateCommandLine("java", []string{"-cp", classpath}, nil, 16*1024)).
+			To(MatchError(ContainSubstring("exceeding ARG_MAX of 16384 bytes")))
+	})
+
+	it("rejects an argument exceeding the single argument limit", func() {
+		classpath := strings.Repeat("a", boot.MaxArgLength+1)
+		Expect(boot.ValidateCommandLine("java", []string{"-cp", classpath}, nil, boot.DefaultArgMax)).
+			To(MatchError(ContainSubstring("argument 1 of java is 131073 bytes long")))
+	})
+
+	it("rejects an environment variable exceeding the single argument limit", func() {
+		env := "JAVA_TOOL_OPTIONS=" + strings.Repeat("a", boot.MaxArgLength)
+		Expect(boot.ValidateCommandLine("java", nil, []string{env}, boot.DefaultArgMax)).
+			To(MatchError(ContainSubstring("environment variable 0 of java")))
+	})
+
+	it("returns an ARG_MAX of at least 128 KiB", func() {
+		Expect(boot.ArgMax()).To(BeNumerically(">=", 128*1024))
+	})
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CompletionMarkerName is the name of the file marking a performance layer whose training run completed.
+const CompletionMarkerName = "spring-performance.complete"
+
+// completion is the content of the completion marker, what a completed contribution applied.
+type completion struct {
+	AOTApplied       bool   `json:"aot_applied"`
+	CDSApplied       bool   `json:"cds_applied"`
+	ArchivePath      string `json:"archive_path,omitempty"`
+	ArchiveSize      int64  `json:"archive_size,omitempty"`
+	ExtractedJar     string `json:"extracted_jar"`
+	ExtractedEntries int    `json:"extracted_entries"`
+	ExtractedBytes   int64  `json:"extracted_bytes"`
+	JDKVersion       string `json:"jdk_version,omitempty"`
+	JDKVendor        string `json:"jdk_vendor,omitempty"`
+	BeanCount        int    `json:"training_bean_count,omitempty"`
+}
+
+// writeCompletion writes the completion marker to layerPath.
+func writeCompletion(layerPath string, c completion) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("unable to encode completion marker\n%w", err)
+	}
+	file := filepath.Join(layerPath, CompletionMarkerName)
+	if err := os.WriteFile(file, b, 0644); err != nil {
+		return fmt.Errorf("unable to write completion marker %s\n%w", file, err)
+	}
+	return nil
+}
+
+// readCompletion reads the completion marker of layerPath, returning false if there is none.
+func readCompletion(layerPath string) (completion, bool, error) {
+	file := filepath.Join(layerPath, CompletionMarkerName)
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return completion{}, false, nil
+	} else if err != nil {
+		return completion{}, false, fmt.Errorf("unable to read completion marker %s\n%w", file, err)
+	}
+
+	var c completion
+	if err := json.Unmarshal(b, &c); err != nil {
+		return completion{}, false, fmt.Errorf("unable to decode completion marker %s\n%w", file, err)
+	}
+	return c, true, nil
+}
+
+// validate checks that the extracted jar and the archive recorded by the marker are still in place, and that the
+// archive is the one expected at archive with the Spring AOT classes enabled as aotEnabled.
+func (c completion) validate(aotEnabled bool, archive string) error {
+	if c.AOTApplied != aotEnabled {
+		return fmt.Errorf("Spring AOT was applied as %t, expected %t", c.AOTApplied, aotEnabled)
+	}
+	if _, err := os.Stat(c.ExtractedJar); err != nil {
+		return fmt.Errorf("unable to find extracted jar %s\n%w", c.ExtractedJar, err)
+	}
+	if !c.CDSApplied {
+		return nil
+	}
+	if c.ArchivePath != archive {
+		return fmt.Errorf("archive was created at %s, expected %s", c.ArchivePath, archive)
+	}
+	info, err := os.Stat(c.ArchivePath)
+	if err != nil {
+		return fmt.Errorf("unable to find archive %s\n%w", c.ArchivePath, err)
+	}
+	if info.Size() != c.ArchiveSize {
+		return fmt.Errorf("archive %s is %d bytes, expected %d", c.ArchivePath, info.Size(), c.ArchiveSize)
+	}
+	return nil
+}
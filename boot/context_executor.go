@@ -0,0 +1,83 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+// InterruptGracePeriod is how long a command interrupted by ContextExecutor may take to exit before it is killed.
+const InterruptGracePeriod = 10 * time.Second
+
+// ContextExecutor runs the executions of Executor until Context is done. The commands of the libpak executors are run
+// without a terminal and interrupted once Context is done, the executions of other executors fail once the Context is
+// done, whether they succeeded or not, and are not run once it is.
+type ContextExecutor struct {
+	Context  context.Context
+	Executor effect.Executor
+}
+
+func (c ContextExecutor) Execute(execution effect.Execution) error {
+	if err := c.Context.Err(); err != nil {
+		return fmt.Errorf("%s was not run, the contribution was interrupted\n%w", execution.Command, err)
+	}
+
+	var err error
+	switch c.Executor.(type) {
+	case effect.CommandExecutor, effect.TTYExecutor:
+		err = c.run(execution)
+	default:
+		err = c.Executor.Execute(execution)
+	}
+
+	if ctxErr := c.Context.Err(); ctxErr != nil {
+		return fmt.Errorf("%s was interrupted\n%w", execution.Command, ctxErr)
+	}
+	return err
+}
+
+// run runs execution as effect.CommandExecutor does, the command being interrupted once Context is done. The output
+// written to stderr goes to stdout if the execution has no stderr, as it would with the terminal of a TTYExecutor.
+func (c ContextExecutor) run(execution effect.Execution) error {
+	cmd := exec.CommandContext(c.Context, execution.Command, execution.Args...)
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = InterruptGracePeriod
+
+	if execution.Dir != "" {
+		cmd.Dir = execution.Dir
+	}
+	if len(execution.Env) > 0 {
+		cmd.Env = execution.Env
+	}
+
+	cmd.Stdin = execution.Stdin
+	cmd.Stdout = execution.Stdout
+	cmd.Stderr = execution.Stderr
+	if cmd.Stderr == nil {
+		cmd.Stderr = execution.Stdout
+	}
+
+	return cmd.Run()
+}
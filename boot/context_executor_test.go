@@ -0,0 +1,110 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"bytes"
+	gocontext "context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/paketo-buildpacks/libpak/effect/mocks"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testContextExecutor(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		ctx    gocontext.Context
+		cancel gocontext.CancelFunc
+	)
+
+	it.Before(func() {
+		ctx, cancel = gocontext.WithCancel(gocontext.Background())
+	})
+
+	it.After(func() {
+		cancel()
+	})
+
+	it("runs the command", func() {
+		out := &bytes.Buffer{}
+
+		Expect(boot.ContextExecutor{Context: ctx, Executor: effect.CommandExecutor{}}.Execute(effect.Execution{
+			Command: "sh",
+			Args:    []string{"-c", "echo out; echo err >&2"},
+			Stdout:  out,
+		})).To(Succeed())
+
+		Expect(out.String()).To(Equal("out\nerr\n"))
+	})
+
+	it("returns the error of the command", func() {
+		Expect(boot.ContextExecutor{Context: ctx, Executor: effect.TTYExecutor{}}.Execute(effect.Execution{
+			Command: "sh",
+			Args:    []string{"-c", "exit 3"},
+		})).To(MatchError("exit status 3"))
+	})
+
+	it("interrupts the command once the context is done", func() {
+		time.AfterFunc(100*time.Millisecond, cancel)
+		start := time.Now()
+
+		err := boot.ContextExecutor{Context: ctx, Executor: effect.CommandExecutor{}}.Execute(effect.Execution{
+			Command: "sleep",
+			Args:    []string{"30"},
+		})
+
+		Expect(err).To(MatchError(gocontext.Canceled))
+		Expect(err).To(MatchError(ContainSubstring("sleep was interrupted")))
+		Expect(time.Since(start)).To(BeNumerically("<", 10*time.Second))
+	})
+
+	it("does not run the execution once the context is done", func() {
+		executor := &mocks.Executor{}
+		cancel()
+
+		err := boot.ContextExecutor{Context: ctx, Executor: executor}.Execute(effect.Execution{Command: "java"})
+
+		Expect(err).To(MatchError(gocontext.Canceled))
+		Expect(err).To(MatchError(ContainSubstring("java was not run, the contribution was interrupted")))
+		Expect(executor.Calls).To(BeEmpty())
+	})
+
+	it("fails an execution during which the context is done", func() {
+		executor := &mocks.Executor{}
+		executor.On("Execute", mock.Anything).Run(func(mock.Arguments) { cancel() }).Return(nil)
+
+		Expect(boot.ContextExecutor{Context: ctx, Executor: executor}.Execute(effect.Execution{Command: "java"})).
+			To(MatchError(gocontext.Canceled))
+	})
+
+	it("returns the error of another executor", func() {
+		executor := &mocks.Executor{}
+		executor.On("Execute", mock.Anything).Return(fmt.Errorf("test-error"))
+
+		Expect(boot.ContextExecutor{Context: ctx, Executor: executor}.Execute(effect.Execution{Command: "java"})).
+			To(MatchError("test-error"))
+	})
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultCgroupRoot is where the cgroup file system is mounted.
+const DefaultCgroupRoot = "/sys/fs/cgroup"
+
+// CgroupCPULimit returns the number of CPUs the cgroup mounted at root is limited to, rounded up, and whether it is
+// limited at all. Both the cgroup v2 cpu.max file and the cgroup v1 CFS quota and period files are supported.
+func CgroupCPULimit(root string) (int, bool, error) {
+	if b, err := os.ReadFile(filepath.Join(root, "cpu.max")); err == nil {
+		fields := strings.Fields(string(b))
+		if len(fields) != 2 {
+			return 0, false, fmt.Errorf("unable to parse cpu.max %q", strings.TrimSpace(string(b)))
+		}
+		if fields[0] == "max" {
+			return 0, false, nil
+		}
+		return cpuLimit(fields[0], fields[1])
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return 0, false, fmt.Errorf("unable to read cpu.max\n%w", err)
+	}
+
+	quota, err := os.ReadFile(filepath.Join(root, "cpu", "cpu.cfs_quota_us"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, fmt.Errorf("unable to read cpu.cfs_quota_us\n%w", err)
+	}
+	if strings.TrimSpace(string(quota)) == "-1" {
+		return 0, false, nil
+	}
+
+	period, err := os.ReadFile(filepath.Join(root, "cpu", "cpu.cfs_period_us"))
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to read cpu.cfs_period_us\n%w", err)
+	}
+	return cpuLimit(strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+}
+
+func cpuLimit(quota string, period string) (int, bool, error) {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to parse CPU quota %q\n%w", quota, err)
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return 0, false, fmt.Errorf("unable to parse CPU period %q", period)
+	}
+	if q <= 0 {
+		return 0, false, nil
+	}
+	return int(math.Ceil(q / p)), true, nil
+}
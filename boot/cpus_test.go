@@ -0,0 +1,96 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testCPUs(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		root string
+	)
+
+	it.Before(func() {
+		root = t.TempDir()
+	})
+
+	context("cgroup v2", func() {
+		it("rounds the limit up", func() {
+			Expect(os.WriteFile(filepath.Join(root, "cpu.max"), []byte("150000 100000\n"), 0644)).To(Succeed())
+
+			cpus, limited, err := boot.CgroupCPULimit(root)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(limited).To(BeTrue())
+			Expect(cpus).To(Equal(2))
+		})
+
+		it("returns unlimited for max", func() {
+			Expect(os.WriteFile(filepath.Join(root, "cpu.max"), []byte("max 100000\n"), 0644)).To(Succeed())
+
+			_, limited, err := boot.CgroupCPULimit(root)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(limited).To(BeFalse())
+		})
+
+		it("fails with a malformed cpu.max", func() {
+			Expect(os.WriteFile(filepath.Join(root, "cpu.max"), []byte("100000\n"), 0644)).To(Succeed())
+
+			_, _, err := boot.CgroupCPULimit(root)
+			Expect(err).To(MatchError(ContainSubstring("unable to parse cpu.max")))
+		})
+	})
+
+	context("cgroup v1", func() {
+		it.Before(func() {
+			Expect(os.MkdirAll(filepath.Join(root, "cpu"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(root, "cpu", "cpu.cfs_period_us"), []byte("100000\n"), 0644)).To(Succeed())
+		})
+
+		it("derives the limit from the quota and the period", func() {
+			Expect(os.WriteFile(filepath.Join(root, "cpu", "cpu.cfs_quota_us"), []byte("400000\n"), 0644)).To(Succeed())
+
+			cpus, limited, err := boot.CgroupCPULimit(root)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(limited).To(BeTrue())
+			Expect(cpus).To(Equal(4))
+		})
+
+		it("returns unlimited without a quota", func() {
+			Expect(os.WriteFile(filepath.Join(root, "cpu", "cpu.cfs_quota_us"), []byte("-1\n"), 0644)).To(Succeed())
+
+			_, limited, err := boot.CgroupCPULimit(root)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(limited).To(BeFalse())
+		})
+	})
+
+	it("returns unlimited without cgroup files", func() {
+		_, limited, err := boot.CgroupCPULimit(root)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(limited).To(BeFalse())
+	})
+}
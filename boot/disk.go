@@ -0,0 +1,48 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// DefaultDiskMultiplier is the free disk space needed by the performance layer, relative to the size of the
+// application: the re-zip, the extraction and the CDS archive temporarily need two to three times its size.
+const DefaultDiskMultiplier = 3.0
+
+// ErrInsufficientDisk is returned by CheckFreeDisk if the available disk space is less than needed.
+var ErrInsufficientDisk = errors.New("insufficient disk space")
+
+// FreeDiskFunc returns the disk space, in bytes, available to the buildpack on the file system containing path.
+type FreeDiskFunc func(path string) (uint64, error)
+
+// CheckFreeDisk returns an error if the disk space available in path, as returned by free, is less than size times
+// multiplier bytes.
+func CheckFreeDisk(path string, size int64, multiplier float64, free FreeDiskFunc) error {
+	available, err := free(path)
+	if err != nil {
+		return fmt.Errorf("unable to determine free disk space of %s\n%w", path, err)
+	}
+
+	needed := uint64(math.Ceil(float64(size) * multiplier))
+	if available < needed {
+		return WithHint(fmt.Errorf("%w in %s: %d bytes needed, %d bytes available", ErrInsufficientDisk, path, needed, available), HintDisk)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testDisk(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	available := func(bytes uint64) boot.FreeDiskFunc {
+		return func(string) (uint64, error) {
+			return bytes, nil
+		}
+	}
+
+	it("passes when enough disk space is available", func() {
+		Expect(boot.CheckFreeDisk("/workspace", 1000, 3, available(3000))).To(Succeed())
+	})
+
+	it("fails naming the needed and available disk space", func() {
+		err := boot.CheckFreeDisk("/workspace", 1000, 2.5, available(2499))
+		Expect(err).To(MatchError(boot.ErrInsufficientDisk))
+		Expect(err).To(MatchError("insufficient disk space in /workspace: 2500 bytes needed, 2499 bytes available"))
+
+		hint, ok := boot.ErrorHint(err)
+		Expect(ok).To(BeTrue())
+		Expect(hint).To(Equal(boot.HintDisk))
+	})
+
+	it("fails when the free disk space cannot be determined", func() {
+		err := boot.CheckFreeDisk("/workspace", 1000, 3, func(string) (uint64, error) {
+			return 0, fmt.Errorf("test-error")
+		})
+		Expect(err).To(MatchError(ContainSubstring("unable to determine free disk space of /workspace")))
+		Expect(err).NotTo(MatchError(boot.ErrInsufficientDisk))
+	})
+
+	it("returns the free disk space of the platform", func() {
+		free, err := boot.FreeDisk(os.TempDir())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(free).To(BeNumerically(">", 0))
+	})
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"archive/zip"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+// artifactPattern matches the file name of a jar named after its artifact and version, such as spring-core-6.1.8.jar.
+var artifactPattern = regexp.MustCompile(`^(.+?)-(\d[^-]*(?:-[^-]+)*)\.jar$`)
+
+// DuplicateArtifact is an artifact of which several versions are on a classpath.
+type DuplicateArtifact struct {
+
+	// Artifact is the name of the artifact, the jar file name without its version.
+	Artifact string
+
+	// Entries are the classpath entries of the versions of the artifact.
+	Entries []string
+}
+
+// SplitPackage is a package whose classes are in several jars of different artifacts on a classpath.
+type SplitPackage struct {
+
+	// Package is the name of the package.
+	Package string
+
+	// Entries are the classpath entries containing classes of the package.
+	Entries []string
+}
+
+// DuplicateArtifacts returns the artifacts of which several versions are on classpath, sorted by name.
+func DuplicateArtifacts(classpath []string) []DuplicateArtifact {
+	entries := map[string][]string{}
+	for _, entry := range classpath {
+		if artifact, ok := artifactName(entry); ok {
+			entries[artifact] = append(entries[artifact], entry)
+		}
+	}
+
+	var duplicates []DuplicateArtifact
+	for artifact, e := range entries {
+		if len(e) > 1 {
+			duplicates = append(duplicates, DuplicateArtifact{Artifact: artifact, Entries: e})
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].Artifact < duplicates[j].Artifact })
+	return duplicates
+}
+
+// SplitPackages returns the packages whose classes are in jars of different artifacts of classpath, sorted by name.
+// Relative entries are resolved against dir, entries other than jars and missing jars are skipped, as are the versioned classes of
+// multi-release jars. Several versions of an artifact are reported by DuplicateArtifacts rather than as split packages.
+func SplitPackages(dir string, classpath []string) ([]SplitPackage, error) {
+	entries := map[string][]string{}
+	for _, entry := range classpath {
+		if !strings.HasSuffix(entry, ".jar") {
+			continue
+		}
+		file := entry
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, entry)
+		}
+
+		// the JVM ignores the entries of the classpath that do not exist
+		if exists, err := sherpa.FileExists(file); err != nil {
+			return nil, fmt.Errorf("unable to check for %s\n%w", file, err)
+		} else if !exists {
+			continue
+		}
+
+		packages, err := jarPackages(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range packages {
+			entries[p] = append(entries[p], entry)
+		}
+	}
+
+	var split []SplitPackage
+	for p, e := range entries {
+		artifacts := map[string]bool{}
+		for _, entry := range e {
+			artifact, ok := artifactName(entry)
+			if !ok {
+				artifact = entry
+			}
+			artifacts[artifact] = true
+		}
+		if len(artifacts) > 1 {
+			split = append(split, SplitPackage{Package: p, Entries: e})
+		}
+	}
+	sort.Slice(split, func(i, j int) bool { return split[i].Package < split[j].Package })
+	return split, nil
+}
+
+// artifactName returns the artifact of the jar at entry, false if its file name does not include a version.
+func artifactName(entry string) (string, bool) {
+	matches := artifactPattern.FindStringSubmatch(filepath.Base(entry))
+	if matches == nil {
+		return "", false
+	}
+	return matches[1], true
+}
+
+// jarPackages returns the packages of the classes of the jar at file.
+func jarPackages(file string) ([]string, error) {
+	r, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s\n%w", file, err)
+	}
+	defer r.Close()
+
+	var packages []string
+	for _, f := range r.File {
+		if !strings.HasSuffix(f.Name, ".class") || strings.HasPrefix(f.Name, "META-INF/") {
+			continue
+		}
+		dir := path.Dir(f.Name)
+		if dir == "." {
+			// module-info and the classes of the default package
+			continue
+		}
+		if p := strings.ReplaceAll(dir, "/", "."); !slices.Contains(packages, p) {
+			packages = append(packages, p)
+		}
+	}
+	return packages, nil
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testDuplicates(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dir = filepath.Join("testdata", "duplicates")
+	)
+
+	it("finds the artifacts with several versions", func() {
+		Expect(boot.DuplicateArtifacts([]string{
+			"runner.jar",
+			"lib/jackson-core-2.17.1.jar",
+			"lib/spring-core-6.1.8.jar",
+			"lib/jackson-core-2.15.4.jar",
+			"lib/spring-core-test-6.1.8.jar",
+		})).To(Equal([]boot.DuplicateArtifact{
+			{Artifact: "jackson-core", Entries: []string{"lib/jackson-core-2.17.1.jar", "lib/jackson-core-2.15.4.jar"}},
+		}))
+	})
+
+	it("finds no duplicates on a classpath of distinct artifacts", func() {
+		Expect(boot.DuplicateArtifacts([]string{"lib/spring-core-6.1.8.jar", "lib/spring-context-6.1.8.jar", "lib/app-1.0-SNAPSHOT.jar"})).To(BeEmpty())
+	})
+
+	it("finds the packages split across artifacts", func() {
+		split, err := boot.SplitPackages(dir, []string{
+			"BOOT-INF/classes",
+			"lib/jackson-core-2.17.1.jar",
+			"lib/jackson-core-2.15.4.jar",
+			"lib/spring-core-6.1.8.jar",
+			"lib/spring-context-6.1.8.jar",
+			"lib/spring-context-support-6.1.8.jar",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(split).To(Equal([]boot.SplitPackage{
+			{Package: "org.springframework.context", Entries: []string{"lib/spring-context-6.1.8.jar", "lib/spring-context-support-6.1.8.jar"}},
+		}))
+	})
+
+	it("ignores the jars that do not exist", func() {
+		Expect(boot.SplitPackages(dir, []string{"lib/spring-context-6.1.8.jar", "lib/missing-1.0.0.jar"})).To(BeEmpty())
+	})
+
+	it("fails when a jar cannot be read", func() {
+		file := filepath.Join(t.TempDir(), "broken-1.0.0.jar")
+		Expect(os.WriteFile(file, []byte("not a jar"), 0644)).To(Succeed())
+
+		_, err := boot.SplitPackages(dir, []string{file})
+		Expect(err).To(MatchError(ContainSubstring("unable to open")))
+	})
+}
@@ -0,0 +1,174 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// BootClassesPrefix is the location of the application classes in a Spring Boot jar.
+	BootClassesPrefix = "BOOT-INF/classes/"
+
+	// ExtractedFileListName is the name of the listing of the extracted files in the debug directory of the layer.
+	ExtractedFileListName = "extracted-files.txt"
+)
+
+// VerifyExtraction compares the class files of a Spring Boot jar with the ones of the application jar extracted from
+// it by the tools jarmode. Every class under BOOT-INF/classes/ must be found at the root of the extracted jar with the
+// same SHA-256 digest, and the extracted jar must not contain any other class.
+func VerifyExtraction(jarPath string, extractedJarPath string) error {
+	expected, err := classDigests(jarPath, BootClassesPrefix)
+	if err != nil {
+		return err
+	}
+
+	actual, err := classDigests(extractedJarPath, "")
+	if err != nil {
+		return err
+	}
+
+	var mismatches []string
+	for name, digest := range expected {
+		if d, ok := actual[name]; !ok {
+			mismatches = append(mismatches, fmt.Sprintf("%s: missing", name))
+		} else if d != digest {
+			mismatches = append(mismatches, fmt.Sprintf("%s: expected sha256:%s, found sha256:%s", name, digest, d))
+		}
+	}
+	for name := range actual {
+		if _, ok := expected[name]; !ok {
+			mismatches = append(mismatches, fmt.Sprintf("%s: unexpected", name))
+		}
+	}
+
+	if len(mismatches) > 0 {
+		sort.Strings(mismatches)
+		return fmt.Errorf("extracted classes do not match %s, expected %d classes and found %d\n%s",
+			jarPath, len(expected), len(actual), strings.Join(mismatches, "\n"))
+	}
+	return nil
+}
+
+// ExtractedSize returns the number of files under dir and their total size in bytes, walking dir once.
+func ExtractedSize(dir string) (int, int64, error) {
+	var (
+		entries int
+		size    int64
+	)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries++
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to walk %s\n%w", dir, err)
+	}
+	return entries, size, nil
+}
+
+// WriteFileList writes a line with the path relative to dir, the size in bytes and the modification time of each
+// file under dir, sorted by path, so that the listings of two builds can be diffed.
+func WriteFileList(dir string, w io.Writer) error {
+	var lines []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, fmt.Sprintf("%s %d %s", filepath.ToSlash(rel), info.Size(), info.ModTime().UTC().Format(time.RFC3339Nano)))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("unable to walk %s\n%w", dir, err)
+	}
+
+	sort.Strings(lines)
+	for _, l := range lines {
+		if _, err := fmt.Fprintln(w, l); err != nil {
+			return fmt.Errorf("unable to write file list of %s\n%w", dir, err)
+		}
+	}
+	return nil
+}
+
+// classDigests returns the SHA-256 digest of the class files under prefix in a jar, keyed by their name relative to
+// prefix.
+func classDigests(jarPath string, prefix string) (map[string]string, error) {
+	r, err := zip.OpenReader(jarPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s\n%w", jarPath, err)
+	}
+	defer r.Close()
+
+	digests := make(map[string]string)
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !strings.HasPrefix(f.Name, prefix) || !strings.HasSuffix(f.Name, ".class") {
+			continue
+		}
+
+		digest, err := entryDigest(f)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s in %s\n%w", f.Name, jarPath, err)
+		}
+		digests[strings.TrimPrefix(f.Name, prefix)] = digest
+	}
+	return digests, nil
+}
+
+func entryDigest(f *zip.File) (string, error) {
+	in, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, in); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testExtraction(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dir       string
+		jar       string
+		extracted string
+	)
+
+	it.Before(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "extraction")
+		Expect(err).NotTo(HaveOccurred())
+
+		jar = filepath.Join(dir, "app.jar")
+		extracted = filepath.Join(dir, "extracted.jar")
+
+		writeJarEntries(t, jar, map[string]string{
+			"META-INF/MANIFEST.MF":                    "Manifest-Version: 1.0\n",
+			"BOOT-INF/classes/com/example/App.class":  "app",
+			"BOOT-INF/classes/com/example/Util.class": "util",
+			"BOOT-INF/classes/application.properties": "",
+			"org/springframework/boot/loader/L.class": "loader",
+		})
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	it("accepts a matching extraction", func() {
+		writeJarEntries(t, extracted, map[string]string{
+			"META-INF/MANIFEST.MF":   "Manifest-Version: 1.0\nClass-Path: lib/a.jar\n",
+			"com/example/App.class":  "app",
+			"com/example/Util.class": "util",
+			"application.properties": "",
+		})
+
+		Expect(boot.VerifyExtraction(jar, extracted)).To(Succeed())
+	})
+
+	it("reports tampered, missing and unexpected classes", func() {
+		writeJarEntries(t, extracted, map[string]string{
+			"com/example/App.class":  "tampered",
+			"com/example/Evil.class": "evil",
+		})
+
+		err := boot.VerifyExtraction(jar, extracted)
+		Expect(err).To(MatchError(ContainSubstring("expected 2 classes and found 2")))
+		Expect(err).To(MatchError(ContainSubstring("com/example/App.class: expected sha256:")))
+		Expect(err).To(MatchError(ContainSubstring("com/example/Evil.class: unexpected")))
+		Expect(err).To(MatchError(ContainSubstring("com/example/Util.class: missing")))
+	})
+
+	it("fails when the extracted jar does not exist", func() {
+		Expect(boot.VerifyExtraction(jar, extracted)).To(MatchError(ContainSubstring("unable to open")))
+	})
+}
+
+func testExtractedSize(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("counts the extracted files and their size", func() {
+		dir := t.TempDir()
+		Expect(os.MkdirAll(filepath.Join(dir, "lib"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "runner.jar"), []byte("0123456789"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "lib", "a.jar"), []byte("01234"), 0644)).To(Succeed())
+		Expect(os.Symlink(filepath.Join(dir, "runner.jar"), filepath.Join(dir, "link.jar"))).To(Succeed())
+
+		entries, size, err := boot.ExtractedSize(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(Equal(2))
+		Expect(size).To(Equal(int64(15)))
+	})
+
+	it("fails for a missing directory", func() {
+		_, _, err := boot.ExtractedSize(filepath.Join(t.TempDir(), "missing"))
+		Expect(err).To(HaveOccurred())
+	})
+}
+
+func testWriteFileList(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("lists the files sorted by path with their size and modification time", func() {
+		dir := t.TempDir()
+		Expect(os.MkdirAll(filepath.Join(dir, "lib"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "runner.jar"), []byte("0123456789"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "lib", "b.jar"), []byte("012"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "lib", "a.jar"), []byte("01234"), 0644)).To(Succeed())
+		Expect(os.Symlink(filepath.Join(dir, "runner.jar"), filepath.Join(dir, "link.jar"))).To(Succeed())
+		modified := time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)
+		for _, f := range []string{"runner.jar", "lib/a.jar", "lib/b.jar"} {
+			Expect(os.Chtimes(filepath.Join(dir, f), modified, modified)).To(Succeed())
+		}
+
+		buf := &bytes.Buffer{}
+		Expect(boot.WriteFileList(dir, buf)).To(Succeed())
+
+		Expect(buf.String()).To(Equal(
+			"lib/a.jar 5 2024-06-01T12:30:00Z\n" +
+				"lib/b.jar 3 2024-06-01T12:30:00Z\n" +
+				"runner.jar 10 2024-06-01T12:30:00Z\n"))
+	})
+
+	it("fails for a missing directory", func() {
+		Expect(boot.WriteFileList(filepath.Join(t.TempDir(), "missing"), &bytes.Buffer{})).To(HaveOccurred())
+	})
+}
+
+func writeJarEntries(t *testing.T, path string, entries map[string]string) {
+	Expect := NewWithT(t).Expect
+
+	f, err := os.Create(path)
+	Expect(err).NotTo(HaveOccurred())
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for name, content := range entries {
+		e, err := w.Create(name)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = e.Write([]byte(content))
+		Expect(err).NotTo(HaveOccurred())
+	}
+	Expect(w.Close()).To(Succeed())
+}
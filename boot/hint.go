@@ -0,0 +1,68 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"errors"
+	"io/fs"
+	"os/exec"
+)
+
+const (
+	HintDisk        = "free disk space in the build image, or lower BP_JVM_CDS_DISK_MULTIPLIER if the estimate is too high"
+	HintJDK         = "ensure a JDK 17+ buildpack is applied before this buildpack"
+	HintJarMode     = "ensure the application is built with Spring Boot 3.3+, or set BP_JVM_CDS_JARMODE to a jarmode it ships"
+	HintSandbox     = "ensure the platform permits the sandbox wrapping the training run, or set BP_JVM_CDS_TRAINING_SANDBOX to one it permits"
+	HintStartClass  = "ensure the application is packaged by the Spring Boot build plugin, which writes the Start-Class manifest entry"
+	HintTrainingRun = "verify your app can start with -Dspring.context.exit=onRefresh, or set BP_JVM_CDS_REQUIRED=false to build without CDS"
+)
+
+// HintError is an error carrying a one-line hint on how to remediate it, logged prominently when the build fails.
+type HintError struct {
+	Err  error
+	Hint string
+}
+
+// WithHint returns err carrying hint, or nil if err is nil.
+func WithHint(err error, hint string) error {
+	if err == nil {
+		return nil
+	}
+	return HintError{Err: err, Hint: hint}
+}
+
+func (h HintError) Error() string {
+	return h.Err.Error()
+}
+
+func (h HintError) Unwrap() error {
+	return h.Err
+}
+
+// ErrorHint returns the hint of the first HintError wrapped by err, if any.
+func ErrorHint(err error) (string, bool) {
+	var h HintError
+	if errors.As(err, &h) {
+		return h.Hint, true
+	}
+	return "", false
+}
+
+// commandNotFound returns whether err is caused by a command that could not be found.
+func commandNotFound(err error) bool {
+	return errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist)
+}
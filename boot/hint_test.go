@@ -0,0 +1,60 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testHint(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("returns the hint of a wrapped error", func() {
+		cause := errors.New("test-error")
+		err := fmt.Errorf("test-context\n%w", boot.WithHint(cause, "test-hint"))
+
+		Expect(err).To(MatchError(ContainSubstring("test-error")))
+		Expect(errors.Is(err, cause)).To(BeTrue())
+
+		hint, ok := boot.ErrorHint(err)
+		Expect(ok).To(BeTrue())
+		Expect(hint).To(Equal("test-hint"))
+	})
+
+	it("returns no hint for an error without one", func() {
+		_, ok := boot.ErrorHint(errors.New("test-error"))
+		Expect(ok).To(BeFalse())
+	})
+
+	it("returns nil for a nil error", func() {
+		Expect(boot.WithHint(nil, "test-hint")).To(BeNil())
+	})
+}
+
+func errorHint(err error) string {
+	hint, _ := boot.ErrorHint(err)
+	return hint
+}
@@ -25,12 +25,56 @@ import (
 
 func TestUnit(t *testing.T) {
 	suite := spec.New("boot", spec.Report(report.Terminal{}))
+	suite("ArchiveFormat", testArchiveFormat)
+	suite("ArchiveRegions", testArchiveRegions)
+	suite("ArchiveValidation", testArchiveValidation)
+	suite("BeanCount", testBeanCount)
+	suite("Benchmark", testBenchmark)
  	suite("Build", testBuild)
+	suite("CDSArchive", testCDSArchive)
+	suite("Category", testCategory)
+	suite("ClassCount", testClassCount)
+	suite("Classpath", testClasspath)
+	suite("CommandLine", testCommandLine)
 	suite("ConfigurationMetadata", testConfigurationMetadata)
+	suite("ContextExecutor", testContextExecutor)
+	suite("CPUs", testCPUs)
 	suite("Detect", testDetect)
+	suite("Disk", testDisk)
+	suite("Duplicates", testDuplicates)
+	suite("Extraction", testExtraction)
+	suite("ExtractBootJarLayout", testExtractBootJarLayout)
+	suite("ExtractedSize", testExtractedSize)
+	suite("WriteFileList", testWriteFileList)
 	suite("GenerationValidator", testGenerationValidator)
+	suite("Hint", testHint)
+	suite("Jar", testJar)
+	suite("JarMode", testJarMode)
+	suite("JDK", testJDK)
+	suite("JVMFlags", testJVMFlags)
+	suite("LaunchEnv", testLaunchEnv)
+	suite("Launcher", testLauncher)
+	suite("Layout", testLayout)
+	suite("LazyInitialization", testLazyInitialization)
+	suite("Lock", testLock)
+	suite("LogLevel", testLogLevel)
+	suite("Manifest", testManifest)
+	suite("NativeHints", testNativeHints)
+	suite("OutputTail", testOutputTail)
+	suite("PerformanceConfig", testPerformanceConfig)
+	suite("Remove", testRemove)
+	suite("ReZip", testReZip)
+	suite("ReZipIgnore", testReZipIgnore)
+	suite("Sandboxed", testSandboxed)
+	suite("SelfTest", testSelfTest)
+	suite("ShutdownWatch", testShutdownWatch)
 	suite("SpringCloudBindings", testSpringCloudBindings)
 	suite("SpringPerformance", testSpringPerformance)
+	suite("TempDirs", testTempDirs)
+	suite("Timestamps", testTimestamps)
+	suite("TrainingDataSource", testTrainingDataSource)
+	suite("TrainingProfileSets", testTrainingProfileSets)
+	suite("WaitForArchive", testWaitForArchive)
  	suite("WebApplicationType", testWebApplicationType)
 	suite("WebApplicationTypeResolver", testWebApplicationTypeResolver)
 	suite("NativeImage", testNativeImage) 
@@ -0,0 +1,231 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CreateJar packs the contents of source into an uncompressed jar at target. Symbolic links are resolved and their
+// targets are packed as regular files. If target is located inside source, it is not packed into itself. Every entry
+// records modified as its modification time, so that the jar does not depend on when the files were written.
+func CreateJar(source, target string, modified time.Time) error {
+	return CreateJarWithHeaderFunc(source, target, func(header *zip.FileHeader) {
+		header.Modified = modified
+	})
+}
+
+// CreateJarWithHeaderFunc packs the contents of source into a jar at target as CreateJar does, applying transform to
+// the header of every entry before it is written. The header records the name, the mode and the modification time of
+// the file, and the Store method, transform may change any of them, such as the timestamps or the comment.
+func CreateJarWithHeaderFunc(source, target string, transform func(*zip.FileHeader)) error {
+	return CreateJarWithOptions(source, target, JarOptions{Transform: transform})
+}
+
+// CreateJarIgnoring packs the contents of source into a jar at target as CreateJar does, without the files and the
+// directories ignored by ignore.
+func CreateJarIgnoring(source, target string, modified time.Time, ignore IgnorePatterns) error {
+	return CreateJarWithProgress(source, target, modified, ignore, nil)
+}
+
+// JarProgressFunc is called by CreateJarWithProgress once each file is packed, with the bytes of the files packed so
+// far and the total bytes of the files to pack.
+type JarProgressFunc func(copied int64, total int64)
+
+// CreateJarWithProgress packs the contents of source into a jar at target as CreateJarIgnoring does, reporting the
+// progress to progress, if not nil. The total size of the files is computed by walking source before they are packed.
+func CreateJarWithProgress(source, target string, modified time.Time, ignore IgnorePatterns, progress JarProgressFunc) error {
+	return CreateJarWithOptions(source, target, JarOptions{
+		Transform: func(header *zip.FileHeader) {
+			header.Modified = modified
+		},
+		Ignore:   ignore,
+		Progress: progress,
+	})
+}
+
+// JarOptions configures how CreateJarWithOptions packs a jar.
+type JarOptions struct {
+
+	// Transform is applied to the header of every entry before it is written, if not nil.
+	Transform func(*zip.FileHeader)
+
+	// Ignore are the patterns of the files and the directories not packed.
+	Ignore IgnorePatterns
+
+	// Progress is called once each file is packed, if not nil.
+	Progress JarProgressFunc
+
+	// CompressionLevel is the deflate level, from flate.BestSpeed to flate.BestCompression, of the entries other than
+	// nested jars, which the Spring Boot loader requires to be stored. The entries are stored if 0.
+	CompressionLevel int
+}
+
+// CreateJarWithOptions packs the contents of source into a jar at target as CreateJar does, configured by options.
+func CreateJarWithOptions(source, target string, options JarOptions) error {
+	if level := options.CompressionLevel; level != 0 && (level < flate.BestSpeed || level > flate.BestCompression) {
+		return fmt.Errorf("invalid compression level %d, expected %d to %d", level, flate.BestSpeed, flate.BestCompression)
+	}
+
+	var total int64
+	if options.Progress != nil {
+		if err := walkJarEntries(source, target, options.Ignore, func(_ string, info os.FileInfo, _ string) error {
+			if !info.IsDir() {
+				total += info.Size()
+			}
+			return nil
+		}); err != nil {
+			return fmt.Errorf("unable to compute size of %s\n%w", source, err)
+		}
+	}
+
+	f, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("unable to create %s\n%w", target, err)
+	}
+	defer f.Close()
+
+	writer := zip.NewWriter(f)
+
+	// the default deflate compressor of the writer always uses the default level
+	if level := options.CompressionLevel; level != 0 {
+		writer.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, level)
+		})
+	}
+
+	var copied int64
+	if err := walkJarEntries(source, target, options.Ignore, func(name string, info os.FileInfo, path string) error {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Method = zip.Store
+		if options.CompressionLevel != 0 && !info.IsDir() && !strings.HasSuffix(name, ".jar") {
+			header.Method = zip.Deflate
+		}
+		header.Name = name
+		if options.Transform != nil {
+			options.Transform(header)
+		}
+
+		headerWriter, err := writer.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		n, err := io.Copy(headerWriter, in)
+		if err != nil {
+			return err
+		}
+		if options.Progress != nil {
+			copied += n
+			options.Progress(copied, total)
+		}
+		return nil
+	}); err != nil {
+		_ = writer.Close()
+		return err
+	}
+
+	// the central directory is written on close, a jar whose writer failed to close is truncated
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("unable to write %s\n%w", target, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to close %s\n%w", target, err)
+	}
+	return nil
+}
+
+// walkJarEntries calls fn with the entry name, the file info and the path of the file of each entry of a jar of
+// source. Symbolic links are resolved to their targets, and target and the files ignored by ignore are skipped.
+func walkJarEntries(source, target string, ignore IgnorePatterns, fn func(name string, info os.FileInfo, path string) error) error {
+	absoluteTarget, err := filepath.Abs(target)
+	if err != nil {
+		return fmt.Errorf("unable to resolve %s\n%w", target, err)
+	}
+
+	return filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if p, err := filepath.Abs(path); err != nil {
+			return fmt.Errorf("unable to resolve %s\n%w", path, err)
+		} else if p == absoluteTarget {
+			return nil
+		}
+
+		// the walk only descends into directories, not into symbolic links to directories
+		walkedDir := info.IsDir()
+
+		file := path
+		if info.Mode()&os.ModeSymlink == os.ModeSymlink {
+			if file, err = filepath.EvalSymlinks(path); err != nil {
+				return fmt.Errorf("unable to eval symlink %s\n%w", path, err)
+			}
+			if info, err = os.Stat(file); err != nil {
+				return fmt.Errorf("unable to stat %s\n%w", file, err)
+			}
+		}
+
+		name, err := JarEntryName(source, path, info.IsDir())
+		if err != nil {
+			return err
+		}
+		if path != source && ignore.Ignored(name, info.IsDir()) {
+			if walkedDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		return fn(name, info, file)
+	})
+}
+
+// JarEntryName returns the name of the jar entry for path, relative to source. Entry names always use forward slashes,
+// as required by the zip format, regardless of the platform separator.
+func JarEntryName(source string, path string, isDir bool) (string, error) {
+	name, err := filepath.Rel(source, path)
+	if err != nil {
+		return "", fmt.Errorf("unable to compute entry name of %s\n%w", path, err)
+	}
+	name = filepath.ToSlash(name)
+	if isDir {
+		name += "/"
+	}
+	return name, nil
+}
@@ -0,0 +1,257 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testJar(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		source    string
+		targetDir string
+		target    string
+	)
+
+	it.Before(func() {
+		var err error
+		source, err = os.MkdirTemp("", "jar-source")
+		Expect(err).NotTo(HaveOccurred())
+		targetDir, err = os.MkdirTemp("", "jar-target")
+		Expect(err).NotTo(HaveOccurred())
+		target = filepath.Join(targetDir, "runner.jar")
+
+		Expect(os.MkdirAll(filepath.Join(source, "BOOT-INF", "classes", "com", "example"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(source, "BOOT-INF", "classes", "com", "example", "Application.class"), []byte("class"), 0644)).To(Succeed())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(source)).To(Succeed())
+		Expect(os.RemoveAll(targetDir)).To(Succeed())
+	})
+
+	entryNames := func(path string) []string {
+		r, err := zip.OpenReader(path)
+		Expect(err).NotTo(HaveOccurred())
+		defer r.Close()
+
+		var names []string
+		for _, f := range r.File {
+			names = append(names, f.Name)
+		}
+		return names
+	}
+
+	it("creates a jar with slash separated entry names", func() {
+		Expect(boot.CreateJar(source, target, boot.DefaultTimestamp)).To(Succeed())
+
+		names := entryNames(target)
+		Expect(names).To(ContainElements(
+			"BOOT-INF/",
+			"BOOT-INF/classes/com/example/",
+			"BOOT-INF/classes/com/example/Application.class",
+		))
+		for _, name := range names {
+			Expect(name).NotTo(ContainSubstring(`\`))
+		}
+	})
+
+	it("does not pack the target into itself when it is inside the source", func() {
+		target = filepath.Join(source, "runner.jar")
+
+		Expect(boot.CreateJar(source, target, boot.DefaultTimestamp)).To(Succeed())
+
+		names := entryNames(target)
+		Expect(names).To(ContainElement("BOOT-INF/classes/com/example/Application.class"))
+		Expect(names).NotTo(ContainElement("runner.jar"))
+	})
+
+	it("fails when the central directory cannot be written", func() {
+		if _, err := os.Stat("/dev/full"); err != nil {
+			t.Skip("/dev/full is not available")
+		}
+
+		// the entries are buffered by the writer, the write only fails once it is closed
+		Expect(boot.CreateJar(source, "/dev/full", boot.DefaultTimestamp)).
+			To(MatchError(ContainSubstring("unable to write /dev/full")))
+	})
+
+	it("records the given modification time on every entry", func() {
+		modified := time.Unix(1700000000, 0).UTC()
+		Expect(boot.CreateJar(source, target, modified)).To(Succeed())
+
+		r, err := zip.OpenReader(target)
+		Expect(err).NotTo(HaveOccurred())
+		defer r.Close()
+
+		Expect(r.File).NotTo(BeEmpty())
+		for _, f := range r.File {
+			Expect(f.Modified.UTC()).To(Equal(modified), f.Name)
+		}
+	})
+
+	it("applies the header transform to every entry", func() {
+		modified := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+		Expect(boot.CreateJarWithHeaderFunc(source, target, func(header *zip.FileHeader) {
+			header.Modified = modified
+			header.Comment = "packed"
+		})).To(Succeed())
+
+		r, err := zip.OpenReader(target)
+		Expect(err).NotTo(HaveOccurred())
+		defer r.Close()
+
+		Expect(r.File).NotTo(BeEmpty())
+		for _, f := range r.File {
+			Expect(f.Modified.UTC()).To(Equal(modified), f.Name)
+			Expect(f.Comment).To(Equal("packed"), f.Name)
+			Expect(f.Method).To(Equal(zip.Store), f.Name)
+		}
+	})
+
+	it("does not pack the files and directories excluded by the ignore file", func() {
+		Expect(os.MkdirAll(filepath.Join(source, "BOOT-INF", "classes", "fixtures"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(source, "BOOT-INF", "classes", "fixtures", "data.json"), []byte("{}"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(source, "BOOT-INF", "classes", "build.log"), []byte("log"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(source, boot.ReZipIgnoreFile), []byte("# test data\nfixtures/\n*.log\n"), 0644)).To(Succeed())
+
+		ignore, err := boot.ReadIgnoreFile(source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(boot.CreateJarIgnoring(source, target, boot.DefaultTimestamp, ignore)).To(Succeed())
+
+		names := entryNames(target)
+		Expect(names).To(ContainElement("BOOT-INF/classes/com/example/Application.class"))
+		Expect(names).NotTo(ContainElements(
+			"BOOT-INF/classes/fixtures/",
+			"BOOT-INF/classes/fixtures/data.json",
+			"BOOT-INF/classes/build.log",
+			boot.ReZipIgnoreFile,
+		))
+	})
+
+	it("reads no patterns without an ignore file", func() {
+		ignore, err := boot.ReadIgnoreFile(source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ignore).To(BeEmpty())
+	})
+
+	it("reports the progress in increasing order", func() {
+		Expect(os.WriteFile(filepath.Join(source, "BOOT-INF", "classes", "application.properties"), []byte("test=true"), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(source, "BOOT-INF", "lib"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(source, "BOOT-INF", "lib", "test.jar"), make([]byte, 1024), 0644)).To(Succeed())
+
+		var copied, totals []int64
+		Expect(boot.CreateJarWithProgress(source, target, boot.DefaultTimestamp, nil, func(c int64, t int64) {
+			copied = append(copied, c)
+			totals = append(totals, t)
+		})).To(Succeed())
+
+		Expect(copied).To(HaveLen(3))
+		Expect(slices.IsSorted(copied)).To(BeTrue())
+		Expect(copied[0]).To(BeNumerically(">", 0))
+		Expect(copied[2]).To(Equal(int64(len("class") + len("test=true") + 1024)))
+		Expect(totals).To(HaveEach(copied[2]))
+	})
+
+	it("does not count the ignored files in the progress", func() {
+		Expect(os.WriteFile(filepath.Join(source, "build.log"), make([]byte, 1024), 0644)).To(Succeed())
+		ignore, err := boot.ParseIgnorePatterns(strings.NewReader("*.log\n"))
+		Expect(err).NotTo(HaveOccurred())
+
+		var total int64
+		Expect(boot.CreateJarWithProgress(source, target, boot.DefaultTimestamp, ignore, func(_ int64, t int64) {
+			total = t
+		})).To(Succeed())
+		Expect(total).To(Equal(int64(len("class"))))
+	})
+
+	context("compression level", func() {
+		it.Before(func() {
+			// the content is compressible, but not so repetitive that every level compresses it alike
+			r := rand.New(rand.NewSource(1))
+			content := &bytes.Buffer{}
+			for content.Len() < 256*1024 {
+				fmt.Fprintf(content, "entry-%d=%x\n", r.Intn(5000), r.Intn(1<<16))
+			}
+			Expect(os.WriteFile(filepath.Join(source, "BOOT-INF", "classes", "application.properties"), content.Bytes(), 0644)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(source, "BOOT-INF", "lib"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(source, "BOOT-INF", "lib", "test.jar"), content.Bytes(), 0644)).To(Succeed())
+		})
+
+		size := func(level int) int64 {
+			target := filepath.Join(targetDir, fmt.Sprintf("level-%d.jar", level))
+			Expect(boot.CreateJarWithOptions(source, target, boot.JarOptions{CompressionLevel: level})).To(Succeed())
+			info, err := os.Stat(target)
+			Expect(err).NotTo(HaveOccurred())
+			return info.Size()
+		}
+
+		it("produces smaller jars at higher levels", func() {
+			stored, fastest, best := size(0), size(flate.BestSpeed), size(flate.BestCompression)
+			Expect(fastest).To(BeNumerically("<", stored))
+			Expect(best).To(BeNumerically("<", fastest))
+		})
+
+		it("deflates the entries but stores the nested jars", func() {
+			Expect(boot.CreateJarWithOptions(source, target, boot.JarOptions{CompressionLevel: 6})).To(Succeed())
+
+			r, err := zip.OpenReader(target)
+			Expect(err).NotTo(HaveOccurred())
+			defer r.Close()
+
+			methods := map[string]uint16{}
+			for _, f := range r.File {
+				methods[f.Name] = f.Method
+			}
+			Expect(methods).To(HaveKeyWithValue("BOOT-INF/classes/application.properties", zip.Deflate))
+			Expect(methods).To(HaveKeyWithValue("BOOT-INF/lib/test.jar", zip.Store))
+			Expect(methods).To(HaveKeyWithValue("BOOT-INF/", zip.Store))
+		})
+
+		it("fails with an invalid level", func() {
+			Expect(boot.CreateJarWithOptions(source, target, boot.JarOptions{CompressionLevel: 10})).
+				To(MatchError("invalid compression level 10, expected 1 to 9"))
+		})
+	})
+
+	it("computes entry names with forward slashes", func() {
+		name, err := boot.JarEntryName(source, filepath.Join(source, "BOOT-INF", "lib", "test.jar"), false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(name).To(Equal("BOOT-INF/lib/test.jar"))
+
+		name, err = boot.JarEntryName(source, filepath.Join(source, "BOOT-INF", "lib"), true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(name).To(Equal("BOOT-INF/lib/"))
+	})
+}
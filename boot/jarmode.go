@@ -0,0 +1,79 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+// JarModeTools is the jarmode extracting the CDS friendly layout, with the runner jar the training run is performed with.
+// Spring Boot ships it from 3.3, the layertools jarmode of older versions extracts the layers without the runner jar.
+const JarModeTools = "tools"
+
+// JarModeSupported returns whether the jar at jarPath ships the jarmode library for mode.
+func JarModeSupported(jarPath string, mode string) (bool, error) {
+	r, err := zip.OpenReader(jarPath)
+	if err != nil {
+		return false, fmt.Errorf("unable to open %s\n%w", jarPath, err)
+	}
+	defer r.Close()
+
+	prefix := fmt.Sprintf("spring-boot-jarmode-%s-", mode)
+	for _, f := range r.File {
+		if name := path.Base(f.Name); strings.HasPrefix(name, prefix) && strings.HasSuffix(name, ".jar") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ExtractBootJar extracts the Spring Boot jar at jarPath into dest with the tools jarmode, using the java found on the
+// PATH. The output of the extraction is discarded.
+func ExtractBootJar(jarPath string, dest string, exec effect.Executor) error {
+	return extractBootJar(exec, "java", nil, JarModeTools, jarPath, dest, nil)
+}
+
+func extractBootJar(exec effect.Executor, javaCommand string, env []string, mode string, jarPath string, dest string, out io.Writer) error {
+	if supported, err := JarModeSupported(jarPath, mode); err != nil {
+		return fmt.Errorf("unable to check jarmode support\n%w", err)
+	} else if !supported {
+		return WithHint(fmt.Errorf("jarmode %s is not supported by %s, it requires the spring-boot-jarmode-%s library", mode, jarPath, mode), HintJarMode)
+	}
+
+	if err := exec.Execute(effect.Execution{
+		Command: javaCommand,
+		Args:    []string{fmt.Sprintf("-Djarmode=%s", mode), "-jar", jarPath, "extract", "--destination", dest},
+		Dir:     filepath.Dir(jarPath),
+		Env:     env,
+		Stdout:  out,
+		Stderr:  out,
+	}); err != nil {
+		hint := HintJarMode
+		if commandNotFound(err) {
+			hint = HintJDK
+		}
+		return WithHint(fmt.Errorf("error extracting Jar with jarmode\n%w", err), hint)
+	}
+	return nil
+}
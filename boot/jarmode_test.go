@@ -0,0 +1,128 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/paketo-buildpacks/libpak/effect/mocks"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testJarMode(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		jarPath string
+	)
+
+	it.Before(func() {
+		f, err := os.CreateTemp("", "jarmode-*.jar")
+		Expect(err).NotTo(HaveOccurred())
+		defer f.Close()
+		jarPath = f.Name()
+
+		w := zip.NewWriter(f)
+		_, err = w.Create("BOOT-INF/lib/spring-boot-jarmode-tools-3.3.1.jar")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(w.Close()).To(Succeed())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(jarPath)).To(Succeed())
+	})
+
+	it("detects a jarmode shipped in the jar", func() {
+		supported, err := boot.JarModeSupported(jarPath, boot.JarModeTools)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(supported).To(BeTrue())
+	})
+
+	it("detects a jarmode missing from the jar", func() {
+		f, err := os.Create(jarPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(zip.NewWriter(f).Close()).To(Succeed())
+		Expect(f.Close()).To(Succeed())
+
+		supported, err := boot.JarModeSupported(jarPath, boot.JarModeTools)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(supported).To(BeFalse())
+	})
+
+	it("fails for a file that is not a jar", func() {
+		_, err := boot.JarModeSupported(filepath.Join("testdata", "does-not-exist.jar"), boot.JarModeTools)
+		Expect(err).To(MatchError(ContainSubstring("unable to open")))
+	})
+
+	context("ExtractBootJar", func() {
+		var executor *mocks.Executor
+
+		it.Before(func() {
+			executor = &mocks.Executor{}
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("extracts the jar with the tools jarmode", func() {
+			Expect(boot.ExtractBootJar(jarPath, "/tmp/extracted", executor)).To(Succeed())
+
+			Expect(executor.Calls).To(HaveLen(1))
+			e, ok := executor.Calls[0].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Command).To(Equal("java"))
+			Expect(e.Args).To(Equal([]string{"-Djarmode=tools", "-jar", jarPath, "extract", "--destination", "/tmp/extracted"}))
+			Expect(e.Dir).To(Equal(filepath.Dir(jarPath)))
+		})
+
+		it("fails when the jar does not support the tools jarmode", func() {
+			f, err := os.Create(jarPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zip.NewWriter(f).Close()).To(Succeed())
+			Expect(f.Close()).To(Succeed())
+
+			err = boot.ExtractBootJar(jarPath, "/tmp/extracted", executor)
+			Expect(err).To(MatchError(ContainSubstring("jarmode tools is not supported")))
+			Expect(errorHint(err)).To(Equal(boot.HintJarMode))
+			Expect(executor.Calls).To(BeEmpty())
+		})
+
+		it("returns extraction errors", func() {
+			executor = &mocks.Executor{}
+			executor.On("Execute", mock.Anything).Return(fmt.Errorf("test-error"))
+
+			err := boot.ExtractBootJar(jarPath, "/tmp/extracted", executor)
+			Expect(err).To(MatchError(ContainSubstring("test-error")))
+			Expect(errorHint(err)).To(Equal(boot.HintJarMode))
+		})
+
+		it("hints at the JDK when java cannot be found", func() {
+			executor = &mocks.Executor{}
+			executor.On("Execute", mock.Anything).Return(fmt.Errorf("unable to start\n%w", exec.ErrNotFound))
+
+			Expect(errorHint(boot.ExtractBootJar(jarPath, "/tmp/extracted", executor))).To(Equal(boot.HintJDK))
+		})
+	})
+}
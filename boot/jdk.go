@@ -0,0 +1,88 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// JDK describes a Java runtime, as reported by java -XshowSettings:properties -version.
+type JDK struct {
+	Name    string
+	Version string
+	Vendor  string
+}
+
+// NewJDKFromSettings parses the output of java -XshowSettings:properties -version.
+func NewJDKFromSettings(output string) JDK {
+	var jdk JDK
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " = ")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "java.runtime.name":
+			jdk.Name = value
+		case "java.runtime.version":
+			if jdk.Version == "" {
+				jdk.Version = value
+			}
+		case "java.version":
+			jdk.Version = value
+		case "java.vendor":
+			jdk.Vendor = value
+		}
+	}
+	return jdk
+}
+
+// WriteCycloneDX writes a CycloneDX SBOM at path, describing the JDK as a component.
+func (j JDK) WriteCycloneDX(path string) error {
+	sbom := map[string]interface{}{
+		"bomFormat":   "CycloneDX",
+		"specVersion": "1.4",
+		"version":     1,
+		"components": []map[string]interface{}{
+			{
+				"type":        "application",
+				"name":        j.Name,
+				"version":     j.Version,
+				"publisher":   j.Vendor,
+				"description": "JDK used for the CDS training run",
+			},
+		},
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to create %s\n%w", path, err)
+	}
+	defer out.Close()
+
+	if err := json.NewEncoder(out).Encode(sbom); err != nil {
+		return fmt.Errorf("unable to encode %s\n%w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+const jdkSettings = `Property settings:
+    file.encoding = UTF-8
+    java.runtime.name = OpenJDK Runtime Environment
+    java.runtime.version = 21.0.4+7-LTS
+    java.vendor = Eclipse Adoptium
+    java.version = 21.0.4
+    java.vm.name = OpenJDK 64-Bit Server VM
+
+openjdk version "21.0.4" 2024-07-16 LTS
+OpenJDK Runtime Environment Temurin-21.0.4+7 (build 21.0.4+7-LTS)
+`
+
+func testJDK(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("parses the JDK settings", func() {
+		Expect(boot.NewJDKFromSettings(jdkSettings)).To(Equal(boot.JDK{
+			Name:    "OpenJDK Runtime Environment",
+			Version: "21.0.4",
+			Vendor:  "Eclipse Adoptium",
+		}))
+	})
+
+	it("ignores unrelated output", func() {
+		Expect(boot.NewJDKFromSettings("Error: could not create the Java Virtual Machine.")).To(Equal(boot.JDK{}))
+	})
+
+	it("writes a CycloneDX component", func() {
+		dir, err := os.MkdirTemp("", "jdk")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "sbom.cdx.json")
+		Expect(boot.NewJDKFromSettings(jdkSettings).WriteCycloneDX(path)).To(Succeed())
+
+		content, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+
+		var sbom struct {
+			BOMFormat  string `json:"bomFormat"`
+			Components []struct {
+				Name      string `json:"name"`
+				Version   string `json:"version"`
+				Publisher string `json:"publisher"`
+			} `json:"components"`
+		}
+		Expect(json.Unmarshal(content, &sbom)).To(Succeed())
+		Expect(sbom.BOMFormat).To(Equal("CycloneDX"))
+		Expect(sbom.Components).To(HaveLen(1))
+		Expect(sbom.Components[0].Name).To(Equal("OpenJDK Runtime Environment"))
+		Expect(sbom.Components[0].Version).To(Equal("21.0.4"))
+		Expect(sbom.Components[0].Publisher).To(Equal("Eclipse Adoptium"))
+	})
+}
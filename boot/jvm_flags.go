@@ -0,0 +1,83 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DeniedJVMFlags are the prefixes of the JVM flags that load arbitrary code into the JVM, run commands or unlock
+// unsupported options. They are rejected from the arguments of the training run unless allowed with
+// $BP_JVM_CDS_ALLOWED_FLAGS.
+var DeniedJVMFlags = []string{
+	"-agentlib:",
+	"-agentpath:",
+	"-javaagent:",
+	"-Xdebug",
+	"-Xrunjdwp",
+	"-XX:OnError=",
+	"-XX:OnOutOfMemoryError=",
+	"-XX:+UnlockDiagnosticVMOptions",
+	"-XX:+UnlockExperimentalVMOptions",
+}
+
+// ParseAllowedFlags returns the comma-separated JVM flag prefixes in value, ignoring empty entries. It fails if an
+// entry is not a JVM flag.
+func ParseAllowedFlags(value string) ([]string, error) {
+	var flags []string
+	for _, f := range strings.Split(value, ",") {
+		if f = strings.TrimSpace(f); f == "" {
+			continue
+		}
+		if !strings.HasPrefix(f, "-") {
+			return nil, fmt.Errorf("%q is not a JVM flag", f)
+		}
+		flags = append(flags, f)
+	}
+	return flags, nil
+}
+
+// ValidateJVMFlags checks that flags, supplied by channel, contain none of the DeniedJVMFlags, unless they start with
+// one of the allowed prefixes.
+func ValidateJVMFlags(channel string, flags []string, allowed []string) error {
+	if denied := FindDeniedJVMFlags(flags, allowed); len(denied) > 0 {
+		return fmt.Errorf("JVM flag %s supplied by %s is denied, allow it explicitly with BP_JVM_CDS_ALLOWED_FLAGS", denied[0], channel)
+	}
+	return nil
+}
+
+// FindDeniedJVMFlags returns the flags starting with one of the DeniedJVMFlags, and none of the allowed prefixes.
+func FindDeniedJVMFlags(flags []string, allowed []string) []string {
+	var denied []string
+	for _, flag := range flags {
+		if hasFlagPrefix(flag, DeniedJVMFlags) && !hasFlagPrefix(flag, allowed) {
+			denied = append(denied, flag)
+		}
+	}
+	return denied
+}
+
+// hasFlagPrefix returns whether flag starts with one of prefixes.
+func hasFlagPrefix(flag string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(flag, p) {
+			return true
+		}
+	}
+	return false
+}
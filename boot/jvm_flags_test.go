@@ -0,0 +1,82 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testJVMFlags(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	context("ValidateJVMFlags", func() {
+		it("accepts the flags of a training run", func() {
+			Expect(boot.ValidateJVMFlags("test", []string{
+				"-Dspring.aot.enabled=true",
+				"-XX:SharedArchiveFile=base.jsa",
+				"-Xlog:cds*=debug:file=cds.log",
+				"-XX:+HeapDumpOnOutOfMemoryError",
+				"-Dspring.context.exit=onRefresh",
+				"-XX:ArchiveClassesAtExit=application.jsa",
+				"-cp", "runner.jar:lib/spring-core-6.1.10.jar",
+				"com.example.Application",
+			}, nil)).To(Succeed())
+		})
+
+		it("rejects a denied flag with the channel supplying it", func() {
+			err := boot.ValidateJVMFlags("BP_JVM_CDS_TEST", []string{"-ea", "-agentlib:jdwp=transport=dt_socket"}, nil)
+			Expect(err).To(MatchError("JVM flag -agentlib:jdwp=transport=dt_socket supplied by BP_JVM_CDS_TEST is denied, allow it explicitly with BP_JVM_CDS_ALLOWED_FLAGS"))
+
+			Expect(boot.ValidateJVMFlags("test", []string{"-XX:+UnlockDiagnosticVMOptions"}, nil)).To(MatchError(ContainSubstring("-XX:+UnlockDiagnosticVMOptions")))
+			Expect(boot.ValidateJVMFlags("test", []string{"-XX:OnError=rm -rf /"}, nil)).To(MatchError(ContainSubstring("-XX:OnError")))
+		})
+
+		it("accepts a denied flag explicitly allowed", func() {
+			Expect(boot.ValidateJVMFlags("test", []string{"-XX:+UnlockDiagnosticVMOptions", "-javaagent:/agents/otel.jar"},
+				[]string{"-XX:+UnlockDiagnosticVMOptions", "-javaagent:/agents/"})).To(Succeed())
+			Expect(boot.ValidateJVMFlags("test", []string{"-javaagent:/tmp/other.jar"},
+				[]string{"-javaagent:/agents/"})).To(MatchError(ContainSubstring("-javaagent:/tmp/other.jar")))
+		})
+	})
+
+	context("FindDeniedJVMFlags", func() {
+		it("returns the denied flags that are not allowed", func() {
+			Expect(boot.FindDeniedJVMFlags([]string{"-ea", "-javaagent:/agents/otel.jar", "-Xdebug", "-javaagent:/tmp/other.jar"},
+				[]string{"-javaagent:/agents/"})).To(Equal([]string{"-Xdebug", "-javaagent:/tmp/other.jar"}))
+			Expect(boot.FindDeniedJVMFlags([]string{"-ea"}, nil)).To(BeEmpty())
+		})
+	})
+
+	context("ParseAllowedFlags", func() {
+		it("splits the flags", func() {
+			Expect(boot.ParseAllowedFlags(" -XX:+UnlockDiagnosticVMOptions,,-javaagent:/agents/")).
+				To(Equal([]string{"-XX:+UnlockDiagnosticVMOptions", "-javaagent:/agents/"}))
+		})
+
+		it("fails with an entry that is not a flag", func() {
+			_, err := boot.ParseAllowedFlags("-ea,javaagent")
+			Expect(err).To(MatchError(`"javaagent" is not a JVM flag`))
+		})
+	})
+}
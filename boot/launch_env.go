@@ -0,0 +1,91 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+)
+
+// AliasableLaunchVariables are the launch environment variables contributed by the buildpack that LaunchEnvAliases
+// can mirror under other names.
+var AliasableLaunchVariables = []string{"BPL_JVM_CDS_ARCHIVE_FILE", "BPL_JVM_CDS_ENABLED", "BPL_SPRING_AOT_ENABLED"}
+
+// LaunchEnvAliases maps a launch environment variable of AliasableLaunchVariables to the names it is also contributed
+// as, for runtimes that do not read the standard names.
+type LaunchEnvAliases map[string][]string
+
+// ParseLaunchEnvAliases returns the comma-separated NAME=ALIAS entries in value, ignoring empty entries. A name may be
+// given several aliases. It fails if a name is not one of AliasableLaunchVariables or an alias is not a valid
+// environment variable name.
+func ParseLaunchEnvAliases(value string) (LaunchEnvAliases, error) {
+	aliases := LaunchEnvAliases{}
+	for _, e := range strings.Split(value, ",") {
+		if e = strings.TrimSpace(e); e == "" {
+			continue
+		}
+		name, alias, ok := strings.Cut(e, "=")
+		if name, alias = strings.TrimSpace(name), strings.TrimSpace(alias); !ok || alias == "" {
+			return nil, fmt.Errorf("%q is not a NAME=ALIAS entry", e)
+		}
+		if !slices.Contains(AliasableLaunchVariables, name) {
+			return nil, fmt.Errorf("%s cannot be aliased, expected one of %s", name, strings.Join(AliasableLaunchVariables, ", "))
+		}
+		if !envNamePattern.MatchString(alias) {
+			return nil, fmt.Errorf("%q is not a valid environment variable name", alias)
+		}
+		if slices.Contains(AliasableLaunchVariables, alias) {
+			return nil, fmt.Errorf("%s cannot be an alias, it is contributed by the buildpack", alias)
+		}
+		if !slices.Contains(aliases[name], alias) {
+			aliases[name] = append(aliases[name], alias)
+		}
+	}
+	return aliases, nil
+}
+
+// String returns the aliases in the format parsed by ParseLaunchEnvAliases, sorted by name.
+func (a LaunchEnvAliases) String() string {
+	var entries []string
+	for name, aliases := range a {
+		for _, alias := range aliases {
+			entries = append(entries, name+"="+alias)
+		}
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+// Default sets the default value of name in env, and of each of its aliases.
+func (a LaunchEnvAliases) Default(env libcnb.Environment, name string, value interface{}) {
+	env.Default(name, value)
+	for _, alias := range a[name] {
+		env.Default(alias, value)
+	}
+}
+
+// Delete removes the default value of name from env, and of each of its aliases.
+func (a LaunchEnvAliases) Delete(env libcnb.Environment, name string) {
+	delete(env, name+".default")
+	for _, alias := range a[name] {
+		delete(env, alias+".default")
+	}
+}
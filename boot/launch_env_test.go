@@ -0,0 +1,82 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testLaunchEnv(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	context("ParseLaunchEnvAliases", func() {
+		it("groups the aliases by name", func() {
+			aliases, err := boot.ParseLaunchEnvAliases(" BPL_JVM_CDS_ENABLED=CDS_ON,,BPL_SPRING_AOT_ENABLED = AOT_ON,BPL_JVM_CDS_ENABLED=XSHARE,BPL_JVM_CDS_ENABLED=CDS_ON")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(aliases).To(Equal(boot.LaunchEnvAliases{
+				"BPL_JVM_CDS_ENABLED":    {"CDS_ON", "XSHARE"},
+				"BPL_SPRING_AOT_ENABLED": {"AOT_ON"},
+			}))
+			Expect(aliases.String()).To(Equal("BPL_JVM_CDS_ENABLED=CDS_ON,BPL_JVM_CDS_ENABLED=XSHARE,BPL_SPRING_AOT_ENABLED=AOT_ON"))
+		})
+
+		it("fails without an alias", func() {
+			_, err := boot.ParseLaunchEnvAliases("BPL_JVM_CDS_ENABLED")
+			Expect(err).To(MatchError(`"BPL_JVM_CDS_ENABLED" is not a NAME=ALIAS entry`))
+		})
+
+		it("fails with a variable the buildpack does not contribute", func() {
+			_, err := boot.ParseLaunchEnvAliases("JAVA_TOOL_OPTIONS=OPTS")
+			Expect(err).To(MatchError(ContainSubstring("JAVA_TOOL_OPTIONS cannot be aliased")))
+		})
+
+		it("fails with an illegal alias", func() {
+			_, err := boot.ParseLaunchEnvAliases("BPL_JVM_CDS_ENABLED=CDS-ON")
+			Expect(err).To(MatchError(`"CDS-ON" is not a valid environment variable name`))
+		})
+
+		it("fails with an alias contributed by the buildpack", func() {
+			_, err := boot.ParseLaunchEnvAliases("BPL_JVM_CDS_ENABLED=BPL_SPRING_AOT_ENABLED")
+			Expect(err).To(MatchError("BPL_SPRING_AOT_ENABLED cannot be an alias, it is contributed by the buildpack"))
+		})
+	})
+
+	it("sets and deletes the aliases alongside the name", func() {
+		aliases := boot.LaunchEnvAliases{"BPL_JVM_CDS_ENABLED": {"CDS_ON"}}
+		env := libcnb.Environment{}
+
+		aliases.Default(env, "BPL_JVM_CDS_ENABLED", true)
+		aliases.Default(env, "BPL_SPRING_AOT_ENABLED", true)
+		Expect(env).To(Equal(libcnb.Environment{
+			"BPL_JVM_CDS_ENABLED.default":    "true",
+			"CDS_ON.default":                 "true",
+			"BPL_SPRING_AOT_ENABLED.default": "true",
+		}))
+
+		aliases.Delete(env, "BPL_JVM_CDS_ENABLED")
+		Expect(env).To(Equal(libcnb.Environment{"BPL_SPRING_AOT_ENABLED.default": "true"}))
+	})
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/magiconair/properties"
+)
+
+const (
+	JarLauncher        = "JarLauncher"
+	WarLauncher        = "WarLauncher"
+	PropertiesLauncher = "PropertiesLauncher"
+)
+
+// LauncherType returns the launcher able to boot the application described by manifest and laid out in appPath. An
+// existing launcher Main-Class is kept, PropertiesLauncher is selected when the manifest configures a Loader-Path and
+// WarLauncher when the libraries live in WEB-INF, JarLauncher otherwise.
+func LauncherType(appPath string, manifest *properties.Properties) string {
+	if mainClass, ok := manifest.Get("Main-Class"); ok {
+		for _, launcher := range []string{JarLauncher, WarLauncher, PropertiesLauncher} {
+			if strings.HasSuffix(mainClass, "."+launcher) {
+				return launcher
+			}
+		}
+	}
+
+	if _, ok := manifest.Get("Loader-Path"); ok {
+		return PropertiesLauncher
+	}
+
+	if IsWar(appPath, manifest) {
+		return WarLauncher
+	}
+	return JarLauncher
+}
+
+// IsWar returns whether the application described by manifest and laid out in appPath is packaged as a war: its
+// libraries live in WEB-INF rather than BOOT-INF.
+func IsWar(appPath string, manifest *properties.Properties) bool {
+	if lib, ok := manifest.Get("Spring-Boot-Lib"); ok {
+		return strings.HasPrefix(lib, "WEB-INF/")
+	}
+	if IsApplicationJar(appPath) {
+		return strings.HasSuffix(appPath, ".war")
+	}
+	_, err := os.Stat(filepath.Join(appPath, "WEB-INF"))
+	return err == nil
+}
+
+// ApplicationArchiveName returns the name of the archive an exploded application is re-zipped to, runner.war for a
+// war and runner.jar otherwise.
+func ApplicationArchiveName(appPath string, manifest *properties.Properties) string {
+	if IsWar(appPath, manifest) {
+		return "runner.war"
+	}
+	return "runner.jar"
+}
+
+// LauncherMainClass returns the fully qualified Main-Class of launcher for the given Spring Boot version. Spring Boot
+// 3.2 moved the launchers to the org.springframework.boot.loader.launch package.
+func LauncherMainClass(bootVersion string, launcher string) string {
+	if versionRespectsConstraint(bootVersion, ">= 3.2.0") {
+		return "org.springframework.boot.loader.launch." + launcher
+	}
+	return "org.springframework.boot.loader." + launcher
+}
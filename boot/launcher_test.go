@@ -0,0 +1,107 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/magiconair/properties"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testLauncher(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appPath string
+	)
+
+	it.Before(func() {
+		var err error
+		appPath, err = os.MkdirTemp("", "launcher")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(appPath)).To(Succeed())
+	})
+
+	context("LauncherType", func() {
+		it("selects JarLauncher for a jar layout", func() {
+			manifest := properties.MustLoadString("Spring-Boot-Lib: BOOT-INF/lib/")
+			Expect(boot.LauncherType(appPath, manifest)).To(Equal(boot.JarLauncher))
+		})
+
+		it("selects WarLauncher for a war layout", func() {
+			manifest := properties.MustLoadString("Spring-Boot-Lib: WEB-INF/lib/")
+			Expect(boot.LauncherType(appPath, manifest)).To(Equal(boot.WarLauncher))
+		})
+
+		it("selects WarLauncher when the application contains WEB-INF", func() {
+			Expect(os.MkdirAll(filepath.Join(appPath, "WEB-INF"), 0755)).To(Succeed())
+			Expect(boot.LauncherType(appPath, properties.NewProperties())).To(Equal(boot.WarLauncher))
+		})
+
+		it("selects PropertiesLauncher when a Loader-Path is configured", func() {
+			manifest := properties.MustLoadString("Spring-Boot-Lib: BOOT-INF/lib/\nLoader-Path: config/")
+			Expect(boot.LauncherType(appPath, manifest)).To(Equal(boot.PropertiesLauncher))
+		})
+
+		it("keeps an existing launcher", func() {
+			manifest := properties.MustLoadString("Main-Class: org.springframework.boot.loader.launch.PropertiesLauncher\nSpring-Boot-Lib: WEB-INF/lib/")
+			Expect(boot.LauncherType(appPath, manifest)).To(Equal(boot.PropertiesLauncher))
+		})
+	})
+
+	context("ApplicationArchiveName", func() {
+		it("re-zips a jar layout to runner.jar", func() {
+			manifest := properties.MustLoadString("Spring-Boot-Lib: BOOT-INF/lib/")
+			Expect(boot.IsWar(appPath, manifest)).To(BeFalse())
+			Expect(boot.ApplicationArchiveName(appPath, manifest)).To(Equal("runner.jar"))
+		})
+
+		it("re-zips a war layout to runner.war", func() {
+			manifest := properties.MustLoadString("Spring-Boot-Lib: WEB-INF/lib/")
+			Expect(boot.IsWar(appPath, manifest)).To(BeTrue())
+			Expect(boot.ApplicationArchiveName(appPath, manifest)).To(Equal("runner.war"))
+		})
+
+		it("names a war file without Spring-Boot-Lib runner.war", func() {
+			war := filepath.Join(t.TempDir(), "application.war")
+			Expect(os.WriteFile(war, []byte{}, 0644)).To(Succeed())
+
+			Expect(boot.ApplicationArchiveName(war, properties.NewProperties())).To(Equal("runner.war"))
+		})
+	})
+
+	context("LauncherMainClass", func() {
+		it("uses the launch package from Spring Boot 3.2", func() {
+			Expect(boot.LauncherMainClass("3.2.0", boot.JarLauncher)).To(Equal("org.springframework.boot.loader.launch.JarLauncher"))
+			Expect(boot.LauncherMainClass("3.3.1", boot.WarLauncher)).To(Equal("org.springframework.boot.loader.launch.WarLauncher"))
+		})
+
+		it("uses the loader package before Spring Boot 3.2", func() {
+			Expect(boot.LauncherMainClass("3.1.12", boot.JarLauncher)).To(Equal("org.springframework.boot.loader.JarLauncher"))
+			Expect(boot.LauncherMainClass("2.7.18", boot.PropertiesLauncher)).To(Equal("org.springframework.boot.loader.PropertiesLauncher"))
+		})
+	})
+}
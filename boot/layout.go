@@ -0,0 +1,161 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ValidateApplicationLayout checks that the application at appPath can be contributed: the extraction needs the jar to
+// be re-zipped from an exploded application, as the extracted layout replaces the application. A jar that is re-zipped
+// is used as is and replaced by the extracted layout.
+func ValidateApplicationLayout(appPath string, reZip bool) error {
+	info, err := os.Stat(appPath)
+	if err != nil {
+		return fmt.Errorf("unable to stat %s\n%w", appPath, err)
+	}
+
+	switch {
+	case info.IsDir() && reZip:
+		return nil
+	case info.IsDir():
+		return fmt.Errorf("%s is an exploded application, it must be re-zipped before it is extracted", appPath)
+	case !IsApplicationJar(appPath):
+		return fmt.Errorf("%s is neither an exploded application nor a jar and cannot be re-zipped", appPath)
+	case reZip:
+		return nil
+	default:
+		return fmt.Errorf("%s is a jar, the extraction requires an exploded application to replace", appPath)
+	}
+}
+
+// IsApplicationJar returns whether appPath is a jar or a war file rather than an exploded application.
+func IsApplicationJar(appPath string) bool {
+	if !strings.HasSuffix(appPath, ".jar") && !strings.HasSuffix(appPath, ".war") {
+		return false
+	}
+	info, err := os.Stat(appPath)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// ReZipMode is whether the application is re-zipped before it is extracted, set by $BP_SPRING_REZIP.
+type ReZipMode int
+
+const (
+	// ReZipAuto, the zero value, re-zips the application if its layout needs it, see DetectReZip.
+	ReZipAuto ReZipMode = iota
+
+	// ReZipAlways re-zips the application whatever its layout.
+	ReZipAlways
+
+	// ReZipNever never re-zips the application, which then cannot be extracted if it is exploded.
+	ReZipNever
+)
+
+func (m ReZipMode) String() string {
+	switch m {
+	case ReZipAuto:
+		return "auto"
+	case ReZipAlways:
+		return "true"
+	case ReZipNever:
+		return "false"
+	default:
+		return fmt.Sprintf("ReZipMode(%d)", int(m))
+	}
+}
+
+// ParseReZipMode returns the ReZipMode of value, ignoring case: auto, or a boolean forcing the re-zip on or off.
+func ParseReZipMode(value string) (ReZipMode, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "auto") {
+		return ReZipAuto, nil
+	}
+	reZip, err := strconv.ParseBool(value)
+	if err != nil {
+		return ReZipAuto, fmt.Errorf("%q is not one of auto, true or false", value)
+	}
+	if reZip {
+		return ReZipAlways, nil
+	}
+	return ReZipNever, nil
+}
+
+// ReZip returns whether the application at appPath is re-zipped: as forced by the mode, or as detected from its
+// layout by DetectReZip.
+func (m ReZipMode) ReZip(appPath string) bool {
+	switch m {
+	case ReZipAlways:
+		return true
+	case ReZipNever:
+		return false
+	default:
+		return DetectReZip(appPath)
+	}
+}
+
+// DetectReZip returns whether the layout of the application at appPath needs a re-zip before the extraction: an
+// exploded application is packed into a jar, and a jar is used as is in place of the re-zipped one. Anything else
+// cannot be re-zipped and is left to ValidateApplicationLayout to reject.
+func DetectReZip(appPath string) bool {
+	if info, err := os.Stat(appPath); err == nil && info.IsDir() {
+		return true
+	}
+	return IsApplicationJar(appPath)
+}
+
+// phase is a step of the contribution modifying the application.
+type phase int
+
+const (
+	phaseStarted phase = iota
+	phaseReZipped
+	phaseRemoved
+	phaseExtracted
+)
+
+func (p phase) String() string {
+	switch p {
+	case phaseStarted:
+		return "start"
+	case phaseReZipped:
+		return "re-zip"
+	case phaseRemoved:
+		return "removal"
+	case phaseExtracted:
+		return "extraction"
+	default:
+		return fmt.Sprintf("phase %d", int(p))
+	}
+}
+
+// phases tracks the steps modifying the application, each one must directly follow the one it depends on.
+type phases struct {
+	current phase
+}
+
+// to moves to next, failing if the current step is not after.
+func (p *phases) to(next phase, after phase) error {
+	if p.current != after {
+		return fmt.Errorf("invalid contribution order, %s must follow %s but follows %s", next, after, p.current)
+	}
+	p.current = next
+	return nil
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+const (
+	// CachedLayoutDir is the extracted layout kept in the performance layer, relative to the layer, with
+	// BP_JVM_CDS_CACHE_LAYOUT for the platform to restore it on the next build.
+	CachedLayoutDir = "cache/layout"
+
+	// LayoutKeyMetadata is the layer metadata recording the contents digest of the application the cached layout was
+	// extracted from.
+	LayoutKeyMetadata = "cds_layout_key"
+
+	// LayoutDigestMetadata is the layer metadata recording the contents digest of the cached layout.
+	LayoutDigestMetadata = "cds_layout_sha256"
+
+	// LayoutJarMetadata is the layer metadata recording the name of the re-zipped jar the cached layout was extracted
+	// from, kept in the layer next to it.
+	LayoutJarMetadata = "cds_layout_jar"
+)
+
+// cachedLayout is an extracted layout restored by the platform from a previous build, with the jar it was extracted
+// from.
+type cachedLayout struct {
+	dir string
+	jar string
+	key string
+}
+
+// takeCachedLayout moves the extracted layout and the re-zipped jar cached in layer out of it, as the layer is reset
+// before it is contributed again. The layout is ignored if the layer metadata does not record its key, or if it is not
+// the layout recorded.
+func (s SpringPerformance) takeCachedLayout(layer libcnb.Layer) (*cachedLayout, error) {
+	if !s.Config.CacheLayout || !s.DoTrainingRun {
+		return nil, nil
+	}
+
+	cached := filepath.Join(layer.Path, CachedLayoutDir)
+	if exists, err := sherpa.DirExists(cached); err != nil {
+		return nil, fmt.Errorf("unable to check for %s\n%w", cached, err)
+	} else if !exists {
+		return nil, nil
+	}
+
+	key, _ := layer.Metadata[LayoutKeyMetadata].(string)
+	expected, _ := layer.Metadata[LayoutDigestMetadata].(string)
+	name, _ := layer.Metadata[LayoutJarMetadata].(string)
+	if key == "" || name == "" || filepath.Base(name) != name {
+		s.log().Bodyf("Ignoring cached layout %s, the layer metadata does not describe it", cached)
+		return nil, nil
+	}
+	if digest, err := layoutDigest(cached); err != nil {
+		return nil, err
+	} else if digest != expected {
+		s.log().Bodyf("Ignoring cached layout %s, its digest sha256:%s is not the one recorded", cached, digest)
+		return nil, nil
+	}
+
+	dir, err := s.temp.MkdirTemp("cds-layout")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create temp directory for the cached layout\n%w", err)
+	}
+	layout := &cachedLayout{dir: filepath.Join(dir, "layout"), jar: filepath.Join(dir, name), key: key}
+	// the temporary directory may be on another file system than the layer
+	if err := moveArchive(filepath.Join(layer.Path, name), layout.jar); err != nil {
+		return nil, fmt.Errorf("unable to move the cached jar %s\n%w", name, err)
+	}
+	if err := sherpa.CopyDir(cached, layout.dir); err != nil {
+		return nil, fmt.Errorf("unable to copy the cached layout\n%w", err)
+	}
+	if err := os.RemoveAll(cached); err != nil {
+		return nil, fmt.Errorf("unable to remove %s\n%w", cached, err)
+	}
+	return layout, nil
+}
+
+// restoreLayout replaces the application with the cached layout, and copies the jar it was extracted from back into
+// layer. It returns the path of the jar.
+func (s SpringPerformance) restoreLayout(layer libcnb.Layer, layout *cachedLayout) (string, error) {
+	in, err := os.Open(layout.jar)
+	if err != nil {
+		return "", fmt.Errorf("unable to open %s\n%w", layout.jar, err)
+	}
+	defer in.Close()
+	if err := sherpa.CopyFile(in, filepath.Join(layer.Path, filepath.Base(layout.jar))); err != nil {
+		return "", fmt.Errorf("unable to copy the cached jar\n%w", err)
+	}
+
+	if err := RemoveApplication(s.AppPath); err != nil {
+		return "", fmt.Errorf("error removing the application\n%w", err)
+	}
+	if err := sherpa.CopyDir(layout.dir, s.AppPath); err != nil {
+		return "", fmt.Errorf("unable to copy the cached layout to %s\n%w", s.AppPath, err)
+	}
+	return layout.jar, nil
+}
+
+// cacheLayout copies the extracted layout at appPath into layer for the platform to restore it on the next build,
+// and returns its digest.
+func cacheLayout(layer libcnb.Layer, appPath string) (string, error) {
+	cached := filepath.Join(layer.Path, CachedLayoutDir)
+	if err := sherpa.CopyDir(appPath, cached); err != nil {
+		return "", fmt.Errorf("unable to copy %s to %s\n%w", appPath, cached, err)
+	}
+	return layoutDigest(cached)
+}
+
+// layoutDigest returns the contents digest of the layout at dir.
+func layoutDigest(dir string) (string, error) {
+	digests, err := ContentDigests(filepath.Clean(dir) + string(filepath.Separator))
+	if err != nil {
+		return "", fmt.Errorf("unable to compute the digests of %s\n%w", dir, err)
+	}
+	return ContentsDigest(digests), nil
+}
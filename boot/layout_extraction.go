@@ -0,0 +1,249 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/paketo-buildpacks/libjvm"
+	"gopkg.in/yaml.v3"
+)
+
+// manifestLineLength is the maximum length in bytes of a manifest line, longer values continuing on the next lines.
+const manifestLineLength = 72
+
+// layoutManifestAttributes are the attributes of the manifest of a Spring Boot jar that are not copied to the
+// application jar of the extracted layout, which is not started by the Spring Boot loader.
+var layoutManifestAttributes = []string{
+	"Class-Path",
+	"Main-Class",
+	"Manifest-Version",
+	"Spring-Boot-Classes",
+	"Spring-Boot-Classpath-Index",
+	"Spring-Boot-Layers-Index",
+	"Spring-Boot-Lib",
+	"Start-Class",
+}
+
+// ExtractBootJarLayout extracts the Spring Boot jar at jarPath into dest without the JVM, for jars that do not ship the
+// tools jarmode, in the layout it extracts: an application jar named after jarPath, holding the application classes,
+// the META-INF entries of the jar and a manifest with the Start-Class as Main-Class and the nested jars as Class-Path,
+// in the order of the classpath index. The nested jars are copied to the lib directory of dest, the classes of the
+// Spring Boot loader are left out.
+func ExtractBootJarLayout(jarPath string, dest string) error {
+	manifest, err := libjvm.NewManifestFromJAR(jarPath)
+	if err != nil {
+		return fmt.Errorf("unable to read manifest of %s\n%w", jarPath, err)
+	}
+	startClass, ok := manifest.Get("Start-Class")
+	if !ok {
+		return WithHint(fmt.Errorf("manifest of %s does not contain Start-Class", jarPath), HintStartClass)
+	}
+	classesPrefix := strings.TrimSuffix(manifest.GetString("Spring-Boot-Classes", "BOOT-INF/classes"), "/") + "/"
+	libPrefix := strings.TrimSuffix(manifest.GetString("Spring-Boot-Lib", "BOOT-INF/lib"), "/") + "/"
+
+	r, err := zip.OpenReader(jarPath)
+	if err != nil {
+		return fmt.Errorf("unable to open %s\n%w", jarPath, err)
+	}
+	defer r.Close()
+
+	libDir := filepath.Join(dest, "lib")
+	if err := os.MkdirAll(libDir, 0755); err != nil {
+		return fmt.Errorf("unable to create %s\n%w", libDir, err)
+	}
+
+	var (
+		libs    []string
+		entries []*zip.File
+		names   = map[string]string{}
+	)
+	for _, f := range r.File {
+		switch {
+		case strings.HasPrefix(f.Name, libPrefix):
+			if f.FileInfo().IsDir() || !strings.HasSuffix(f.Name, ".jar") {
+				continue
+			}
+			lib := path.Join("lib", path.Base(f.Name))
+			if err := extractZipFile(f, filepath.Join(dest, filepath.FromSlash(lib))); err != nil {
+				return err
+			}
+			libs = append(libs, lib)
+		case strings.HasPrefix(f.Name, classesPrefix):
+			// the application classes take precedence over the entries of the jar with the same name
+			if name := strings.TrimPrefix(f.Name, classesPrefix); name != "" {
+				names[name] = f.Name
+				entries = append(entries, f)
+			}
+		case strings.HasPrefix(f.Name, "META-INF/") && f.Name != ManifestEntryName:
+			if _, ok := names[f.Name]; !ok {
+				names[f.Name] = f.Name
+				entries = append(entries, f)
+			}
+		}
+	}
+
+	index, err := classpathIndex(&r.Reader, manifest.GetString("Spring-Boot-Classpath-Index", ""), libPrefix)
+	if err != nil {
+		return err
+	}
+	libs = OrderClasspath(libs, index)
+
+	attributes := [][2]string{{"Manifest-Version", "1.0"}, {"Main-Class", startClass}}
+	for _, key := range manifest.Keys() {
+		if !slices.Contains(layoutManifestAttributes, key) {
+			attributes = append(attributes, [2]string{key, manifest.GetString(key, "")})
+		}
+	}
+	if len(libs) > 0 {
+		var classPath []string
+		for _, lib := range libs {
+			classPath = append(classPath, path.Join(path.Dir(lib), url.PathEscape(path.Base(lib))))
+		}
+		attributes = append(attributes, [2]string{"Class-Path", strings.Join(classPath, " ")})
+	}
+
+	return writeLayoutJar(filepath.Join(dest, filepath.Base(jarPath)), attributes, entries, names, classesPrefix)
+}
+
+// writeLayoutJar writes the application jar of the extracted layout to file, with a manifest of attributes and the
+// entries renamed as in names, the application classes without classesPrefix.
+func writeLayoutJar(file string, attributes [][2]string, entries []*zip.File, names map[string]string, classesPrefix string) error {
+	out, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("unable to create %s\n%w", file, err)
+	}
+	defer out.Close()
+
+	w := zip.NewWriter(out)
+	m, err := w.Create(ManifestEntryName)
+	if err != nil {
+		return fmt.Errorf("unable to write manifest of %s\n%w", file, err)
+	}
+	if _, err := m.Write(manifestBytes(attributes)); err != nil {
+		return fmt.Errorf("unable to write manifest of %s\n%w", file, err)
+	}
+
+	for _, f := range entries {
+		name := strings.TrimPrefix(f.Name, classesPrefix)
+		if names[name] != f.Name {
+			continue
+		}
+
+		header := f.FileHeader
+		header.Name = name
+		raw, err := f.OpenRaw()
+		if err != nil {
+			return fmt.Errorf("unable to read %s\n%w", f.Name, err)
+		}
+		dst, err := w.CreateRaw(&header)
+		if err != nil {
+			return fmt.Errorf("unable to write %s to %s\n%w", name, file, err)
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if _, err := io.Copy(dst, raw); err != nil {
+			return fmt.Errorf("unable to write %s to %s\n%w", name, file, err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("unable to close %s\n%w", file, err)
+	}
+	return nil
+}
+
+// classpathIndex returns the nested jars listed by the classpath index at name in r, relative to the lib directory of
+// the extracted layout. It returns nil if name is empty or the index does not exist.
+func classpathIndex(r *zip.Reader, name string, libPrefix string) ([]string, error) {
+	if name == "" {
+		return nil, nil
+	}
+	f, err := r.Open(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to open %s\n%w", name, err)
+	}
+	defer f.Close()
+
+	var entries []string
+	if err := yaml.NewDecoder(f).Decode(&entries); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("unable to decode %s\n%w", name, err)
+	}
+
+	// the index lists the jars by name or, in most versions, by their path in the jar
+	var index []string
+	for _, e := range entries {
+		index = append(index, path.Join("lib", path.Base(strings.TrimPrefix(e, libPrefix))))
+	}
+	return index, nil
+}
+
+// extractZipFile writes the content of f to file, with the modification time of f.
+func extractZipFile(f *zip.File, file string) error {
+	in, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("unable to read %s\n%w", f.Name, err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("unable to create %s\n%w", file, err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return fmt.Errorf("unable to extract %s to %s\n%w", f.Name, file, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("unable to close %s\n%w", file, err)
+	}
+	return os.Chtimes(file, f.Modified, f.Modified)
+}
+
+// manifestBytes returns the manifest of attributes, its lines wrapped at 72 bytes as required by the JVM.
+func manifestBytes(attributes [][2]string) []byte {
+	buf := &bytes.Buffer{}
+	for _, a := range attributes {
+		line := a[0] + ": " + a[1]
+		for limit := manifestLineLength; len(line) > limit; limit = manifestLineLength - 1 {
+			// a multi-byte character is not split across lines
+			i := limit
+			for i > 0 && !utf8.RuneStart(line[i]) {
+				i--
+			}
+			buf.WriteString(line[:i] + "\r\n ")
+			line = line[i:]
+		}
+		buf.WriteString(line + "\r\n")
+	}
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
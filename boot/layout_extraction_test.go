@@ -0,0 +1,147 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libjvm"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testExtractBootJarLayout(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dest string
+		jar  = filepath.Join("testdata", "extraction", "spring-app-2.7.jar")
+	)
+
+	it.Before(func() {
+		dest = t.TempDir()
+	})
+
+	jarEntries := func(file string) []string {
+		r, err := zip.OpenReader(file)
+		Expect(err).NotTo(HaveOccurred())
+		defer r.Close()
+
+		var names []string
+		for _, f := range r.File {
+			names = append(names, f.Name)
+		}
+		return names
+	}
+
+	it("extracts the layout of the tools jarmode", func() {
+		Expect(boot.ExtractBootJarLayout(jar, dest)).To(Succeed())
+
+		Expect(boot.BuildClasspath(dest)).To(Equal(strings.Join([]string{
+			"spring-app-2.7.jar", "lib/spring-boot-2.7.18.jar", "lib/spring-core-5.3.31.jar",
+		}, string(filepath.ListSeparator))))
+		Expect(jarEntries(filepath.Join(dest, "lib", "spring-core-5.3.31.jar"))).To(ContainElement("org/springframework/core/SpringVersion.class"))
+	})
+
+	it("moves the application classes to the root of the application jar", func() {
+		Expect(boot.ExtractBootJarLayout(jar, dest)).To(Succeed())
+
+		entries := jarEntries(filepath.Join(dest, "spring-app-2.7.jar"))
+		Expect(entries[0]).To(Equal("META-INF/MANIFEST.MF"))
+		Expect(entries).To(ContainElements(
+			"com/example/Application.class",
+			"application.properties",
+			"META-INF/spring.factories",
+			"META-INF/maven/com.example/spring-app/pom.properties",
+		))
+		Expect(entries).NotTo(ContainElement("org/springframework/boot/loader/JarLauncher.class"))
+		Expect(entries).NotTo(ContainElement(HavePrefix("BOOT-INF/")))
+
+		r, err := zip.OpenReader(filepath.Join(dest, "spring-app-2.7.jar"))
+		Expect(err).NotTo(HaveOccurred())
+		defer r.Close()
+		f, err := r.Open("com/example/Application.class")
+		Expect(err).NotTo(HaveOccurred())
+		defer f.Close()
+		Expect(io.ReadAll(f)).To(Equal([]byte("application")))
+	})
+
+	it("starts the application jar with the Start-Class", func() {
+		Expect(boot.ExtractBootJarLayout(jar, dest)).To(Succeed())
+
+		manifest, err := libjvm.NewManifestFromJAR(filepath.Join(dest, "spring-app-2.7.jar"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.GetString("Main-Class", "")).To(Equal("com.example.Application"))
+		Expect(manifest.GetString("Implementation-Title", "")).To(Equal("spring-app"))
+		Expect(manifest.Keys()).NotTo(ContainElements("Start-Class", "Spring-Boot-Lib", "Spring-Boot-Classpath-Index"))
+	})
+
+	it("wraps the lines of the manifest", func() {
+		file := filepath.Join(t.TempDir(), "many-dependencies.jar")
+		out, err := os.Create(file)
+		Expect(err).NotTo(HaveOccurred())
+		w := zip.NewWriter(out)
+		m, err := w.Create("META-INF/MANIFEST.MF")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = m.Write([]byte("Manifest-Version: 1.0\r\nStart-Class: com.example.Application\r\n\r\n"))
+		Expect(err).NotTo(HaveOccurred())
+		var expected []string
+		for i := 0; i < 20; i++ {
+			name := fmt.Sprintf("spring-boot-starter-dependency-with-a-long-name-%d-1.0.0.jar", i)
+			_, err = w.Create("BOOT-INF/lib/" + name)
+			Expect(err).NotTo(HaveOccurred())
+			expected = append(expected, "lib/"+name)
+		}
+		Expect(w.Close()).To(Succeed())
+		Expect(out.Close()).To(Succeed())
+
+		Expect(boot.ExtractBootJarLayout(file, dest)).To(Succeed())
+
+		r, err := zip.OpenReader(filepath.Join(dest, "many-dependencies.jar"))
+		Expect(err).NotTo(HaveOccurred())
+		defer r.Close()
+		f, err := r.Open("META-INF/MANIFEST.MF")
+		Expect(err).NotTo(HaveOccurred())
+		b, err := io.ReadAll(f)
+		Expect(err).NotTo(HaveOccurred())
+		for _, line := range strings.Split(string(b), "\r\n") {
+			Expect(len(line)).To(BeNumerically("<=", 72))
+		}
+
+		manifest, err := libjvm.NewManifestFromJAR(filepath.Join(dest, "many-dependencies.jar"))
+		Expect(err).NotTo(HaveOccurred())
+		classPath, _ := manifest.Get("Class-Path")
+		Expect(strings.Fields(classPath)).To(ConsistOf(expected))
+	})
+
+	it("fails without Start-Class", func() {
+		file := filepath.Join(t.TempDir(), "no-start-class.jar")
+		writeJarWithManifest(t, file, "Manifest-Version: 1.0\n")
+
+		err := boot.ExtractBootJarLayout(file, dest)
+		Expect(err).To(MatchError(ContainSubstring("does not contain Start-Class")))
+		Expect(errorHint(err)).To(Equal(boot.HintStartClass))
+	})
+}
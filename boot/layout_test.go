@@ -0,0 +1,113 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testLayout(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dir string
+		jar string
+	)
+
+	it.Before(func() {
+		dir = t.TempDir()
+		jar = filepath.Join(t.TempDir(), "application.jar")
+		Expect(os.WriteFile(jar, []byte{}, 0644)).To(Succeed())
+	})
+
+	it("accepts an exploded application that is re-zipped", func() {
+		Expect(boot.ValidateApplicationLayout(dir, true)).To(Succeed())
+	})
+
+	it("rejects an exploded application that is not re-zipped", func() {
+		Expect(boot.ValidateApplicationLayout(dir, false)).To(MatchError(ContainSubstring("it must be re-zipped before it is extracted")))
+	})
+
+	it("accepts a jar that is re-zipped", func() {
+		Expect(boot.ValidateApplicationLayout(jar, true)).To(Succeed())
+	})
+
+	it("rejects re-zipping a file that is not a jar", func() {
+		file := filepath.Join(t.TempDir(), "application.txt")
+		Expect(os.WriteFile(file, []byte{}, 0644)).To(Succeed())
+
+		Expect(boot.ValidateApplicationLayout(file, true)).To(MatchError(ContainSubstring("is neither an exploded application nor a jar and cannot be re-zipped")))
+	})
+
+	it("rejects extracting a jar", func() {
+		Expect(boot.ValidateApplicationLayout(jar, false)).To(MatchError(ContainSubstring("the extraction requires an exploded application")))
+	})
+
+	it("rejects a missing application", func() {
+		Expect(boot.ValidateApplicationLayout(filepath.Join(dir, "missing"), true)).To(MatchError(ContainSubstring("unable to stat")))
+	})
+
+	it("rejects extracting a file that is not a jar", func() {
+		file := filepath.Join(t.TempDir(), "application.txt")
+		Expect(os.WriteFile(file, []byte{}, 0644)).To(Succeed())
+
+		Expect(boot.ValidateApplicationLayout(file, false)).To(MatchError(ContainSubstring("is neither an exploded application nor a jar")))
+	})
+
+	it("recognizes a jar file", func() {
+		Expect(boot.IsApplicationJar(jar)).To(BeTrue())
+		Expect(boot.IsApplicationJar(dir)).To(BeFalse())
+		Expect(boot.IsApplicationJar(filepath.Join(dir, "missing.jar"))).To(BeFalse())
+	})
+
+	context("re-zip detection", func() {
+		it("re-zips an exploded application and a jar", func() {
+			Expect(boot.DetectReZip(dir)).To(BeTrue())
+			Expect(boot.DetectReZip(jar)).To(BeTrue())
+		})
+
+		it("does not re-zip a file that is not a jar or a missing application", func() {
+			file := filepath.Join(t.TempDir(), "application.txt")
+			Expect(os.WriteFile(file, []byte{}, 0644)).To(Succeed())
+
+			Expect(boot.DetectReZip(file)).To(BeFalse())
+			Expect(boot.DetectReZip(filepath.Join(dir, "missing"))).To(BeFalse())
+		})
+
+		it("detects the re-zip unless forced", func() {
+			Expect(boot.ReZipAuto.ReZip(dir)).To(BeTrue())
+			Expect(boot.ReZipNever.ReZip(dir)).To(BeFalse())
+			Expect(boot.ReZipNever.ReZip(jar)).To(BeFalse())
+			Expect(boot.ReZipAlways.ReZip(filepath.Join(dir, "missing"))).To(BeTrue())
+		})
+
+		it("parses the mode", func() {
+			for value, mode := range map[string]boot.ReZipMode{"auto": boot.ReZipAuto, " AUTO ": boot.ReZipAuto, "true": boot.ReZipAlways, "0": boot.ReZipNever} {
+				Expect(boot.ParseReZipMode(value)).To(Equal(mode))
+			}
+			_, err := boot.ParseReZipMode("sometimes")
+			Expect(err).To(MatchError(`"sometimes" is not one of auto, true or false`))
+		})
+	})
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/magiconair/properties"
+	"gopkg.in/yaml.v3"
+)
+
+// LazyInitializationProperty is the Spring Boot property making the beans be created when they are first needed
+// rather than when the application context is refreshed.
+const LazyInitializationProperty = "spring.main.lazy-initialization"
+
+// LazyInitialization returns the application configuration file in classesDir enabling LazyInitializationProperty
+// with the profiles active, or an empty string if it is not enabled. The application.properties, application.yml and
+// application.yaml files, then their profile specific variants, are read in the order in which Spring Boot overrides
+// their properties.
+func LazyInitialization(classesDir string, profiles ...string) (string, error) {
+	names := []string{"application"}
+	for _, p := range profiles {
+		names = append(names, fmt.Sprintf("application-%s", p))
+	}
+
+	var file, value string
+	for _, name := range names {
+		for _, extension := range []string{".yaml", ".yml"} {
+			f := filepath.Join(classesDir, name+extension)
+			v, ok, err := yamlProperty(f, LazyInitializationProperty, profiles)
+			if err != nil {
+				return "", err
+			} else if ok {
+				file, value = f, v
+			}
+		}
+
+		f := filepath.Join(classesDir, name+".properties")
+		v, ok, err := propertiesProperty(f, LazyInitializationProperty)
+		if err != nil {
+			return "", err
+		} else if ok {
+			file, value = f, v
+		}
+	}
+
+	if !strings.EqualFold(strings.TrimSpace(value), "true") {
+		return "", nil
+	}
+	return file, nil
+}
+
+// propertiesProperty returns the value of key in the properties file, if it exists.
+func propertiesProperty(file string, key string) (string, bool, error) {
+	// the Spring placeholders are not expanded, they may refer to the environment of the application
+	loader := properties.Loader{Encoding: properties.UTF8, DisableExpansion: true}
+	p, err := loader.LoadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, fmt.Errorf("unable to read %s\n%w", file, err)
+	}
+
+	value, ok := p.Get(key)
+	return value, ok, nil
+}
+
+// yamlProperty returns the value of key in the YAML file, if it exists. The documents activated on a profile that is
+// not one of profiles are ignored.
+func yamlProperty(file string, key string, profiles []string) (string, bool, error) {
+	in, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, fmt.Errorf("unable to open %s\n%w", file, err)
+	}
+	defer in.Close()
+
+	var (
+		value string
+		found bool
+	)
+	decoder := yaml.NewDecoder(in)
+	for {
+		var document map[string]interface{}
+		if err := decoder.Decode(&document); errors.Is(err, io.EOF) {
+			return value, found, nil
+		} else if err != nil {
+			return "", false, fmt.Errorf("unable to decode %s\n%w", file, err)
+		}
+
+		if profile, ok := yamlValue(document, "spring.config.activate.on-profile"); ok && !slices.Contains(profiles, fmt.Sprint(profile)) {
+			continue
+		}
+		if v, ok := yamlValue(document, key); ok {
+			value, found = fmt.Sprint(v), true
+		}
+	}
+}
+
+// yamlValue returns the value of the dotted key in document, whose keys may themselves be dotted.
+func yamlValue(document map[string]interface{}, key string) (interface{}, bool) {
+	if value, ok := document[key]; ok {
+		return value, true
+	}
+	for k, v := range document {
+		rest, ok := strings.CutPrefix(key, k+".")
+		if !ok {
+			continue
+		}
+		if nested, ok := v.(map[string]interface{}); ok {
+			if value, ok := yamlValue(nested, rest); ok {
+				return value, true
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testLazyInitialization(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		classes string
+	)
+
+	it.Before(func() {
+		classes = t.TempDir()
+	})
+
+	it("detects lazy initialization enabled in application.yml", func() {
+		file, err := boot.LazyInitialization("testdata/lazy-initialization")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file).To(Equal(filepath.Join("testdata/lazy-initialization", "application.yml")))
+	})
+
+	it("ignores lazy initialization disabled by an active profile", func() {
+		file, err := boot.LazyInitialization("testdata/lazy-initialization", "eager")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file).To(BeEmpty())
+	})
+
+	it("detects lazy initialization enabled in application.properties", func() {
+		Expect(os.WriteFile(filepath.Join(classes, "application.properties"), []byte("spring.main.lazy-initialization = TRUE\n"), 0644)).To(Succeed())
+
+		file, err := boot.LazyInitialization(classes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file).To(Equal(filepath.Join(classes, "application.properties")))
+	})
+
+	it("detects lazy initialization with a dotted YAML key", func() {
+		Expect(os.WriteFile(filepath.Join(classes, "application.yaml"), []byte("spring.main:\n  lazy-initialization: true\n"), 0644)).To(Succeed())
+
+		file, err := boot.LazyInitialization(classes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file).To(Equal(filepath.Join(classes, "application.yaml")))
+	})
+
+	it("lets application.properties override application.yml", func() {
+		Expect(os.WriteFile(filepath.Join(classes, "application.yml"), []byte("spring:\n  main:\n    lazy-initialization: true\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(classes, "application.properties"), []byte("spring.main.lazy-initialization=false\n"), 0644)).To(Succeed())
+
+		file, err := boot.LazyInitialization(classes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file).To(BeEmpty())
+	})
+
+	it("detects lazy initialization enabled in a profile specific file", func() {
+		Expect(os.WriteFile(filepath.Join(classes, "application.properties"), []byte("spring.main.lazy-initialization=false\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(classes, "application-lazy.properties"), []byte("spring.main.lazy-initialization=true\n"), 0644)).To(Succeed())
+
+		file, err := boot.LazyInitialization(classes, "lazy")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file).To(Equal(filepath.Join(classes, "application-lazy.properties")))
+
+		file, err = boot.LazyInitialization(classes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file).To(BeEmpty())
+	})
+
+	it("returns nothing without application configuration", func() {
+		file, err := boot.LazyInitialization(classes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file).To(BeEmpty())
+	})
+
+	it("fails with a malformed application.yml", func() {
+		Expect(os.WriteFile(filepath.Join(classes, "application.yml"), []byte("spring: [\n"), 0644)).To(Succeed())
+
+		_, err := boot.LazyInitialization(classes)
+		Expect(err).To(MatchError(ContainSubstring("unable to decode")))
+	})
+}
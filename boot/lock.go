@@ -0,0 +1,62 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// AppLockPath returns the lock file serializing the contributions sharing appPath. It is located in the temporary
+// directory rather than in appPath, which is removed and recreated by the re-zip.
+func AppLockPath(appPath string) (string, error) {
+	abs, err := filepath.Abs(appPath)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve %s\n%w", appPath, err)
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(os.TempDir(), fmt.Sprintf("spring-boot-%s.lock", hex.EncodeToString(sum[:8]))), nil
+}
+
+// LockAppPath acquires an exclusive lock on appPath, waiting for any other holder to release it, and returns the
+// function releasing it.
+func LockAppPath(appPath string) (func() error, error) {
+	path, err := AppLockPath(appPath)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open lock file %s\n%w", path, err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("unable to lock %s with %s\n%w", appPath, path, err)
+	}
+
+	return func() error {
+		defer f.Close()
+		if err := unlockFile(f); err != nil {
+			return fmt.Errorf("unable to unlock %s\n%w", path, err)
+		}
+		return nil
+	}, nil
+}
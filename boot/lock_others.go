@@ -0,0 +1,30 @@
+//go:build !unix
+
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import "os"
+
+// lockFile does not lock on platforms without flock, contributions sharing an application are not serialized.
+func lockFile(_ *os.File) error {
+	return nil
+}
+
+func unlockFile(_ *os.File) error {
+	return nil
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testLock(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appPath string
+	)
+
+	it.Before(func() {
+		appPath = t.TempDir()
+	})
+
+	it("locks outside of the application", func() {
+		path, err := boot.AppLockPath(appPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(path).NotTo(HavePrefix(appPath))
+
+		other, err := boot.AppLockPath(t.TempDir())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(other).NotTo(Equal(path))
+	})
+
+	it("serializes goroutines contending for the lock", func() {
+		if runtime.GOOS == "windows" {
+			t.Skip("flock is not available on windows")
+		}
+
+		var (
+			mu      sync.Mutex
+			holders int
+			most    int
+			wg      sync.WaitGroup
+		)
+
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				unlock, err := boot.LockAppPath(appPath)
+				Expect(err).NotTo(HaveOccurred())
+
+				mu.Lock()
+				holders++
+				most = max(most, holders)
+				mu.Unlock()
+
+				time.Sleep(50 * time.Millisecond)
+
+				mu.Lock()
+				holders--
+				mu.Unlock()
+
+				Expect(unlock()).To(Succeed())
+			}()
+		}
+		wg.Wait()
+
+		Expect(most).To(Equal(1))
+	})
+
+	it("can be locked again once released", func() {
+		unlock, err := boot.LockAppPath(appPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unlock()).To(Succeed())
+
+		unlock, err = boot.LockAppPath(appPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unlock()).To(Succeed())
+	})
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/bard"
+)
+
+// LogLevel is the verbosity of the log of the contribution, set by $BP_SPRING_PERFORMANCE_LOG_LEVEL.
+type LogLevel int
+
+const (
+	// LogQuiet logs only the warnings and the summary of the contribution, its errors being returned.
+	LogQuiet LogLevel = iota - 1
+
+	// LogNormal, the zero value, logs the progress of the contribution and the output of the commands it runs.
+	LogNormal
+
+	// LogVerbose also logs the diagnostics of the application and of the training run classpath.
+	LogVerbose
+
+	// LogDebug also logs the resolved commands, the duration of each phase and the progress of the re-zip.
+	LogDebug
+)
+
+var logLevels = []LogLevel{LogQuiet, LogNormal, LogVerbose, LogDebug}
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogQuiet:
+		return "quiet"
+	case LogNormal:
+		return "normal"
+	case LogVerbose:
+		return "verbose"
+	case LogDebug:
+		return "debug"
+	default:
+		return fmt.Sprintf("LogLevel(%d)", int(l))
+	}
+}
+
+// ParseLogLevel returns the LogLevel named by value, ignoring case: quiet, normal, verbose or debug.
+func ParseLogLevel(value string) (LogLevel, error) {
+	for _, l := range logLevels {
+		if strings.EqualFold(strings.TrimSpace(value), l.String()) {
+			return l, nil
+		}
+	}
+	return LogNormal, fmt.Errorf("%q is not one of quiet, normal, verbose or debug", value)
+}
+
+// performanceLogger gates the log of the contribution by its LogLevel. The headers, the warnings included, are always
+// logged.
+type performanceLogger struct {
+	bard.Logger
+	level LogLevel
+}
+
+// log returns the logger of the contribution. The level is debug if BP_SPRING_PERFORMANCE_LOG_LEVEL is not set and
+// Logger logs at debug level.
+func (s SpringPerformance) log() performanceLogger {
+	level := s.Config.LogLevel
+	if level == LogNormal && s.Logger.IsDebugEnabled() {
+		level = LogDebug
+	}
+	return performanceLogger{Logger: s.Logger, level: level}
+}
+
+// Bodyf logs the progress of the contribution, unless quiet.
+func (l performanceLogger) Bodyf(format string, a ...interface{}) {
+	if l.level > LogQuiet {
+		l.Logger.Bodyf(format, a...)
+	}
+}
+
+// Summaryf logs the outcome of the contribution, at every level.
+func (l performanceLogger) Summaryf(format string, a ...interface{}) {
+	l.Logger.Bodyf(format, a...)
+}
+
+// InfoWriter returns the writer of the output of the commands, nil if quiet.
+func (l performanceLogger) InfoWriter() io.Writer {
+	if l.level == LogQuiet {
+		return nil
+	}
+	return l.Logger.InfoWriter()
+}
+
+// IsVerbose returns whether the diagnostics are logged.
+func (l performanceLogger) IsVerbose() bool {
+	return l.level >= LogVerbose
+}
+
+// IsDebugEnabled returns whether the debug messages are logged.
+func (l performanceLogger) IsDebugEnabled() bool {
+	return l.level >= LogDebug
+}
+
+// Debugf logs a debug message, to the debug writer of Logger if it has one and as a body otherwise.
+func (l performanceLogger) Debugf(format string, a ...interface{}) {
+	switch {
+	case l.level < LogDebug:
+	case l.Logger.IsDebugEnabled():
+		l.Logger.Debugf(format, a...)
+	default:
+		l.Logger.Bodyf(format, a...)
+	}
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testLogLevel(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	context("ParseLogLevel", func() {
+		it("parses the levels ignoring case", func() {
+			Expect(boot.ParseLogLevel("quiet")).To(Equal(boot.LogQuiet))
+			Expect(boot.ParseLogLevel("Normal")).To(Equal(boot.LogNormal))
+			Expect(boot.ParseLogLevel("VERBOSE")).To(Equal(boot.LogVerbose))
+			Expect(boot.ParseLogLevel(" debug ")).To(Equal(boot.LogDebug))
+		})
+
+		it("fails on an unknown level", func() {
+			_, err := boot.ParseLogLevel("trace")
+			Expect(err).To(MatchError(ContainSubstring(`"trace" is not one of quiet, normal, verbose or debug`)))
+		})
+	})
+
+	it("orders the levels by verbosity", func() {
+		Expect(boot.LogQuiet < boot.LogNormal && boot.LogNormal < boot.LogVerbose && boot.LogVerbose < boot.LogDebug).To(BeTrue())
+		Expect(boot.PerformanceConfig{}.LogLevel).To(Equal(boot.LogNormal))
+	})
+}
@@ -0,0 +1,47 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/magiconair/properties"
+)
+
+// MaxManifestValueLength is the length above which a single valued manifest entry, such as Start-Class, is considered
+// corrupt.
+const MaxManifestValueLength = 1024
+
+// ManifestValue returns the value of a single valued manifest entry, trimmed of surrounding whitespace. It fails if
+// the value is too long or contains control characters such as CR or LF, which indicates a malformed manifest.
+func ManifestValue(manifest *properties.Properties, key string) (string, bool, error) {
+	value, ok := manifest.Get(key)
+	if !ok {
+		return "", false, nil
+	}
+
+	value = strings.TrimSpace(value)
+	if len(value) > MaxManifestValueLength {
+		return "", true, fmt.Errorf("manifest entry %s is %d characters long, the maximum is %d", key, len(value), MaxManifestValueLength)
+	}
+	if i := strings.IndexFunc(value, unicode.IsControl); i != -1 {
+		return "", true, fmt.Errorf("manifest entry %s contains the control character %q at position %d", key, value[i], i)
+	}
+	return value, true, nil
+}
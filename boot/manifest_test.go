@@ -0,0 +1,92 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libjvm"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testManifest(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appPath string
+	)
+
+	it.Before(func() {
+		var err error
+		appPath, err = os.MkdirTemp("", "manifest")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(appPath, "META-INF"), 0755)).To(Succeed())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(appPath)).To(Succeed())
+	})
+
+	writeManifest := func(content string) {
+		Expect(os.WriteFile(filepath.Join(appPath, "META-INF", "MANIFEST.MF"), []byte(content), 0644)).To(Succeed())
+	}
+
+	it("trims surrounding whitespace", func() {
+		writeManifest("Start-Class: com.example.Application \t \n")
+		manifest, err := libjvm.NewManifest(appPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		value, ok, err := boot.ManifestValue(manifest, "Start-Class")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal("com.example.Application"))
+	})
+
+	it("rejects embedded newlines", func() {
+		writeManifest("Start-Class: com.example.\\nApplication\n")
+		manifest, err := libjvm.NewManifest(appPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _, err = boot.ManifestValue(manifest, "Start-Class")
+		Expect(err).To(MatchError(ContainSubstring(`manifest entry Start-Class contains the control character '\n'`)))
+	})
+
+	it("rejects values that are too long", func() {
+		writeManifest("Start-Class: com.example." + strings.Repeat("a", boot.MaxManifestValueLength) + "\n")
+		manifest, err := libjvm.NewManifest(appPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _, err = boot.ManifestValue(manifest, "Start-Class")
+		Expect(err).To(MatchError(ContainSubstring("the maximum is 1024")))
+	})
+
+	it("reports missing entries", func() {
+		writeManifest("Spring-Boot-Version: 3.3.1\n")
+		manifest, err := libjvm.NewManifest(appPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, ok, err := boot.ManifestValue(manifest, "Start-Class")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeFalse())
+	})
+}
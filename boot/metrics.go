@@ -0,0 +1,42 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import "time"
+
+// MetricsSink receives measurements taken while contributing a layer, so that platforms can forward them to their
+// observability tooling.
+type MetricsSink interface {
+
+	// RecordDuration records how long the named phase took.
+	RecordDuration(name string, duration time.Duration)
+
+	// RecordSize records the size in bytes of the named artifact.
+	RecordSize(name string, bytes int64)
+
+	// RecordEvent records that the named event happened.
+	RecordEvent(name string)
+}
+
+// NoopMetricsSink is a MetricsSink that discards all measurements.
+type NoopMetricsSink struct{}
+
+func (NoopMetricsSink) RecordDuration(string, time.Duration) {}
+
+func (NoopMetricsSink) RecordSize(string, int64) {}
+
+func (NoopMetricsSink) RecordEvent(string) {}
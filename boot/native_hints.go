@@ -0,0 +1,244 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+// NativeHintFiles are the runtime hints of an application, in META-INF/native-image, that Spring AOT generates for
+// the reflection, resources and proxies it cannot replace.
+var NativeHintFiles = []string{"reachability-metadata.json", "reflect-config.json"}
+
+// reflectiveMethods are the methods, by class, whose callers need runtime hints once the application is AOT processed.
+var reflectiveMethods = map[string][]string{
+	"java/lang/Class": {"forName", "getConstructor", "getDeclaredConstructor", "getDeclaredConstructors", "getDeclaredField",
+		"getDeclaredFields", "getDeclaredMethod", "getDeclaredMethods", "getField", "getMethod", "getResource", "getResourceAsStream"},
+	"java/lang/ClassLoader":   {"getResource", "getResourceAsStream", "getResources", "loadClass"},
+	"java/lang/reflect/Proxy": {"newProxyInstance"},
+}
+
+// ReflectiveCalls are the calls of a class to methods that AOT processed applications need runtime hints for.
+type ReflectiveCalls struct {
+
+	// Class is the name of the class.
+	Class string
+
+	// Methods are the methods called, such as Class.forName, sorted.
+	Methods []string
+}
+
+// HasNativeHints returns whether the classes in dir include one of NativeHintFiles in META-INF/native-image.
+func HasNativeHints(dir string) (bool, error) {
+	found := false
+	root := filepath.Join(dir, "META-INF", "native-image")
+	if exists, err := sherpa.DirExists(root); err != nil {
+		return false, fmt.Errorf("unable to check for %s\n%w", root, err)
+	} else if !exists {
+		return false, nil
+	}
+
+	if err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		for _, name := range NativeHintFiles {
+			if !d.IsDir() && d.Name() == name {
+				found = true
+				return filepath.SkipAll
+			}
+		}
+		return nil
+	}); err != nil {
+		return false, fmt.Errorf("unable to walk %s\n%w", root, err)
+	}
+	return found, nil
+}
+
+// FindReflectiveCalls returns the classes in dir calling methods that need runtime hints once the application is AOT
+// processed, sorted by name. This is a heuristic: the calls are read from the constant pool of the classes, whether they
+// are covered by a hint, or even reachable, is not known. The classes generated by Spring AOT are skipped.
+func FindReflectiveCalls(dir string) ([]ReflectiveCalls, error) {
+	var calls []ReflectiveCalls
+	if err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".class") || strings.Contains(d.Name(), "__") {
+			return nil
+		}
+
+		methods, err := classReflectiveMethods(path)
+		if err != nil {
+			return err
+		}
+		if len(methods) > 0 {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return fmt.Errorf("unable to relativize %s\n%w", path, err)
+			}
+			name := strings.ReplaceAll(strings.TrimSuffix(filepath.ToSlash(rel), ".class"), "/", ".")
+			calls = append(calls, ReflectiveCalls{Class: name, Methods: methods})
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("unable to walk %s\n%w", dir, err)
+	}
+
+	sort.Slice(calls, func(i, j int) bool { return calls[i].Class < calls[j].Class })
+	return calls, nil
+}
+
+// classReflectiveMethods returns the reflective methods referenced by the constant pool of the class file at path.
+func classReflectiveMethods(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s\n%w", path, err)
+	}
+	defer f.Close()
+
+	pool, err := readConstantPool(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the constant pool of %s\n%w", path, err)
+	}
+
+	var methods []string
+	for _, c := range pool {
+		// Methodref and InterfaceMethodref
+		if c.tag != 10 && c.tag != 11 {
+			continue
+		}
+		owner, name := pool.utf8(pool.ref(c.a).a), pool.utf8(pool.ref(c.b).a)
+		for _, m := range reflectiveMethods[owner] {
+			if m != name {
+				continue
+			}
+			method := fmt.Sprintf("%s.%s", owner[strings.LastIndex(owner, "/")+1:], name)
+			if !slices.Contains(methods, method) {
+				methods = append(methods, method)
+			}
+		}
+	}
+	sort.Strings(methods)
+	return methods, nil
+}
+
+// constant is an entry of the constant pool of a class file, with the indexes it references.
+type constant struct {
+	tag  byte
+	a, b uint16
+	utf8 string
+}
+
+// constantPool is the constant pool of a class file, indexed from one as in the class file.
+type constantPool []constant
+
+func (p constantPool) ref(index uint16) constant {
+	if int(index) >= len(p) {
+		return constant{}
+	}
+	return p[index]
+}
+
+func (p constantPool) utf8(index uint16) string {
+	if c := p.ref(index); c.tag == 1 {
+		return c.utf8
+	}
+	return ""
+}
+
+// readConstantPool reads the constant pool of the class file read by r.
+func readConstantPool(r io.Reader) (constantPool, error) {
+	var header struct {
+		Magic        uint32
+		Minor, Major uint16
+		Count        uint16
+	}
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return nil, err
+	}
+	if header.Magic != 0xCAFEBABE {
+		return nil, fmt.Errorf("not a class file")
+	}
+
+	pool := make(constantPool, header.Count)
+	for i := 1; i < int(header.Count); i++ {
+		var tag byte
+		if err := binary.Read(r, binary.BigEndian, &tag); err != nil {
+			return nil, err
+		}
+		c := constant{tag: tag}
+
+		switch tag {
+		case 1: // Utf8
+			var length uint16
+			if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+				return nil, err
+			}
+			b := make([]byte, length)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return nil, err
+			}
+			c.utf8 = string(b)
+		case 7, 8, 16, 19, 20: // Class, String, MethodType, Module, Package
+			if err := binary.Read(r, binary.BigEndian, &c.a); err != nil {
+				return nil, err
+			}
+		case 9, 10, 11, 12, 17, 18: // Fieldref, Methodref, InterfaceMethodref, NameAndType, Dynamic, InvokeDynamic
+			if err := binary.Read(r, binary.BigEndian, &c.a); err != nil {
+				return nil, err
+			}
+			if err := binary.Read(r, binary.BigEndian, &c.b); err != nil {
+				return nil, err
+			}
+		case 15: // MethodHandle
+			var kind byte
+			if err := binary.Read(r, binary.BigEndian, &kind); err != nil {
+				return nil, err
+			}
+			if err := binary.Read(r, binary.BigEndian, &c.a); err != nil {
+				return nil, err
+			}
+		case 3, 4: // Integer, Float
+			if _, err := io.CopyN(io.Discard, r, 4); err != nil {
+				return nil, err
+			}
+		case 5, 6: // Long, Double take two entries
+			if _, err := io.CopyN(io.Discard, r, 8); err != nil {
+				return nil, err
+			}
+			pool[i] = c
+			i++
+			continue
+		default:
+			return nil, fmt.Errorf("unknown constant pool tag %d at index %d", tag, i)
+		}
+		pool[i] = c
+	}
+	return pool, nil
+}
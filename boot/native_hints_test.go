@@ -0,0 +1,72 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testNativeHints(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		classes = filepath.Join("testdata", "native-hints", "BOOT-INF", "classes")
+	)
+
+	context("FindReflectiveCalls", func() {
+		it("finds the reflective calls of the application classes", func() {
+			Expect(boot.FindReflectiveCalls(classes)).To(Equal([]boot.ReflectiveCalls{
+				{Class: "com.example.Reflective", Methods: []string{"Class.forName", "ClassLoader.getResourceAsStream", "Proxy.newProxyInstance"}},
+			}))
+		})
+
+		it("fails when a class cannot be read", func() {
+			dir := t.TempDir()
+			Expect(os.WriteFile(filepath.Join(dir, "Broken.class"), []byte("not a class"), 0644)).To(Succeed())
+
+			_, err := boot.FindReflectiveCalls(dir)
+			Expect(err).To(MatchError(ContainSubstring("unable to read the constant pool")))
+		})
+	})
+
+	context("HasNativeHints", func() {
+		it("finds the reflect-config.json generated by Spring AOT", func() {
+			Expect(boot.HasNativeHints(filepath.Join("testdata", "aot", "resources"))).To(BeTrue())
+		})
+
+		it("finds the reachability metadata", func() {
+			dir := t.TempDir()
+			Expect(sherpa.CopyDir(classes, dir)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(dir, "META-INF", "native-image", "com.example"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "META-INF", "native-image", "com.example", "reachability-metadata.json"), []byte("{}"), 0644)).To(Succeed())
+
+			Expect(boot.HasNativeHints(dir)).To(BeTrue())
+		})
+
+		it("finds no hints in classes without them", func() {
+			Expect(boot.HasNativeHints(classes)).To(BeFalse())
+		})
+	})
+}
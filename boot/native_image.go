@@ -18,7 +18,6 @@ package boot
 import (
 	"fmt"
 	"github.com/paketo-buildpacks/libpak/sherpa"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -26,7 +25,6 @@ import (
 	"github.com/magiconair/properties"
 	"github.com/paketo-buildpacks/libpak"
 	"github.com/paketo-buildpacks/libpak/bard"
-	"gopkg.in/yaml.v3"
 )
 
 type NativeImageClasspath struct {
@@ -96,31 +94,17 @@ func (n NativeImageClasspath) classpathEntries() ([]string, error) {
 		return nil, fmt.Errorf("manifest does not contain Spring-Boot-Classpath-Index")
 	}
 
-	file := filepath.Join(n.ApplicationPath, classpathIdx)
-	in, err := os.Open(filepath.Join(n.ApplicationPath, classpathIdx))
-	if err != nil {
-		return nil, fmt.Errorf("unable to open %s\n%w", file, err)
-	}
-	defer in.Close()
-
-	var libs []string
-	if err := yaml.NewDecoder(in).Decode(&libs); err != nil {
-		return nil, fmt.Errorf("unable to decode %s\n%w", file, err)
-	}
-
 	libDir, ok := n.Manifest.Get("Spring-Boot-Lib")
 	if !ok {
 		return nil, fmt.Errorf("manifest does not contain Spring-Boot-Lib")
 	}
 
+	libs, err := parseClasspathIndex(n.ApplicationPath, classpathIdx, libDir)
+	if err != nil {
+		return nil, err
+	}
 	for _, l := range libs {
-		if dir, _ := filepath.Split(l); dir == "" {
-			// In Spring Boot version 2.3.0.M4 -> 2.4.2 classpath.idx contains a list of jars
-			cp = append(cp, filepath.Join(n.ApplicationPath, libDir, l))
-		} else {
-			// In Spring Boot version <= 2.3.0.M3 or >= 2.4.2 classpath.idx contains a list of relative paths to jars
-			cp = append(cp, filepath.Join(n.ApplicationPath, l))
-		}
+		cp = append(cp, filepath.Join(n.ApplicationPath, l))
 	}
 	return cp, nil
 }
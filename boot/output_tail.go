@@ -0,0 +1,65 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"bytes"
+	"sync"
+)
+
+// DefaultOutputTailLines is the number of lines of the training run output included in its error.
+const DefaultOutputTailLines = 20
+
+// OutputTail is an io.Writer keeping the last lines written to it, so that they can be reported when a command fails
+// after its output has been streamed to the build log.
+type OutputTail struct {
+	lines int
+	mu    sync.Mutex
+	buf   []byte
+}
+
+// NewOutputTail creates an OutputTail keeping the last lines written to it.
+func NewOutputTail(lines int) *OutputTail {
+	return &OutputTail{lines: lines}
+}
+
+func (t *OutputTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+
+	// only the complete lines count, the last one may still be written
+	end := len(bytes.TrimRight(t.buf, "\n"))
+	for i, n := end-1, 0; i >= 0; i-- {
+		if t.buf[i] == '\n' {
+			if n++; n == t.lines {
+				t.buf = append([]byte(nil), t.buf[i+1:]...)
+				break
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// String returns the last lines written, without the trailing line break.
+func (t *OutputTail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return string(bytes.TrimRight(t.buf, "\n"))
+}
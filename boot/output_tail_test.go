@@ -0,0 +1,65 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testOutputTail(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("keeps the last lines", func() {
+		tail := boot.NewOutputTail(2)
+		for i := 1; i <= 5; i++ {
+			_, err := fmt.Fprintf(tail, "line %d\n", i)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(tail.String()).To(Equal("line 4\nline 5"))
+	})
+
+	it("keeps lines written in several parts", func() {
+		tail := boot.NewOutputTail(2)
+		for _, part := range []string{"line 1\nli", "ne 2\nline", " 3", "\n"} {
+			_, err := tail.Write([]byte(part))
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(tail.String()).To(Equal("line 2\nline 3"))
+	})
+
+	it("keeps an unterminated last line", func() {
+		tail := boot.NewOutputTail(2)
+		_, err := tail.Write([]byte("line 1\nline 2\nline 3"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(tail.String()).To(Equal("line 2\nline 3"))
+	})
+
+	it("is empty without output", func() {
+		Expect(boot.NewOutputTail(2).String()).To(BeEmpty())
+	})
+}
@@ -0,0 +1,776 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+const (
+	// DefaultArchiveName is the name of the CDS archive created by the training run when BP_JVM_CDS_ENV_TAG is not set,
+	// the one the helper uses when BPL_JVM_CDS_ARCHIVE_FILE is not set.
+	DefaultArchiveName = "application.jsa"
+
+	// EnvTagMetadata is the layer metadata recording the BP_JVM_CDS_ENV_TAG the CDS archive was created for.
+	EnvTagMetadata = "cds_env_tag"
+)
+
+// PerformanceConfig holds the user configuration of the CDS and AOT optimizations. The zero value is not the default
+// configuration, NewPerformanceConfig should be used to resolve it.
+type PerformanceConfig struct {
+
+	// JavaHome is the JRE used for the extraction and the training run, from $JRE_HOME or else $JAVA_HOME.
+	JavaHome string
+
+	// JavaToolOptions is $JAVA_TOOL_OPTIONS.
+	JavaToolOptions string
+
+	// TrainingJavaToolOptions is $CDS_TRAINING_JAVA_TOOL_OPTIONS.
+	TrainingJavaToolOptions string
+
+	// BaseArchive is $BP_JVM_CDS_BASE_ARCHIVE.
+	BaseArchive string
+
+	// ArchiveDir is $BP_JVM_CDS_ARCHIVE_DIR.
+	ArchiveDir string
+
+	// ArchiveFormat is $BP_JVM_CDS_ARCHIVE_FORMAT, see Format for the default.
+	ArchiveFormat ArchiveFormat
+
+	// EnvTag is $BP_JVM_CDS_ENV_TAG, the environment discriminating the name of the archive.
+	EnvTag string
+
+	// ArchiveTmpDir is $BP_JVM_CDS_ARCHIVE_TMPDIR.
+	ArchiveTmpDir string
+
+	// ClassList is $BP_JVM_CDS_CLASSLIST.
+	ClassList string
+
+	// ClasspathPrepend is $BP_JVM_CDS_CLASSPATH_PREPEND split on the path list separator.
+	ClasspathPrepend []string
+
+	// ClasspathAppend is $BP_JVM_CDS_CLASSPATH_APPEND split on the path list separator.
+	ClasspathAppend []string
+
+	// TrainingStdin is $BP_JVM_CDS_TRAINING_STDIN.
+	TrainingStdin string
+
+	// TrainingEntrypoint is $BP_JVM_CDS_TRAINING_ENTRYPOINT.
+	TrainingEntrypoint string
+
+	// WarmupIterations is $BP_JVM_CDS_WARMUP_ITERATIONS, the number of times the training run refreshes the application
+	// context, defaults to 1.
+	WarmupIterations int
+
+	// TrainingSandbox is the space-separated command and arguments of $BP_JVM_CDS_TRAINING_SANDBOX.
+	TrainingSandbox []string
+
+	// TrainingNetwork is $BP_JVM_CDS_TRAINING_NETWORK, defaults to true.
+	TrainingNetwork bool
+
+	// PostExtractScript is $BP_JVM_CDS_POST_EXTRACT_SCRIPT.
+	PostExtractScript string
+
+	// JarMode is $BP_JVM_CDS_JARMODE, empty or tools.
+	JarMode string
+
+	// TrainingDir is $BP_JVM_CDS_TRAINING_DIR.
+	TrainingDir string
+
+	// LaunchDir is $BP_JVM_CDS_LAUNCH_DIR, defaults to DefaultLaunchDir. The archive portability is not checked if empty.
+	LaunchDir string
+
+	// Benchmark is $BP_JVM_CDS_BENCHMARK, defaults to false.
+	Benchmark bool
+
+	// CacheArchive is $BP_JVM_CDS_CACHE_ARCHIVE, defaults to false, or true if CacheLayout is set.
+	CacheArchive bool
+
+	// CacheLayout is $BP_JVM_CDS_CACHE_LAYOUT, defaults to false.
+	CacheLayout bool
+
+	// CheckOnly is $BP_SPRING_PERFORMANCE_CHECK_ONLY, defaults to false.
+	CheckOnly bool
+
+	// LogLevel is $BP_SPRING_PERFORMANCE_LOG_LEVEL, defaults to LogNormal.
+	LogLevel LogLevel
+
+	// TrainingCPUs is $BP_JVM_CDS_TRAINING_CPUS, 0 if unset.
+	TrainingCPUs int
+
+	// TrainingProfiles is $BP_JVM_CDS_TRAINING_PROFILES split on commas.
+	TrainingProfiles []string
+
+	// TrainingProfileSets is $BP_JVM_CDS_TRAINING_PROFILE_SETS split on semicolons, the profiles of a training run each.
+	TrainingProfileSets [][]string
+
+	// AllowedFlags is $BP_JVM_CDS_ALLOWED_FLAGS split on commas.
+	AllowedFlags []string
+
+	// MinAppClasses is $BP_JVM_CDS_MIN_APP_CLASSES, zero if the training run is performed whatever the number of
+	// classes of the application.
+	MinAppClasses int
+
+	// MaxExtractBytes is $BP_JVM_CDS_MAX_EXTRACT_BYTES, zero if the size of the extracted layout is not capped.
+	MaxExtractBytes int64
+
+	// DiskMultiplier is $BP_JVM_CDS_DISK_MULTIPLIER, DefaultDiskMultiplier by default, zero if the free disk space is
+	// not checked.
+	DiskMultiplier float64
+
+	// DumpGrace is $BP_JVM_CDS_DUMP_GRACE, DefaultDumpGrace by default.
+	DumpGrace time.Duration
+
+	// ShutdownTimeout is $BP_JVM_CDS_SHUTDOWN_TIMEOUT, zero not to watch the shutdown of the training run, or
+	// DefaultShutdownTimeout if ForceDump is set.
+	ShutdownTimeout time.Duration
+
+	// ForceDump is $BP_JVM_CDS_FORCE_DUMP, defaults to false.
+	ForceDump bool
+
+	// LaunchEnvAliases is $BP_SPRING_PERFORMANCE_LAUNCH_ENV_ALIASES, the names the launch environment is also contributed
+	// as.
+	LaunchEnvAliases LaunchEnvAliases
+
+	// TrainingDataSource is $BP_JVM_CDS_TRAINING_DATASOURCE, empty for the datasource of the application or
+	// EmbeddedDataSource.
+	TrainingDataSource string
+
+	// TrainingEnv is $BP_JVM_CDS_TRAINING_ENV split on commas.
+	TrainingEnv []string
+
+	// TrainingAssertions is $BP_JVM_CDS_TRAINING_ASSERTIONS, defaults to false.
+	TrainingAssertions bool
+
+	// TrainingDebug is $BP_JVM_CDS_TRAINING_DEBUG, defaults to false.
+	TrainingDebug bool
+
+	// TrainingHeapDump is $BP_JVM_CDS_TRAINING_HEAPDUMP, defaults to false.
+	TrainingHeapDump bool
+
+	// TrainingIncludeLoader is $BP_JVM_CDS_TRAINING_INCLUDE_LOADER, defaults to false.
+	TrainingIncludeLoader bool
+
+	// TrainingJFR is $BP_JVM_CDS_TRAINING_JFR, defaults to false.
+	TrainingJFR bool
+
+	// VerifyExtraction is $BP_JVM_CDS_VERIFY_EXTRACTION, defaults to false.
+	VerifyExtraction bool
+
+	// WriteFileList is $BP_JVM_CDS_WRITE_FILELIST, defaults to false.
+	WriteFileList bool
+
+	// WriteConfig is $BP_JVM_CDS_WRITE_CONFIG, defaults to false.
+	WriteConfig bool
+
+	// KeepOriginalJar is $BP_JVM_CDS_KEEP_ORIGINAL_JAR, defaults to false.
+	KeepOriginalJar bool
+
+	// ReZip is $BP_SPRING_REZIP, defaults to ReZipAuto detecting the re-zip from the layout of the application.
+	ReZip ReZipMode
+
+	// ReZipVerifyIdentical is $BP_SPRING_REZIP_VERIFY_IDENTICAL, defaults to false.
+	ReZipVerifyIdentical bool
+
+	// ReZipCompressionLevel is $BP_SPRING_REZIP_COMPRESSION_LEVEL, zero if the entries of the re-zipped jar are stored.
+	ReZipCompressionLevel int
+
+	// ValidateArchive is $BP_JVM_CDS_VALIDATE_ARCHIVE, defaults to false.
+	ValidateArchive bool
+
+	// ReportRegions is $BP_JVM_CDS_REPORT_REGIONS, defaults to false.
+	ReportRegions bool
+
+	// SelfTest is $BP_JVM_CDS_SELF_TEST, defaults to false.
+	SelfTest bool
+
+	// WarnMissingArchive is $BP_JVM_CDS_WARN_MISSING_ARCHIVE, defaults to false.
+	WarnMissingArchive bool
+
+	// Required is $BP_JVM_CDS_REQUIRED, defaults to true.
+	Required bool
+
+	// SourceDateEpoch is $SOURCE_DATE_EPOCH, the zero time if unset.
+	SourceDateEpoch time.Time
+}
+
+// NewPerformanceConfig resolves the configuration from the environment, applying defaults for unset values.
+func NewPerformanceConfig() (PerformanceConfig, error) {
+	required, err := strconv.ParseBool(sherpa.GetEnvWithDefault("BP_JVM_CDS_REQUIRED", "true"))
+	if err != nil {
+		return PerformanceConfig{}, fmt.Errorf("invalid value for BP_JVM_CDS_REQUIRED\n%w", err)
+	}
+
+	trainingNetwork, err := strconv.ParseBool(sherpa.GetEnvWithDefault("BP_JVM_CDS_TRAINING_NETWORK", "true"))
+	if err != nil {
+		return PerformanceConfig{}, fmt.Errorf("invalid value for BP_JVM_CDS_TRAINING_NETWORK\n%w", err)
+	}
+
+	envTag := sherpa.GetEnvWithDefault("BP_JVM_CDS_ENV_TAG", "")
+	if envTag != "" && !profilePattern.MatchString(envTag) {
+		return PerformanceConfig{}, fmt.Errorf("invalid value for BP_JVM_CDS_ENV_TAG %q, it may only contain letters, digits, '.', '_' and '-'", envTag)
+	}
+
+	var trainingCPUs int
+	if cpus := sherpa.GetEnvWithDefault("BP_JVM_CDS_TRAINING_CPUS", ""); cpus != "" {
+		if trainingCPUs, err = strconv.Atoi(cpus); err != nil || trainingCPUs < 1 {
+			return PerformanceConfig{}, fmt.Errorf("invalid value for BP_JVM_CDS_TRAINING_CPUS %q, expected a positive number of CPUs", cpus)
+		}
+	}
+
+	var minAppClasses int
+	if value := sherpa.GetEnvWithDefault("BP_JVM_CDS_MIN_APP_CLASSES", ""); value != "" {
+		if minAppClasses, err = strconv.Atoi(value); err != nil || minAppClasses < 0 {
+			return PerformanceConfig{}, fmt.Errorf("invalid value for BP_JVM_CDS_MIN_APP_CLASSES %q, expected a number of classes", value)
+		}
+	}
+
+	warmupIterations := 1
+	if value := sherpa.GetEnvWithDefault("BP_JVM_CDS_WARMUP_ITERATIONS", ""); value != "" {
+		if warmupIterations, err = strconv.Atoi(value); err != nil || warmupIterations < 1 {
+			return PerformanceConfig{}, fmt.Errorf("invalid value for BP_JVM_CDS_WARMUP_ITERATIONS %q, expected a positive number of iterations", value)
+		}
+	}
+
+	trainingProfiles, err := ParseProfiles(sherpa.GetEnvWithDefault("BP_JVM_CDS_TRAINING_PROFILES", ""))
+	if err != nil {
+		return PerformanceConfig{}, fmt.Errorf("invalid value for BP_JVM_CDS_TRAINING_PROFILES\n%w", err)
+	}
+
+	trainingProfileSets, err := ParseProfileSets(sherpa.GetEnvWithDefault("BP_JVM_CDS_TRAINING_PROFILE_SETS", ""))
+	if err != nil {
+		return PerformanceConfig{}, fmt.Errorf("invalid value for BP_JVM_CDS_TRAINING_PROFILE_SETS\n%w", err)
+	}
+	if len(trainingProfileSets) > 0 && len(trainingProfiles) > 0 {
+		return PerformanceConfig{}, fmt.Errorf("BP_JVM_CDS_TRAINING_PROFILE_SETS and BP_JVM_CDS_TRAINING_PROFILES cannot both be set")
+	}
+	if len(trainingProfileSets) > 0 && sherpa.GetEnvWithDefault("BP_JVM_CDS_TRAINING_ENTRYPOINT", "") != "" {
+		return PerformanceConfig{}, fmt.Errorf("BP_JVM_CDS_TRAINING_PROFILE_SETS is not supported with BP_JVM_CDS_TRAINING_ENTRYPOINT")
+	}
+
+	// the layertools jarmode extracts a layered layout without the runner jar the training run is performed with
+	if mode := sherpa.GetEnvWithDefault("BP_JVM_CDS_JARMODE", ""); mode != "" && mode != JarModeTools {
+		return PerformanceConfig{}, fmt.Errorf("invalid value for BP_JVM_CDS_JARMODE %q, only the %s jarmode extracts the layout of the training run", mode, JarModeTools)
+	}
+
+	trainingEnv, err := ParseEnvNames(sherpa.GetEnvWithDefault("BP_JVM_CDS_TRAINING_ENV", ""))
+	if err != nil {
+		return PerformanceConfig{}, fmt.Errorf("invalid value for BP_JVM_CDS_TRAINING_ENV\n%w", err)
+	}
+
+	trainingDataSource, err := ParseTrainingDataSource(sherpa.GetEnvWithDefault("BP_JVM_CDS_TRAINING_DATASOURCE", ""))
+	if err != nil {
+		return PerformanceConfig{}, fmt.Errorf("invalid value for BP_JVM_CDS_TRAINING_DATASOURCE\n%w", err)
+	}
+
+	launchEnvAliases, err := ParseLaunchEnvAliases(sherpa.GetEnvWithDefault("BP_SPRING_PERFORMANCE_LAUNCH_ENV_ALIASES", ""))
+	if err != nil {
+		return PerformanceConfig{}, fmt.Errorf("invalid value for BP_SPRING_PERFORMANCE_LAUNCH_ENV_ALIASES\n%w", err)
+	}
+
+	allowedFlags, err := ParseAllowedFlags(sherpa.GetEnvWithDefault("BP_JVM_CDS_ALLOWED_FLAGS", ""))
+	if err != nil {
+		return PerformanceConfig{}, fmt.Errorf("invalid value for BP_JVM_CDS_ALLOWED_FLAGS\n%w", err)
+	}
+
+	var maxExtractBytes int64
+	if limit := sherpa.GetEnvWithDefault("BP_JVM_CDS_MAX_EXTRACT_BYTES", ""); limit != "" {
+		if maxExtractBytes, err = strconv.ParseInt(limit, 10, 64); err != nil || maxExtractBytes < 1 {
+			return PerformanceConfig{}, fmt.Errorf("invalid value for BP_JVM_CDS_MAX_EXTRACT_BYTES %q, expected a positive number of bytes", limit)
+		}
+	}
+
+	var compressionLevel int
+	if level := sherpa.GetEnvWithDefault("BP_SPRING_REZIP_COMPRESSION_LEVEL", ""); level != "" {
+		if compressionLevel, err = strconv.Atoi(level); err != nil || compressionLevel < 1 || compressionLevel > 9 {
+			return PerformanceConfig{}, fmt.Errorf("invalid value for BP_SPRING_REZIP_COMPRESSION_LEVEL %q, expected a deflate level from 1 to 9", level)
+		}
+	}
+
+	format, err := ParseArchiveFormat(sherpa.GetEnvWithDefault("BP_JVM_CDS_ARCHIVE_FORMAT", DefaultArchiveFormat))
+	if err != nil {
+		return PerformanceConfig{}, fmt.Errorf("invalid value for BP_JVM_CDS_ARCHIVE_FORMAT\n%w", err)
+	}
+	// the archive of a format with a dump step is dumped by the JVM itself, not by the training entrypoint
+	if format.DumpArgs("", "", "") != nil && sherpa.GetEnvWithDefault("BP_JVM_CDS_TRAINING_ENTRYPOINT", "") != "" {
+		return PerformanceConfig{}, fmt.Errorf("BP_JVM_CDS_ARCHIVE_FORMAT=%s is not supported with BP_JVM_CDS_TRAINING_ENTRYPOINT", format.Name())
+	}
+
+	reZip := ReZipAuto
+	if mode := sherpa.GetEnvWithDefault("BP_SPRING_REZIP", ""); mode != "" {
+		if reZip, err = ParseReZipMode(mode); err != nil {
+			return PerformanceConfig{}, fmt.Errorf("invalid value for BP_SPRING_REZIP\n%w", err)
+		}
+	}
+
+	logLevel := LogNormal
+	if level := sherpa.GetEnvWithDefault("BP_SPRING_PERFORMANCE_LOG_LEVEL", ""); level != "" {
+		if logLevel, err = ParseLogLevel(level); err != nil {
+			return PerformanceConfig{}, fmt.Errorf("invalid value for BP_SPRING_PERFORMANCE_LOG_LEVEL\n%w", err)
+		}
+	}
+
+	diskMultiplier := DefaultDiskMultiplier
+	if multiplier := sherpa.GetEnvWithDefault("BP_JVM_CDS_DISK_MULTIPLIER", ""); multiplier != "" {
+		if diskMultiplier, err = strconv.ParseFloat(multiplier, 64); err != nil || diskMultiplier < 0 {
+			return PerformanceConfig{}, fmt.Errorf("invalid value for BP_JVM_CDS_DISK_MULTIPLIER %q, expected a multiple of the application size, or 0 to not check the free disk space", multiplier)
+		}
+	}
+
+	dumpGrace := DefaultDumpGrace
+	if grace := sherpa.GetEnvWithDefault("BP_JVM_CDS_DUMP_GRACE", ""); grace != "" {
+		if dumpGrace, err = time.ParseDuration(grace); err != nil || dumpGrace < 0 {
+			return PerformanceConfig{}, fmt.Errorf("invalid value for BP_JVM_CDS_DUMP_GRACE %q, expected a duration such as 10s", grace)
+		}
+	}
+
+	// the cached layout is only restored with the archive trained on it, the training run is otherwise performed again
+	cacheLayout := sherpa.ResolveBool("BP_JVM_CDS_CACHE_LAYOUT")
+
+	forceDump := sherpa.ResolveBool("BP_JVM_CDS_FORCE_DUMP")
+	var shutdownTimeout time.Duration
+	if forceDump {
+		shutdownTimeout = DefaultShutdownTimeout
+	}
+	if timeout := sherpa.GetEnvWithDefault("BP_JVM_CDS_SHUTDOWN_TIMEOUT", ""); timeout != "" {
+		if shutdownTimeout, err = time.ParseDuration(timeout); err != nil || shutdownTimeout < time.Millisecond {
+			return PerformanceConfig{}, fmt.Errorf("invalid value for BP_JVM_CDS_SHUTDOWN_TIMEOUT %q, expected a duration such as 30s", timeout)
+		}
+	}
+
+	var sourceDateEpoch time.Time
+	if epoch, ok := os.LookupEnv("SOURCE_DATE_EPOCH"); ok && epoch != "" {
+		if sourceDateEpoch, err = ParseSourceDateEpoch(epoch); err != nil {
+			return PerformanceConfig{}, err
+		}
+	}
+
+	return PerformanceConfig{
+		JavaHome:                sherpa.GetEnvWithDefault("JRE_HOME", sherpa.GetEnvWithDefault("JAVA_HOME", "")),
+		JavaToolOptions:         sherpa.GetEnvWithDefault("JAVA_TOOL_OPTIONS", ""),
+		TrainingJavaToolOptions: sherpa.GetEnvWithDefault("CDS_TRAINING_JAVA_TOOL_OPTIONS", ""),
+		BaseArchive:             sherpa.GetEnvWithDefault("BP_JVM_CDS_BASE_ARCHIVE", ""),
+		ArchiveDir:              sherpa.GetEnvWithDefault("BP_JVM_CDS_ARCHIVE_DIR", ""),
+		ArchiveFormat:           format,
+		EnvTag:                  envTag,
+		ArchiveTmpDir:           sherpa.GetEnvWithDefault("BP_JVM_CDS_ARCHIVE_TMPDIR", ""),
+		ClassList:               sherpa.GetEnvWithDefault("BP_JVM_CDS_CLASSLIST", ""),
+		ClasspathPrepend:        ParseClasspathEntries(sherpa.GetEnvWithDefault("BP_JVM_CDS_CLASSPATH_PREPEND", "")),
+		ClasspathAppend:         ParseClasspathEntries(sherpa.GetEnvWithDefault("BP_JVM_CDS_CLASSPATH_APPEND", "")),
+		TrainingStdin:           sherpa.GetEnvWithDefault("BP_JVM_CDS_TRAINING_STDIN", ""),
+		TrainingEntrypoint:      sherpa.GetEnvWithDefault("BP_JVM_CDS_TRAINING_ENTRYPOINT", ""),
+		WarmupIterations:        warmupIterations,
+		TrainingSandbox:         strings.Fields(sherpa.GetEnvWithDefault("BP_JVM_CDS_TRAINING_SANDBOX", "")),
+		TrainingNetwork:         trainingNetwork,
+		PostExtractScript:       sherpa.GetEnvWithDefault("BP_JVM_CDS_POST_EXTRACT_SCRIPT", ""),
+		JarMode:                 sherpa.GetEnvWithDefault("BP_JVM_CDS_JARMODE", ""),
+		TrainingDir:             sherpa.GetEnvWithDefault("BP_JVM_CDS_TRAINING_DIR", ""),
+		LaunchDir:               sherpa.GetEnvWithDefault("BP_JVM_CDS_LAUNCH_DIR", DefaultLaunchDir),
+		Benchmark:               sherpa.ResolveBool("BP_JVM_CDS_BENCHMARK"),
+		ValidateArchive:         sherpa.ResolveBool("BP_JVM_CDS_VALIDATE_ARCHIVE"),
+		ReportRegions:           sherpa.ResolveBool("BP_JVM_CDS_REPORT_REGIONS"),
+		SelfTest:                sherpa.ResolveBool("BP_JVM_CDS_SELF_TEST"),
+		CacheArchive:            sherpa.ResolveBool("BP_JVM_CDS_CACHE_ARCHIVE") || cacheLayout,
+		CacheLayout:             cacheLayout,
+		CheckOnly:               sherpa.ResolveBool("BP_SPRING_PERFORMANCE_CHECK_ONLY"),
+		LogLevel:                logLevel,
+		TrainingCPUs:            trainingCPUs,
+		TrainingProfiles:        trainingProfiles,
+		TrainingProfileSets:     trainingProfileSets,
+		TrainingDataSource:      trainingDataSource,
+		TrainingEnv:             trainingEnv,
+		LaunchEnvAliases:        launchEnvAliases,
+		MaxExtractBytes:         maxExtractBytes,
+		MinAppClasses:           minAppClasses,
+		DiskMultiplier:          diskMultiplier,
+		DumpGrace:               dumpGrace,
+		ShutdownTimeout:         shutdownTimeout,
+		ForceDump:               forceDump,
+		AllowedFlags:            allowedFlags,
+		TrainingAssertions:      sherpa.ResolveBool("BP_JVM_CDS_TRAINING_ASSERTIONS"),
+		TrainingDebug:           sherpa.ResolveBool("BP_JVM_CDS_TRAINING_DEBUG"),
+		TrainingHeapDump:        sherpa.ResolveBool("BP_JVM_CDS_TRAINING_HEAPDUMP"),
+		TrainingIncludeLoader:   sherpa.ResolveBool("BP_JVM_CDS_TRAINING_INCLUDE_LOADER"),
+		TrainingJFR:             sherpa.ResolveBool("BP_JVM_CDS_TRAINING_JFR"),
+		VerifyExtraction:        sherpa.ResolveBool("BP_JVM_CDS_VERIFY_EXTRACTION"),
+		WriteFileList:           sherpa.ResolveBool("BP_JVM_CDS_WRITE_FILELIST"),
+		WriteConfig:             sherpa.ResolveBool("BP_JVM_CDS_WRITE_CONFIG"),
+		KeepOriginalJar:         sherpa.ResolveBool("BP_JVM_CDS_KEEP_ORIGINAL_JAR"),
+		ReZip:                   reZip,
+		ReZipVerifyIdentical:    sherpa.ResolveBool("BP_SPRING_REZIP_VERIFY_IDENTICAL"),
+		ReZipCompressionLevel:   compressionLevel,
+		WarnMissingArchive:      sherpa.ResolveBool("BP_JVM_CDS_WARN_MISSING_ARCHIVE"),
+		Required:                required,
+		SourceDateEpoch:         sourceDateEpoch,
+	}, nil
+}
+
+// Variables returns the effective value of each variable of KnownPerformanceVariables resolved by the configuration,
+// defaults included, keyed by name. The variables resolved by the build, such as BP_JVM_CDS_ENABLED, are not included.
+func (p PerformanceConfig) Variables() map[string]string {
+	var sets []string
+	for _, set := range p.TrainingProfileSets {
+		sets = append(sets, strings.Join(set, ","))
+	}
+
+	return map[string]string{
+		"BP_JVM_CDS_ALLOWED_FLAGS":                 strings.Join(p.AllowedFlags, ","),
+		"BP_JVM_CDS_ARCHIVE_DIR":                   p.ArchiveDir,
+		"BP_JVM_CDS_ARCHIVE_FORMAT":                p.Format().Name(),
+		"BP_JVM_CDS_ARCHIVE_TMPDIR":                p.ArchiveTmpDir,
+		"BP_JVM_CDS_BASE_ARCHIVE":                  p.BaseArchive,
+		"BP_JVM_CDS_BENCHMARK":                     strconv.FormatBool(p.Benchmark),
+		"BP_JVM_CDS_CACHE_ARCHIVE":                 strconv.FormatBool(p.CacheArchive),
+		"BP_JVM_CDS_CACHE_LAYOUT":                  strconv.FormatBool(p.CacheLayout),
+		"BP_JVM_CDS_CLASSLIST":                     p.ClassList,
+		"BP_JVM_CDS_CLASSPATH_APPEND":              strings.Join(p.ClasspathAppend, string(filepath.ListSeparator)),
+		"BP_JVM_CDS_CLASSPATH_PREPEND":             strings.Join(p.ClasspathPrepend, string(filepath.ListSeparator)),
+		"BP_JVM_CDS_DISK_MULTIPLIER":               strconv.FormatFloat(p.DiskMultiplier, 'g', -1, 64),
+		"BP_JVM_CDS_DUMP_GRACE":                    p.DumpGrace.String(),
+		"BP_JVM_CDS_FORCE_DUMP":                    strconv.FormatBool(p.ForceDump),
+		"BP_JVM_CDS_ENV_TAG":                       p.EnvTag,
+		"BP_JVM_CDS_JARMODE":                       p.JarMode,
+		"BP_JVM_CDS_KEEP_ORIGINAL_JAR":             strconv.FormatBool(p.KeepOriginalJar),
+		"BP_JVM_CDS_LAUNCH_DIR":                    p.LaunchDir,
+		"BP_JVM_CDS_MAX_EXTRACT_BYTES":             strconv.FormatInt(p.MaxExtractBytes, 10),
+		"BP_JVM_CDS_MIN_APP_CLASSES":               strconv.Itoa(p.MinAppClasses),
+		"BP_JVM_CDS_POST_EXTRACT_SCRIPT":           p.PostExtractScript,
+		"BP_JVM_CDS_REPORT_REGIONS":                strconv.FormatBool(p.ReportRegions),
+		"BP_JVM_CDS_REQUIRED":                      strconv.FormatBool(p.Required),
+		"BP_JVM_CDS_SELF_TEST":                     strconv.FormatBool(p.SelfTest),
+		"BP_JVM_CDS_SHUTDOWN_TIMEOUT":              p.ShutdownTimeout.String(),
+		"BP_JVM_CDS_TRAINING_ASSERTIONS":           strconv.FormatBool(p.TrainingAssertions),
+		"BP_JVM_CDS_TRAINING_CPUS":                 strconv.Itoa(p.TrainingCPUs),
+		"BP_JVM_CDS_TRAINING_DEBUG":                strconv.FormatBool(p.TrainingDebug),
+		"BP_JVM_CDS_TRAINING_DATASOURCE":           p.TrainingDataSource,
+		"BP_JVM_CDS_TRAINING_DIR":                  p.TrainingDir,
+		"BP_JVM_CDS_TRAINING_ENTRYPOINT":           p.TrainingEntrypoint,
+		"BP_JVM_CDS_TRAINING_ENV":                  strings.Join(p.TrainingEnv, ","),
+		"BP_JVM_CDS_TRAINING_HEAPDUMP":             strconv.FormatBool(p.TrainingHeapDump),
+		"BP_JVM_CDS_TRAINING_INCLUDE_LOADER":       strconv.FormatBool(p.TrainingIncludeLoader),
+		"BP_JVM_CDS_TRAINING_JFR":                  strconv.FormatBool(p.TrainingJFR),
+		"BP_JVM_CDS_TRAINING_NETWORK":              strconv.FormatBool(p.TrainingNetwork),
+		"BP_JVM_CDS_TRAINING_PROFILES":             strings.Join(p.TrainingProfiles, ","),
+		"BP_JVM_CDS_TRAINING_PROFILE_SETS":         strings.Join(sets, ";"),
+		"BP_JVM_CDS_TRAINING_SANDBOX":              strings.Join(p.TrainingSandbox, " "),
+		"BP_JVM_CDS_TRAINING_STDIN":                p.TrainingStdin,
+		"BP_JVM_CDS_VALIDATE_ARCHIVE":              strconv.FormatBool(p.ValidateArchive),
+		"BP_JVM_CDS_VERIFY_EXTRACTION":             strconv.FormatBool(p.VerifyExtraction),
+		"BP_JVM_CDS_WARMUP_ITERATIONS":             strconv.Itoa(p.WarmupIterations),
+		"BP_JVM_CDS_WARN_MISSING_ARCHIVE":          strconv.FormatBool(p.WarnMissingArchive),
+		"BP_JVM_CDS_WRITE_CONFIG":                  strconv.FormatBool(p.WriteConfig),
+		"BP_JVM_CDS_WRITE_FILELIST":                strconv.FormatBool(p.WriteFileList),
+		"BP_SPRING_PERFORMANCE_CHECK_ONLY":         strconv.FormatBool(p.CheckOnly),
+		"BP_SPRING_PERFORMANCE_LAUNCH_ENV_ALIASES": p.LaunchEnvAliases.String(),
+		"BP_SPRING_PERFORMANCE_LOG_LEVEL":          p.LogLevel.String(),
+		"BP_SPRING_REZIP":                          p.ReZip.String(),
+		"BP_SPRING_REZIP_COMPRESSION_LEVEL":        strconv.Itoa(p.ReZipCompressionLevel),
+		"BP_SPRING_REZIP_VERIFY_IDENTICAL":         strconv.FormatBool(p.ReZipVerifyIdentical),
+	}
+}
+
+// profilePattern matches the characters allowed in a profile name.
+var profilePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// ParseProfiles returns the comma-separated Spring profiles in value, ignoring empty entries. It fails if a profile name
+// contains other characters than letters, digits, '.', '_' and '-'.
+func ParseProfiles(value string) ([]string, error) {
+	var profiles []string
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		if !profilePattern.MatchString(p) {
+			return nil, fmt.Errorf("profile %q may only contain letters, digits, '.', '_' and '-'", p)
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, nil
+}
+
+// ParseProfileSets returns the semicolon-separated sets of comma-separated Spring profiles in value, ignoring empty
+// sets. It fails if a profile name is not valid, see ParseProfiles.
+func ParseProfileSets(value string) ([][]string, error) {
+	var sets [][]string
+	for _, set := range strings.Split(value, ";") {
+		profiles, err := ParseProfiles(set)
+		if err != nil {
+			return nil, err
+		}
+		if len(profiles) > 0 {
+			sets = append(sets, profiles)
+		}
+	}
+	return sets, nil
+}
+
+// ParseClasspathEntries returns the entries of the classpath value, separated by the path list separator, ignoring empty
+// entries.
+func ParseClasspathEntries(value string) []string {
+	var entries []string
+	for _, e := range filepath.SplitList(value) {
+		if e = strings.TrimSpace(e); e != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// envNamePattern matches a valid environment variable name.
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseEnvNames returns the comma-separated environment variable names in value, ignoring empty entries. It fails if a
+// name is not a valid environment variable name, such as a NAME=value assignment.
+func ParseEnvNames(value string) ([]string, error) {
+	var names []string
+	for _, n := range strings.Split(value, ",") {
+		if n = strings.TrimSpace(n); n == "" {
+			continue
+		}
+		if !envNamePattern.MatchString(n) {
+			return nil, fmt.Errorf("%q is not a valid environment variable name", n)
+		}
+		names = append(names, n)
+	}
+	return names, nil
+}
+
+// TrainingRunJavaToolOptions returns the JAVA_TOOL_OPTIONS of the training run: $CDS_TRAINING_JAVA_TOOL_OPTIONS if set,
+// $JAVA_TOOL_OPTIONS otherwise.
+func (p PerformanceConfig) TrainingRunJavaToolOptions() string {
+	if p.TrainingJavaToolOptions != "" {
+		return p.TrainingJavaToolOptions
+	}
+	return p.JavaToolOptions
+}
+
+// TrainingSandboxCommand returns the command wrapping the training run: TrainingSandbox if set, which is then in charge
+// of isolating the network, DefaultNetworkSandbox if the training run must not have network access, nil otherwise.
+func (p PerformanceConfig) TrainingSandboxCommand() []string {
+	if len(p.TrainingSandbox) > 0 {
+		return p.TrainingSandbox
+	}
+	if !p.TrainingNetwork {
+		return DefaultNetworkSandbox
+	}
+	return nil
+}
+
+// ArchiveName returns the file name of the CDS archive created by the training run, DefaultArchiveName or, if EnvTag is
+// set, application-<EnvTag>.jsa so that the archives of different environments do not share a name.
+func (p PerformanceConfig) ArchiveName() string {
+	if p.EnvTag != "" {
+		return fmt.Sprintf("application-%s.jsa", p.EnvTag)
+	}
+	return DefaultArchiveName
+}
+
+// Format returns the ArchiveFormat of the CDS archive, ArchiveFormat if set or the format named by DefaultArchiveFormat.
+func (p PerformanceConfig) Format() ArchiveFormat {
+	if p.ArchiveFormat != nil {
+		return p.ArchiveFormat
+	}
+	return DynamicArchive{}
+}
+
+// ArchiveFile returns the path of the CDS archive created by the training run, ArchiveName in ArchiveDir if set or in
+// the application otherwise. A relative path is relative to the application.
+func (p PerformanceConfig) ArchiveFile() string {
+	return filepath.Join(p.ArchiveDir, p.ArchiveName())
+}
+
+// Timestamp returns the time the application files are reset to: SourceDateEpoch if set, DefaultTimestamp otherwise.
+func (p PerformanceConfig) Timestamp() time.Time {
+	if !p.SourceDateEpoch.IsZero() {
+		return p.SourceDateEpoch
+	}
+	return DefaultTimestamp
+}
+
+// JavaCommand returns the java executable of JavaHome, or java from the PATH.
+func (p PerformanceConfig) JavaCommand() string {
+	if p.JavaHome != "" {
+		return filepath.Join(p.JavaHome, "bin", "java")
+	}
+	return "java"
+}
+
+// JavacCommand returns the javac executable of JavaHome, or javac from the PATH.
+func (p PerformanceConfig) JavacCommand() string {
+	if p.JavaHome != "" {
+		return filepath.Join(p.JavaHome, "bin", "javac")
+	}
+	return "javac"
+}
+
+// JavaEnv returns env with the bin directory of JavaHome prepended to its PATH, so that java and the tools it runs
+// resolve to JavaHome even if the PATH does not contain it. An empty env stands for the environment of the buildpack.
+// env is returned unchanged if JavaHome is not set.
+func (p PerformanceConfig) JavaEnv(env []string) []string {
+	if p.JavaHome == "" {
+		return env
+	}
+	if len(env) == 0 {
+		env = os.Environ()
+	}
+
+	bin := filepath.Join(p.JavaHome, "bin")
+	path := fmt.Sprintf("PATH=%s%c%s", bin, filepath.ListSeparator, os.Getenv("PATH"))
+	result := make([]string, 0, len(env)+1)
+	for _, e := range env {
+		if existing, ok := strings.CutPrefix(e, "PATH="); ok {
+			path = fmt.Sprintf("PATH=%s%c%s", bin, filepath.ListSeparator, existing)
+			continue
+		}
+		result = append(result, e)
+	}
+	return append(result, path)
+}
+
+// PerformanceVariablePrefixes are the prefixes of the environment variables configuring the buildpack, variables with
+// one of these prefixes that are not in KnownPerformanceVariables are likely misspelled.
+var PerformanceVariablePrefixes = []string{"BP_JVM_CDS_", "BP_SPRING_"}
+
+// KnownPerformanceVariables are the build time environment variables recognized by the buildpack.
+var KnownPerformanceVariables = []string{
+	"BP_JVM_CDS_ALLOWED_FLAGS",
+	"BP_JVM_CDS_ARCHIVE_DIR",
+	"BP_JVM_CDS_ARCHIVE_FORMAT",
+	"BP_JVM_CDS_ARCHIVE_TMPDIR",
+	"BP_JVM_CDS_BASE_ARCHIVE",
+	"BP_JVM_CDS_BENCHMARK",
+	"BP_JVM_CDS_CACHE_ARCHIVE",
+	"BP_JVM_CDS_CACHE_LAYOUT",
+	"BP_JVM_CDS_CLASSLIST",
+	"BP_JVM_CDS_CLASSPATH_APPEND",
+	"BP_JVM_CDS_CLASSPATH_PREPEND",
+	"BP_JVM_CDS_DISK_MULTIPLIER",
+	"BP_JVM_CDS_DUMP_GRACE",
+	"BP_JVM_CDS_ENABLED",
+	"BP_JVM_CDS_ENV_TAG",
+	"BP_JVM_CDS_FORCE_DUMP",
+	"BP_JVM_CDS_JARMODE",
+	"BP_JVM_CDS_KEEP_ORIGINAL_JAR",
+	"BP_JVM_CDS_LAUNCH_DIR",
+	"BP_JVM_CDS_MAX_EXTRACT_BYTES",
+	"BP_JVM_CDS_MIN_APP_CLASSES",
+	"BP_JVM_CDS_POST_EXTRACT_SCRIPT",
+	"BP_JVM_CDS_REPORT_REGIONS",
+	"BP_JVM_CDS_REQUIRED",
+	"BP_JVM_CDS_SELF_TEST",
+	"BP_JVM_CDS_SHUTDOWN_TIMEOUT",
+	"BP_JVM_CDS_TRAINING_ASSERTIONS",
+	"BP_JVM_CDS_TRAINING_CPUS",
+	"BP_JVM_CDS_TRAINING_DEBUG",
+	"BP_JVM_CDS_TRAINING_DATASOURCE",
+	"BP_JVM_CDS_TRAINING_DIR",
+	"BP_JVM_CDS_TRAINING_ENTRYPOINT",
+	"BP_JVM_CDS_TRAINING_ENV",
+	"BP_JVM_CDS_TRAINING_HEAPDUMP",
+	"BP_JVM_CDS_TRAINING_INCLUDE_LOADER",
+	"BP_JVM_CDS_TRAINING_JFR",
+	"BP_JVM_CDS_TRAINING_NETWORK",
+	"BP_JVM_CDS_TRAINING_PROFILES",
+	"BP_JVM_CDS_TRAINING_PROFILE_SETS",
+	"BP_JVM_CDS_TRAINING_SANDBOX",
+	"BP_JVM_CDS_TRAINING_STDIN",
+	"BP_JVM_CDS_VALIDATE_ARCHIVE",
+	"BP_JVM_CDS_VERIFY_EXTRACTION",
+	"BP_JVM_CDS_WARMUP_ITERATIONS",
+	"BP_JVM_CDS_WARN_MISSING_ARCHIVE",
+	"BP_JVM_CDS_WRITE_CONFIG",
+	"BP_JVM_CDS_WRITE_FILELIST",
+	"BP_SPRING_AOT_ENABLED",
+	"BP_SPRING_AOT_GENERATE",
+	"BP_SPRING_CLOUD_BINDINGS_DISABLED",
+	"BP_SPRING_CLOUD_BINDINGS_VERSION",
+	"BP_SPRING_PERFORMANCE_CHECK_ONLY",
+	"BP_SPRING_PERFORMANCE_LAUNCH_ENV_ALIASES",
+	"BP_SPRING_PERFORMANCE_LOG_LEVEL",
+	"BP_SPRING_REZIP",
+	"BP_SPRING_REZIP_COMPRESSION_LEVEL",
+	"BP_SPRING_REZIP_VERIFY_IDENTICAL",
+}
+
+// maxSuggestionDistance is the largest edit distance for which a known variable is suggested for an unknown one.
+const maxSuggestionDistance = 3
+
+// UnknownVariable is an environment variable with a performance prefix that the buildpack does not recognize.
+type UnknownVariable struct {
+
+	// Name is the name of the variable.
+	Name string
+
+	// Suggestion is the closest known variable, empty if none is close enough.
+	Suggestion string
+}
+
+// UnknownPerformanceVariables returns the variables of environ, in the format of os.Environ, that have a performance
+// prefix but are not known, sorted by name.
+func UnknownPerformanceVariables(environ []string) []UnknownVariable {
+	var unknown []UnknownVariable
+	for _, e := range environ {
+		name, _, _ := strings.Cut(e, "=")
+		if !slices.ContainsFunc(PerformanceVariablePrefixes, func(p string) bool { return strings.HasPrefix(name, p) }) ||
+			slices.Contains(KnownPerformanceVariables, name) {
+			continue
+		}
+
+		v := UnknownVariable{Name: name}
+		best := maxSuggestionDistance + 1
+		for _, known := range KnownPerformanceVariables {
+			if d := editDistance(name, known); d < best {
+				v.Suggestion, best = known, d
+			}
+		}
+		unknown = append(unknown, v)
+	}
+
+	slices.SortFunc(unknown, func(a, b UnknownVariable) int { return strings.Compare(a.Name, b.Name) })
+	return unknown
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,760 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pelletier/go-toml"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testPerformanceConfig(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("resolves defaults", func() {
+		t.Setenv("JRE_HOME", "")
+		t.Setenv("JAVA_HOME", "")
+
+		config, err := boot.NewPerformanceConfig()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(config.Required).To(BeTrue())
+		Expect(config.TrainingAssertions).To(BeFalse())
+		Expect(config.WarnMissingArchive).To(BeFalse())
+		Expect(config.JavaCommand()).To(Equal("java"))
+	})
+
+	context("ArchiveFile", func() {
+		it("defaults to the application", func() {
+			Expect(boot.PerformanceConfig{}.ArchiveFile()).To(Equal("application.jsa"))
+		})
+
+		it("uses BP_JVM_CDS_ARCHIVE_DIR", func() {
+			t.Setenv("BP_JVM_CDS_ARCHIVE_DIR", "/cds")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.ArchiveFile()).To(Equal("/cds/application.jsa"))
+		})
+
+		it("names the archive after BP_JVM_CDS_ENV_TAG", func() {
+			t.Setenv("BP_JVM_CDS_ARCHIVE_DIR", "/cds")
+
+			t.Setenv("BP_JVM_CDS_ENV_TAG", "prod")
+			prod, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			t.Setenv("BP_JVM_CDS_ENV_TAG", "staging")
+			staging, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(prod.ArchiveFile()).To(Equal("/cds/application-prod.jsa"))
+			Expect(staging.ArchiveFile()).To(Equal("/cds/application-staging.jsa"))
+		})
+
+		it("fails with an invalid BP_JVM_CDS_ENV_TAG", func() {
+			t.Setenv("BP_JVM_CDS_ENV_TAG", "../prod")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring(`invalid value for BP_JVM_CDS_ENV_TAG "../prod"`)))
+		})
+	})
+
+	context("Timestamp", func() {
+		it("defaults to 1980 when SOURCE_DATE_EPOCH is unset", func() {
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.Timestamp()).To(Equal(boot.DefaultTimestamp))
+		})
+
+		it("uses SOURCE_DATE_EPOCH when set", func() {
+			t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.Timestamp()).To(Equal(time.Unix(1700000000, 0).UTC()))
+		})
+
+		it("fails with an invalid SOURCE_DATE_EPOCH", func() {
+			t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring("unable to parse SOURCE_DATE_EPOCH")))
+		})
+	})
+
+	it("reads the environment", func() {
+		t.Setenv("BP_JVM_CDS_BASE_ARCHIVE", "/base.jsa")
+		t.Setenv("BP_JVM_CDS_TRAINING_ENTRYPOINT", "train.sh")
+		t.Setenv("BP_JVM_CDS_JARMODE", "tools")
+		t.Setenv("BP_JVM_CDS_TRAINING_DIR", "work")
+		t.Setenv("BP_JVM_CDS_TRAINING_ASSERTIONS", "true")
+		t.Setenv("BP_JVM_CDS_WARN_MISSING_ARCHIVE", "true")
+		t.Setenv("BP_JVM_CDS_REQUIRED", "false")
+
+		config, err := boot.NewPerformanceConfig()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(config.BaseArchive).To(Equal("/base.jsa"))
+		Expect(config.TrainingEntrypoint).To(Equal("train.sh"))
+		Expect(config.JarMode).To(Equal("tools"))
+		Expect(config.TrainingDir).To(Equal("work"))
+		Expect(config.TrainingAssertions).To(BeTrue())
+		Expect(config.WarnMissingArchive).To(BeTrue())
+		Expect(config.Required).To(BeFalse())
+	})
+
+	it("fails with a jarmode other than tools", func() {
+		t.Setenv("BP_JVM_CDS_JARMODE", "layertools")
+
+		_, err := boot.NewPerformanceConfig()
+		Expect(err).To(MatchError(`invalid value for BP_JVM_CDS_JARMODE "layertools", only the tools jarmode extracts the layout of the training run`))
+	})
+
+	it("fails with an invalid BP_JVM_CDS_REQUIRED", func() {
+		t.Setenv("BP_JVM_CDS_REQUIRED", "maybe")
+
+		_, err := boot.NewPerformanceConfig()
+		Expect(err).To(MatchError(ContainSubstring("invalid value for BP_JVM_CDS_REQUIRED")))
+	})
+
+	it("fails with an invalid BP_JVM_CDS_TRAINING_CPUS", func() {
+		t.Setenv("BP_JVM_CDS_TRAINING_CPUS", "0")
+
+		_, err := boot.NewPerformanceConfig()
+		Expect(err).To(MatchError(ContainSubstring("invalid value for BP_JVM_CDS_TRAINING_CPUS")))
+	})
+
+	context("BP_JVM_CDS_ALLOWED_FLAGS", func() {
+		it("splits the flags", func() {
+			t.Setenv("BP_JVM_CDS_ALLOWED_FLAGS", "-XX:+UnlockDiagnosticVMOptions,-javaagent:/agents/")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.AllowedFlags).To(Equal([]string{"-XX:+UnlockDiagnosticVMOptions", "-javaagent:/agents/"}))
+		})
+
+		it("fails with an entry that is not a flag", func() {
+			t.Setenv("BP_JVM_CDS_ALLOWED_FLAGS", "javaagent")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring("invalid value for BP_JVM_CDS_ALLOWED_FLAGS")))
+		})
+	})
+
+	context("BP_JVM_CDS_MAX_EXTRACT_BYTES", func() {
+		it("does not cap the extracted layout by default", func() {
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.MaxExtractBytes).To(BeZero())
+		})
+
+		it("parses the cap", func() {
+			t.Setenv("BP_JVM_CDS_MAX_EXTRACT_BYTES", "1073741824")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.MaxExtractBytes).To(Equal(int64(1073741824)))
+		})
+
+		it("fails with an invalid cap", func() {
+			t.Setenv("BP_JVM_CDS_MAX_EXTRACT_BYTES", "1G")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring(`invalid value for BP_JVM_CDS_MAX_EXTRACT_BYTES "1G"`)))
+		})
+	})
+
+	context("BP_SPRING_REZIP_COMPRESSION_LEVEL", func() {
+		it("stores the entries by default", func() {
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.ReZipCompressionLevel).To(BeZero())
+		})
+
+		it("parses the level", func() {
+			t.Setenv("BP_SPRING_REZIP_COMPRESSION_LEVEL", "9")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.ReZipCompressionLevel).To(Equal(9))
+		})
+
+		it("fails with a level out of range", func() {
+			t.Setenv("BP_SPRING_REZIP_COMPRESSION_LEVEL", "0")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring(`invalid value for BP_SPRING_REZIP_COMPRESSION_LEVEL "0"`)))
+		})
+	})
+
+	context("BP_JVM_CDS_ARCHIVE_FORMAT", func() {
+		it("creates a dynamic archive by default", func() {
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.Format()).To(Equal(boot.DynamicArchive{}))
+			Expect(boot.PerformanceConfig{}.Format()).To(Equal(boot.DynamicArchive{}))
+		})
+
+		it("selects the format", func() {
+			t.Setenv("BP_JVM_CDS_ARCHIVE_FORMAT", "classic")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.Format()).To(Equal(boot.ClassicArchive{}))
+		})
+
+		it("fails with a format that is not supported", func() {
+			t.Setenv("BP_JVM_CDS_ARCHIVE_FORMAT", "leyden")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring("invalid value for BP_JVM_CDS_ARCHIVE_FORMAT")))
+		})
+
+		it("fails with the classic format and BP_JVM_CDS_TRAINING_ENTRYPOINT", func() {
+			t.Setenv("BP_JVM_CDS_ARCHIVE_FORMAT", "classic")
+			t.Setenv("BP_JVM_CDS_TRAINING_ENTRYPOINT", "train.sh")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError("BP_JVM_CDS_ARCHIVE_FORMAT=classic is not supported with BP_JVM_CDS_TRAINING_ENTRYPOINT"))
+		})
+
+		it("allows the dynamic format with BP_JVM_CDS_TRAINING_ENTRYPOINT", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_ENTRYPOINT", "train.sh")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	context("BP_SPRING_REZIP", func() {
+		it("detects the re-zip by default", func() {
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.ReZip).To(Equal(boot.ReZipAuto))
+		})
+
+		it("forces the re-zip", func() {
+			t.Setenv("BP_SPRING_REZIP", "false")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.ReZip).To(Equal(boot.ReZipNever))
+		})
+
+		it("fails with an unknown mode", func() {
+			t.Setenv("BP_SPRING_REZIP", "sometimes")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring("invalid value for BP_SPRING_REZIP")))
+		})
+	})
+
+	context("BP_SPRING_PERFORMANCE_LOG_LEVEL", func() {
+		it("logs at normal level by default", func() {
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.LogLevel).To(Equal(boot.LogNormal))
+		})
+
+		it("parses the level", func() {
+			t.Setenv("BP_SPRING_PERFORMANCE_LOG_LEVEL", "quiet")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.LogLevel).To(Equal(boot.LogQuiet))
+		})
+
+		it("fails with an unknown level", func() {
+			t.Setenv("BP_SPRING_PERFORMANCE_LOG_LEVEL", "trace")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring("invalid value for BP_SPRING_PERFORMANCE_LOG_LEVEL")))
+		})
+	})
+
+	context("BP_JVM_CDS_DISK_MULTIPLIER", func() {
+		it("defaults the multiplier", func() {
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.DiskMultiplier).To(Equal(boot.DefaultDiskMultiplier))
+		})
+
+		it("parses the multiplier", func() {
+			t.Setenv("BP_JVM_CDS_DISK_MULTIPLIER", "1.5")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.DiskMultiplier).To(Equal(1.5))
+		})
+
+		it("fails with an invalid multiplier", func() {
+			t.Setenv("BP_JVM_CDS_DISK_MULTIPLIER", "-1")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring(`invalid value for BP_JVM_CDS_DISK_MULTIPLIER "-1"`)))
+		})
+	})
+
+	context("BP_JVM_CDS_WARMUP_ITERATIONS", func() {
+		it("refreshes the context once by default", func() {
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.WarmupIterations).To(Equal(1))
+		})
+
+		it("parses the iterations", func() {
+			t.Setenv("BP_JVM_CDS_WARMUP_ITERATIONS", "3")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.WarmupIterations).To(Equal(3))
+		})
+
+		it("fails with an invalid number of iterations", func() {
+			t.Setenv("BP_JVM_CDS_WARMUP_ITERATIONS", "0")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring("invalid value for BP_JVM_CDS_WARMUP_ITERATIONS")))
+		})
+	})
+
+	context("BP_JVM_CDS_DUMP_GRACE", func() {
+		it("defaults the grace period", func() {
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.DumpGrace).To(Equal(boot.DefaultDumpGrace))
+		})
+
+		it("parses the grace period", func() {
+			t.Setenv("BP_JVM_CDS_DUMP_GRACE", "1m30s")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.DumpGrace).To(Equal(90 * time.Second))
+		})
+
+		it("fails with an invalid grace period", func() {
+			t.Setenv("BP_JVM_CDS_DUMP_GRACE", "10")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring(`invalid value for BP_JVM_CDS_DUMP_GRACE "10"`)))
+		})
+	})
+
+	context("BP_JVM_CDS_SHUTDOWN_TIMEOUT", func() {
+		it("does not watch the shutdown by default", func() {
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.ShutdownTimeout).To(BeZero())
+			Expect(config.ForceDump).To(BeFalse())
+		})
+
+		it("parses the timeout", func() {
+			t.Setenv("BP_JVM_CDS_SHUTDOWN_TIMEOUT", "1m")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.ShutdownTimeout).To(Equal(time.Minute))
+		})
+
+		it("defaults the timeout of BP_JVM_CDS_FORCE_DUMP", func() {
+			t.Setenv("BP_JVM_CDS_FORCE_DUMP", "true")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.ShutdownTimeout).To(Equal(boot.DefaultShutdownTimeout))
+			Expect(config.ForceDump).To(BeTrue())
+		})
+
+		it("fails with an invalid timeout", func() {
+			t.Setenv("BP_JVM_CDS_SHUTDOWN_TIMEOUT", "0s")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring(`invalid value for BP_JVM_CDS_SHUTDOWN_TIMEOUT "0s"`)))
+		})
+	})
+
+	context("BP_JVM_CDS_TRAINING_DATASOURCE", func() {
+		it("parses the datasource", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_DATASOURCE", "embedded")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.TrainingDataSource).To(Equal(boot.EmbeddedDataSource))
+		})
+
+		it("fails with an unknown datasource", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_DATASOURCE", "mysql")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring("invalid value for BP_JVM_CDS_TRAINING_DATASOURCE")))
+		})
+	})
+
+	context("BP_JVM_CDS_TRAINING_ENV", func() {
+		it("splits the names", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_ENV", "SPRING_CONFIG_LOCATION, _DB_URL,,db2")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.TrainingEnv).To(Equal([]string{"SPRING_CONFIG_LOCATION", "_DB_URL", "db2"}))
+		})
+
+		it("fails with an illegal name", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_ENV", "SPRING_CONFIG_LOCATION,DB_URL=jdbc:h2:mem")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring("invalid value for BP_JVM_CDS_TRAINING_ENV")))
+			Expect(err).To(MatchError(ContainSubstring(`"DB_URL=jdbc:h2:mem" is not a valid environment variable name`)))
+		})
+	})
+
+	context("BP_JVM_CDS_CACHE_LAYOUT", func() {
+		it("caches the archive with the layout", func() {
+			t.Setenv("BP_JVM_CDS_CACHE_LAYOUT", "true")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.CacheLayout).To(BeTrue())
+			Expect(config.CacheArchive).To(BeTrue())
+		})
+	})
+
+	context("BP_JVM_CDS_MIN_APP_CLASSES", func() {
+		it("defaults to no minimum", func() {
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.MinAppClasses).To(BeZero())
+		})
+
+		it("parses the minimum", func() {
+			t.Setenv("BP_JVM_CDS_MIN_APP_CLASSES", "500")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.MinAppClasses).To(Equal(500))
+		})
+
+		it("fails with a negative minimum", func() {
+			t.Setenv("BP_JVM_CDS_MIN_APP_CLASSES", "-1")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(`invalid value for BP_JVM_CDS_MIN_APP_CLASSES "-1", expected a number of classes`))
+		})
+	})
+
+	context("BP_SPRING_PERFORMANCE_LAUNCH_ENV_ALIASES", func() {
+		it("parses the aliases", func() {
+			t.Setenv("BP_SPRING_PERFORMANCE_LAUNCH_ENV_ALIASES", "BPL_JVM_CDS_ENABLED=CDS_ON")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.LaunchEnvAliases).To(Equal(boot.LaunchEnvAliases{"BPL_JVM_CDS_ENABLED": {"CDS_ON"}}))
+			Expect(config.Variables()).To(HaveKeyWithValue("BP_SPRING_PERFORMANCE_LAUNCH_ENV_ALIASES", "BPL_JVM_CDS_ENABLED=CDS_ON"))
+		})
+
+		it("fails with an illegal alias", func() {
+			t.Setenv("BP_SPRING_PERFORMANCE_LAUNCH_ENV_ALIASES", "BPL_JVM_CDS_ENABLED=CDS ON")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring("invalid value for BP_SPRING_PERFORMANCE_LAUNCH_ENV_ALIASES")))
+		})
+	})
+
+	context("BP_JVM_CDS_TRAINING_PROFILES", func() {
+		it("splits the profiles", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_PROFILES", "prod, cloud-aws,,eu_west.1")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.TrainingProfiles).To(Equal([]string{"prod", "cloud-aws", "eu_west.1"}))
+		})
+
+		it("fails with an illegal profile name", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_PROFILES", "prod,-Dinjected=true test")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring("invalid value for BP_JVM_CDS_TRAINING_PROFILES")))
+			Expect(err).To(MatchError(ContainSubstring(`profile "-Dinjected=true test"`)))
+		})
+	})
+
+	context("BP_JVM_CDS_CLASSPATH_PREPEND and BP_JVM_CDS_CLASSPATH_APPEND", func() {
+		it("splits the entries on the path list separator", func() {
+			t.Setenv("BP_JVM_CDS_CLASSPATH_PREPEND", "/agents/stubs.jar")
+			t.Setenv("BP_JVM_CDS_CLASSPATH_APPEND", strings.Join([]string{"overrides", "", " /opt/extra.jar "}, string(filepath.ListSeparator)))
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.ClasspathPrepend).To(Equal([]string{"/agents/stubs.jar"}))
+			Expect(config.ClasspathAppend).To(Equal([]string{"overrides", "/opt/extra.jar"}))
+		})
+	})
+
+	context("BP_JVM_CDS_TRAINING_PROFILE_SETS", func() {
+		it("splits the profile sets", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_PROFILE_SETS", "default; cloud,kafka ;;")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.TrainingProfileSets).To(Equal([][]string{{"default"}, {"cloud", "kafka"}}))
+		})
+
+		it("fails with an illegal profile name", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_PROFILE_SETS", "default;cloud kafka")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring("invalid value for BP_JVM_CDS_TRAINING_PROFILE_SETS")))
+			Expect(err).To(MatchError(ContainSubstring(`profile "cloud kafka"`)))
+		})
+
+		it("fails with BP_JVM_CDS_TRAINING_PROFILES", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_PROFILE_SETS", "default;cloud")
+			t.Setenv("BP_JVM_CDS_TRAINING_PROFILES", "prod")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError("BP_JVM_CDS_TRAINING_PROFILE_SETS and BP_JVM_CDS_TRAINING_PROFILES cannot both be set"))
+		})
+
+		it("fails with BP_JVM_CDS_TRAINING_ENTRYPOINT", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_PROFILE_SETS", "default;cloud")
+			t.Setenv("BP_JVM_CDS_TRAINING_ENTRYPOINT", "train.sh")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring("not supported with BP_JVM_CDS_TRAINING_ENTRYPOINT")))
+		})
+	})
+
+	context("TrainingSandboxCommand", func() {
+		it("does not wrap the training run by default", func() {
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.TrainingNetwork).To(BeTrue())
+			Expect(config.TrainingSandboxCommand()).To(BeEmpty())
+		})
+
+		it("isolates the network of the training run", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_NETWORK", "false")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.TrainingSandboxCommand()).To(Equal(boot.DefaultNetworkSandbox))
+		})
+
+		it("prefers BP_JVM_CDS_TRAINING_SANDBOX", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_NETWORK", "false")
+			t.Setenv("BP_JVM_CDS_TRAINING_SANDBOX", " bwrap  --unshare-net --bind / / -- ")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.TrainingSandboxCommand()).To(Equal([]string{"bwrap", "--unshare-net", "--bind", "/", "/", "--"}))
+		})
+
+		it("fails with an invalid BP_JVM_CDS_TRAINING_NETWORK", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_NETWORK", "offline")
+
+			_, err := boot.NewPerformanceConfig()
+			Expect(err).To(MatchError(ContainSubstring("invalid value for BP_JVM_CDS_TRAINING_NETWORK")))
+		})
+	})
+
+	context("JavaEnv", func() {
+		it("returns the environment unchanged without a Java home", func() {
+			Expect(boot.PerformanceConfig{}.JavaEnv(nil)).To(BeNil())
+			Expect(boot.PerformanceConfig{}.JavaEnv([]string{"A=1"})).To(Equal([]string{"A=1"}))
+		})
+
+		it("prepends the Java home to the PATH of the environment", func() {
+			config := boot.PerformanceConfig{JavaHome: "/jdk"}
+
+			Expect(config.JavaEnv([]string{"A=1", "PATH=/usr/bin:/bin"})).To(Equal([]string{"A=1", "PATH=/jdk/bin:/usr/bin:/bin"}))
+		})
+
+		it("prepends the Java home to the PATH of the buildpack", func() {
+			t.Setenv("PATH", "/usr/bin")
+			config := boot.PerformanceConfig{JavaHome: "/jdk"}
+
+			Expect(config.JavaEnv([]string{"A=1"})).To(Equal([]string{"A=1", "PATH=/jdk/bin:/usr/bin"}))
+			Expect(config.JavaEnv(nil)).To(ContainElement("PATH=/jdk/bin:/usr/bin"))
+		})
+	})
+
+	it("prefers JRE_HOME over JAVA_HOME", func() {
+		t.Setenv("JAVA_HOME", "/jdk")
+		t.Setenv("JRE_HOME", "/jre")
+
+		config, err := boot.NewPerformanceConfig()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(config.JavaCommand()).To(Equal("/jre/bin/java"))
+	})
+
+	context("UnknownPerformanceVariables", func() {
+		it("ignores known and unrelated variables", func() {
+			Expect(boot.UnknownPerformanceVariables([]string{
+				"BP_JVM_CDS_ENABLED=true",
+				"BP_SPRING_AOT_ENABLED=true",
+				"BP_JVM_VERSION=21",
+				"PATH=/usr/bin",
+			})).To(BeEmpty())
+		})
+
+		it("suggests the closest known variable", func() {
+			Expect(boot.UnknownPerformanceVariables([]string{
+				"BP_JVM_CDS_ENABELD=true",
+				"BP_SPRING_AOT_ENABLE=true",
+				"BP_JVM_CDS_SOMETHING_ELSE=1",
+			})).To(Equal([]boot.UnknownVariable{
+				{Name: "BP_JVM_CDS_ENABELD", Suggestion: "BP_JVM_CDS_ENABLED"},
+				{Name: "BP_JVM_CDS_SOMETHING_ELSE"},
+				{Name: "BP_SPRING_AOT_ENABLE", Suggestion: "BP_SPRING_AOT_ENABLED"},
+			}))
+		})
+	})
+
+	context("TrainingRunJavaToolOptions", func() {
+		it("falls back to JAVA_TOOL_OPTIONS", func() {
+			t.Setenv("JAVA_TOOL_OPTIONS", "-Dfoo=bar")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.TrainingRunJavaToolOptions()).To(Equal("-Dfoo=bar"))
+		})
+
+		it("prefers CDS_TRAINING_JAVA_TOOL_OPTIONS", func() {
+			t.Setenv("JAVA_TOOL_OPTIONS", "-Dfoo=bar")
+			t.Setenv("CDS_TRAINING_JAVA_TOOL_OPTIONS", "-Dfoo=baz")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.TrainingRunJavaToolOptions()).To(Equal("-Dfoo=baz"))
+		})
+	})
+
+	context("Variables", func() {
+		it("resolves every known variable but the ones of the build", func() {
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			variables := config.Variables()
+			for _, name := range boot.KnownPerformanceVariables {
+				switch name {
+				case "BP_JVM_CDS_ENABLED", "BP_SPRING_AOT_ENABLED", "BP_SPRING_AOT_GENERATE",
+					"BP_SPRING_CLOUD_BINDINGS_DISABLED", "BP_SPRING_CLOUD_BINDINGS_VERSION":
+					Expect(variables).NotTo(HaveKey(name))
+				default:
+					Expect(variables).To(HaveKey(name))
+				}
+			}
+			Expect(variables).To(HaveLen(len(boot.KnownPerformanceVariables) - 5))
+		})
+
+		it("declares every known variable in buildpack.toml", func() {
+			b, err := os.ReadFile(filepath.Join("..", "buildpack.toml"))
+			Expect(err).NotTo(HaveOccurred())
+
+			var buildpack struct {
+				Metadata struct {
+					Configurations []struct {
+						Name    string `toml:"name"`
+						Default string `toml:"default"`
+						Build   bool   `toml:"build"`
+					} `toml:"configurations"`
+				} `toml:"metadata"`
+			}
+			Expect(toml.Unmarshal(b, &buildpack)).To(Succeed())
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+			variables := config.Variables()
+
+			declared := map[string]bool{}
+			for _, c := range buildpack.Metadata.Configurations {
+				declared[c.Name] = true
+				if value, ok := variables[c.Name]; ok && c.Default != "" {
+					Expect(c.Default).To(Equal(value), c.Name)
+				}
+				if strings.HasPrefix(c.Name, "BP_") {
+					Expect(c.Build).To(BeTrue(), c.Name)
+				}
+			}
+			for _, name := range boot.KnownPerformanceVariables {
+				Expect(declared).To(HaveKey(name))
+			}
+		})
+
+		it("formats the resolved values", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_PROFILE_SETS", "default;cloud,kafka")
+			t.Setenv("BP_SPRING_PERFORMANCE_LOG_LEVEL", "VERBOSE")
+
+			config, err := boot.NewPerformanceConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.Variables()).To(HaveKeyWithValue("BP_JVM_CDS_TRAINING_PROFILE_SETS", "default;cloud,kafka"))
+			Expect(config.Variables()).To(HaveKeyWithValue("BP_SPRING_PERFORMANCE_LOG_LEVEL", "verbose"))
+			Expect(config.Variables()).To(HaveKeyWithValue("BP_JVM_CDS_LAUNCH_DIR", boot.DefaultLaunchDir))
+		})
+	})
+}
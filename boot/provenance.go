@@ -0,0 +1,50 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+const (
+	// OptimizationsMetadata is the layer metadata key listing the optimizations active at launch, aot and cds.
+	OptimizationsMetadata = "optimizations"
+
+	// JDKVersionMetadata is the layer metadata key of the version of the JDK that performed the training run.
+	JDKVersionMetadata = "jdk_version"
+
+	// JDKVendorMetadata is the layer metadata key of the vendor of the JDK that performed the training run.
+	JDKVendorMetadata = "jdk_vendor"
+)
+
+// provenance returns the layer metadata describing the optimizations of result and the JDK that produced them. The
+// lifecycle records the layer metadata in the io.buildpacks.lifecycle.metadata label of the image, so that scanners
+// and policies can tell how the image was optimized.
+func provenance(result PerformanceResult, cdsActive bool) map[string]interface{} {
+	optimizations := []string{}
+	if result.AOTApplied {
+		optimizations = append(optimizations, "aot")
+	}
+	if cdsActive {
+		optimizations = append(optimizations, "cds")
+	}
+
+	metadata := map[string]interface{}{OptimizationsMetadata: optimizations}
+	if result.JDKVersion != "" {
+		metadata[JDKVersionMetadata] = result.JDKVersion
+	}
+	if result.JDKVendor != "" {
+		metadata[JDKVendorMetadata] = result.JDKVendor
+	}
+	return metadata
+}
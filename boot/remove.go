@@ -0,0 +1,76 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RemovableDirs returns the directories an application can be removed from: the workspace, the application directory
+// of the platform and the temporary directory.
+func RemovableDirs() []string {
+	dirs := []string{DefaultLaunchDir, os.TempDir()}
+	if appDir, ok := os.LookupEnv("CNB_APP_DIR"); ok && appDir != "" {
+		dirs = append(dirs, appDir)
+	}
+	return dirs
+}
+
+// ValidateRemovable checks that path can safely be removed: it must be one of dirs, other than the temporary directory,
+// or be located under one of them. An empty path and the root are always refused.
+func ValidateRemovable(path string, dirs []string) error {
+	if strings.TrimSpace(path) == "" {
+		return fmt.Errorf("refusing to remove an empty path")
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("unable to resolve %s\n%w", path, err)
+	}
+	if abs == filepath.VolumeName(abs)+string(filepath.Separator) {
+		return fmt.Errorf("refusing to remove the root directory %s", path)
+	}
+
+	tmp := filepath.Clean(os.TempDir())
+	for _, dir := range dirs {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		if abs == dir && dir != tmp {
+			return nil
+		}
+		if rel, err := filepath.Rel(dir, abs); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			return nil
+		}
+	}
+	return fmt.Errorf("refusing to remove %s, it is not located under %s", path, strings.Join(dirs, ", "))
+}
+
+// RemoveApplication removes the application at path, after checking it is located in one of RemovableDirs.
+func RemoveApplication(path string) error {
+	if err := ValidateRemovable(path, RemovableDirs()); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("unable to remove %s\n%w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testRemove(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dirs = []string{boot.DefaultLaunchDir, os.TempDir()}
+	)
+
+	it("accepts the workspace and the directories under it", func() {
+		Expect(boot.ValidateRemovable("/workspace", dirs)).To(Succeed())
+		Expect(boot.ValidateRemovable("/workspace/", dirs)).To(Succeed())
+		Expect(boot.ValidateRemovable("/workspace/app", dirs)).To(Succeed())
+	})
+
+	it("accepts a directory under the temporary directory", func() {
+		Expect(boot.ValidateRemovable(filepath.Join(os.TempDir(), "app"), dirs)).To(Succeed())
+	})
+
+	it("refuses an empty path", func() {
+		Expect(boot.ValidateRemovable("", dirs)).To(MatchError(ContainSubstring("refusing to remove an empty path")))
+		Expect(boot.ValidateRemovable("  ", dirs)).To(MatchError(ContainSubstring("refusing to remove an empty path")))
+	})
+
+	it("refuses the root", func() {
+		Expect(boot.ValidateRemovable("/", dirs)).To(MatchError(ContainSubstring("refusing to remove the root directory")))
+		Expect(boot.ValidateRemovable("/workspace/..", dirs)).To(MatchError(ContainSubstring("refusing to remove the root directory")))
+	})
+
+	it("refuses the temporary directory itself", func() {
+		Expect(boot.ValidateRemovable(os.TempDir(), dirs)).To(MatchError(ContainSubstring("refusing to remove")))
+	})
+
+	it("refuses sensitive directories", func() {
+		for _, path := range []string{"/etc", "/usr/lib", "/home", "/workspace-other", "/workspace/../etc"} {
+			Expect(boot.ValidateRemovable(path, dirs)).To(MatchError(ContainSubstring("is not located under")), path)
+		}
+	})
+
+	it("removes an application under the temporary directory", func() {
+		appPath := t.TempDir()
+		Expect(os.WriteFile(filepath.Join(appPath, "file"), []byte{}, 0644)).To(Succeed())
+
+		Expect(boot.RemoveApplication(appPath)).To(Succeed())
+		Expect(appPath).NotTo(BeAnExistingFile())
+	})
+
+	it("does not remove a refused path", func() {
+		Expect(boot.RemoveApplication("/")).To(MatchError(ContainSubstring("refusing to remove the root directory")))
+	})
+
+	it("accepts the platform application directory", func() {
+		t.Setenv("CNB_APP_DIR", "/layers/app")
+		Expect(boot.RemovableDirs()).To(ContainElement("/layers/app"))
+	})
+}
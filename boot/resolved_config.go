@@ -0,0 +1,82 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+)
+
+// ResolvedConfigName is the name of the resolved configuration of the contribution in the debug directory of the layer.
+const ResolvedConfigName = "performance-config.json"
+
+// resolvedConfig is the effective configuration of a contribution, a single file to attach to a bug report.
+type resolvedConfig struct {
+	Variables        map[string]string `json:"variables"`
+	StartClass       string            `json:"start_class"`
+	ClasspathEntries int               `json:"classpath_entries"`
+	ClasspathLength  int               `json:"classpath_length"`
+	JDKVersion       string            `json:"jdk_version,omitempty"`
+}
+
+// writeResolvedConfig writes the effective value of each variable of KnownPerformanceVariables, the start class, the
+// size of the classpath of the training run and the version of the JDK of javaCommand to the debug directory of layer.
+// The variables resolved by the build have the value of the environment, if any.
+func (s SpringPerformance) writeResolvedConfig(layer libcnb.Layer, startClass string, classpath []string, javaCommand string) error {
+	variables := make(map[string]string, len(KnownPerformanceVariables))
+	for _, name := range KnownPerformanceVariables {
+		variables[name] = os.Getenv(name)
+	}
+	maps.Copy(variables, s.Config.Variables())
+	variables["BP_JVM_CDS_ENABLED"] = strconv.FormatBool(s.DoTrainingRun)
+	variables["BP_SPRING_AOT_ENABLED"] = strconv.FormatBool(s.AotEnabled)
+	variables["BP_SPRING_AOT_GENERATE"] = strconv.FormatBool(s.GenerateAOT)
+
+	config := resolvedConfig{
+		Variables:        variables,
+		StartClass:       startClass,
+		ClasspathEntries: len(classpath),
+		ClasspathLength:  len(strings.Join(classpath, string(filepath.ListSeparator))),
+	}
+	if jdk, err := s.trainingJDK(javaCommand); err != nil {
+		s.log().Bodyf("Unable to record the training run JDK in %s: %s", ResolvedConfigName, err)
+	} else {
+		config.JDKVersion = jdk.Version
+	}
+
+	dir, err := s.debugDir(layer)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode %s\n%w", ResolvedConfigName, err)
+	}
+	file := filepath.Join(dir, ResolvedConfigName)
+	if err := os.WriteFile(file, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("unable to write %s\n%w", file, err)
+	}
+	s.log().Bodyf("Wrote the resolved configuration to %s", file)
+	return nil
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// ManifestEntryName is the name of the manifest entry of a jar, which re-zipping may complete with a Main-Class.
+const ManifestEntryName = "META-INF/MANIFEST.MF"
+
+// ContentDigests returns the SHA-256 digest of the files under dir, keyed by the name of their entry in a jar created
+// from dir. Symbolic links are resolved, as they are by CreateJar.
+func ContentDigests(dir string) (map[string]string, error) {
+	digests := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		file := path
+		if info.Mode()&os.ModeSymlink == os.ModeSymlink {
+			if file, err = filepath.EvalSymlinks(path); err != nil {
+				return fmt.Errorf("unable to eval symlink %s\n%w", path, err)
+			}
+			if info, err = os.Stat(file); err != nil {
+				return fmt.Errorf("unable to stat %s\n%w", file, err)
+			}
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return fmt.Errorf("unable to compute entry name of %s\n%w", path, err)
+		}
+		digest, err := FileDigest(file)
+		if err != nil {
+			return err
+		}
+		digests[filepath.ToSlash(name)] = digest
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute digests of %s\n%w", dir, err)
+	}
+	return digests, nil
+}
+
+// JarDigests returns the SHA-256 digest of the file entries of the jar at jarPath, keyed by their name.
+func JarDigests(jarPath string) (map[string]string, error) {
+	r, err := zip.OpenReader(jarPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s\n%w", jarPath, err)
+	}
+	defer r.Close()
+
+	digests := make(map[string]string)
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		digest, err := entryDigest(f)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s in %s\n%w", f.Name, jarPath, err)
+		}
+		digests[f.Name] = digest
+	}
+	return digests, nil
+}
+
+// ContentsDigest returns a SHA-256 digest identifying a set of file digests, independently of the order they were
+// collected in.
+func ContentsDigest(digests map[string]string) string {
+	names := make([]string, 0, len(digests))
+	for name := range digests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		_, _ = fmt.Fprintf(h, "%s\x00%s\n", name, digests[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// DiffContents returns the differences between two sets of file digests, ignoring the given entry names. It is empty if
+// both sets contain the same files with the same contents.
+func DiffContents(expected map[string]string, actual map[string]string, ignored ...string) []string {
+	var diff []string
+	for name, digest := range expected {
+		if slices.Contains(ignored, name) {
+			continue
+		}
+		if d, ok := actual[name]; !ok {
+			diff = append(diff, fmt.Sprintf("%s: missing", name))
+		} else if d != digest {
+			diff = append(diff, fmt.Sprintf("%s: expected sha256:%s, found sha256:%s", name, digest, d))
+		}
+	}
+	for name := range actual {
+		if _, ok := expected[name]; !ok && !slices.Contains(ignored, name) {
+			diff = append(diff, fmt.Sprintf("%s: unexpected", name))
+		}
+	}
+	sort.Strings(diff)
+	return diff
+}
+
+// FileDigest returns the SHA-256 digest of the file at path.
+func FileDigest(path string) (string, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to open %s\n%w", path, err)
+	}
+	defer in.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, in); err != nil {
+		return "", fmt.Errorf("unable to read %s\n%w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ReZipIgnoreFile is the file of the application root listing, with gitignore-style patterns, the files that are not
+// packed into the re-zipped jar.
+const ReZipIgnoreFile = ".rezipignore"
+
+// IgnorePatterns are gitignore-style patterns matching jar entry names. The last matching pattern decides whether a
+// name is ignored, so that a negated pattern re-includes names ignored by a previous one.
+type IgnorePatterns []ignorePattern
+
+type ignorePattern struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// ParseIgnorePatterns parses the gitignore-style patterns of r, one per line. Blank lines and lines starting with #
+// are skipped. A pattern starting with ! is negated, a pattern ending with / only matches directories, and a pattern
+// containing a / is relative to the root rather than matched at any depth. * and ? do not match a /, ** matches any
+// number of directories.
+func ParseIgnorePatterns(r io.Reader) (IgnorePatterns, error) {
+	var patterns IgnorePatterns
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var p ignorePattern
+		if p.negate = strings.HasPrefix(line, "!"); p.negate {
+			line = line[1:]
+		} else {
+			// a leading \ escapes a pattern starting with # or !
+			line = strings.TrimPrefix(line, `\`)
+		}
+		if p.dirOnly = strings.HasSuffix(line, "/"); p.dirOnly {
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		anchored := strings.Contains(line, "/")
+		p.segments = strings.Split(strings.TrimPrefix(line, "/"), "/")
+		for _, s := range p.segments {
+			if _, err := path.Match(s, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q\n%w", scanner.Text(), err)
+			}
+		}
+		if !anchored {
+			p.segments = append([]string{"**"}, p.segments...)
+		}
+		patterns = append(patterns, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read patterns\n%w", err)
+	}
+	return patterns, nil
+}
+
+// ReadIgnoreFile returns the patterns of the ReZipIgnoreFile of source, with a pattern ignoring the file itself, or no
+// patterns if source does not contain one.
+func ReadIgnoreFile(source string) (IgnorePatterns, error) {
+	file := filepath.Join(source, ReZipIgnoreFile)
+	in, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to open %s\n%w", file, err)
+	}
+	defer in.Close()
+
+	patterns, err := ParseIgnorePatterns(in)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s\n%w", file, err)
+	}
+	return append(patterns, ignorePattern{segments: []string{ReZipIgnoreFile}}), nil
+}
+
+// Ignored returns whether the slash separated entry name, or one of its parent directories, is ignored by the
+// patterns.
+func (p IgnorePatterns) Ignored(name string, isDir bool) bool {
+	if len(p) == 0 {
+		return false
+	}
+
+	segments := strings.Split(strings.Trim(name, "/"), "/")
+	for i := 1; i < len(segments); i++ {
+		if p.matches(segments[:i], true) {
+			return true
+		}
+	}
+	return p.matches(segments, isDir)
+}
+
+func (p IgnorePatterns) matches(segments []string, isDir bool) bool {
+	ignored := false
+	for _, pattern := range p {
+		if pattern.dirOnly && !isDir {
+			continue
+		}
+		if matchSegments(pattern.segments, segments) {
+			ignored = !pattern.negate
+		}
+	}
+	return ignored
+}
+
+// matchSegments returns whether the segments of a name match the segments of a pattern, ** matching any number of
+// segments.
+func matchSegments(pattern []string, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pattern[0], name[0])
+	return ok && matchSegments(pattern[1:], name[1:])
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testReZipIgnore(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	parse := func(content string) boot.IgnorePatterns {
+		patterns, err := boot.ParseIgnorePatterns(strings.NewReader(content))
+		Expect(err).NotTo(HaveOccurred())
+		return patterns
+	}
+
+	it("matches an unanchored pattern at any depth", func() {
+		patterns := parse("*.log\n")
+
+		Expect(patterns.Ignored("build.log", false)).To(BeTrue())
+		Expect(patterns.Ignored("BOOT-INF/classes/build.log", false)).To(BeTrue())
+		Expect(patterns.Ignored("BOOT-INF/classes/build.txt", false)).To(BeFalse())
+	})
+
+	it("matches an anchored pattern from the root only", func() {
+		patterns := parse("/docs\nBOOT-INF/classes/*.md\n")
+
+		Expect(patterns.Ignored("docs", true)).To(BeTrue())
+		Expect(patterns.Ignored("BOOT-INF/docs", true)).To(BeFalse())
+		Expect(patterns.Ignored("BOOT-INF/classes/README.md", false)).To(BeTrue())
+		Expect(patterns.Ignored("BOOT-INF/classes/com/README.md", false)).To(BeFalse())
+	})
+
+	it("matches a directory pattern and its contents", func() {
+		patterns := parse("fixtures/\n")
+
+		Expect(patterns.Ignored("BOOT-INF/classes/fixtures", true)).To(BeTrue())
+		Expect(patterns.Ignored("BOOT-INF/classes/fixtures/data.json", false)).To(BeTrue())
+		Expect(patterns.Ignored("BOOT-INF/classes/fixtures", false)).To(BeFalse())
+	})
+
+	it("matches any number of directories with **", func() {
+		patterns := parse("BOOT-INF/**/test/\n")
+
+		Expect(patterns.Ignored("BOOT-INF/test/a.class", false)).To(BeTrue())
+		Expect(patterns.Ignored("BOOT-INF/classes/com/test/a.class", false)).To(BeTrue())
+		Expect(patterns.Ignored("META-INF/test/a.class", false)).To(BeFalse())
+	})
+
+	it("re-includes names with a negated pattern", func() {
+		patterns := parse("# generated files\n*.txt\n!keep.txt\n\n")
+
+		Expect(patterns.Ignored("notes.txt", false)).To(BeTrue())
+		Expect(patterns.Ignored("keep.txt", false)).To(BeFalse())
+	})
+
+	it("does not re-include the contents of an ignored directory", func() {
+		patterns := parse("cache/\n!cache/keep.txt\n")
+
+		Expect(patterns.Ignored("cache/keep.txt", false)).To(BeTrue())
+	})
+
+	it("fails on an invalid pattern", func() {
+		_, err := boot.ParseIgnorePatterns(strings.NewReader("[a-\n"))
+		Expect(err).To(MatchError(ContainSubstring(`invalid pattern "[a-"`)))
+	})
+
+	it("ignores nothing without patterns", func() {
+		var patterns boot.IgnorePatterns
+		Expect(patterns.Ignored("BOOT-INF/classes/build.log", false)).To(BeFalse())
+	})
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testReZip(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		source string
+	)
+
+	it.Before(func() {
+		source = t.TempDir()
+
+		Expect(os.MkdirAll(filepath.Join(source, "META-INF"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(source, "META-INF", "MANIFEST.MF"), []byte("Manifest-Version: 1.0\n"), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(source, "BOOT-INF", "classes"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(source, "BOOT-INF", "classes", "Application.class"), []byte("class"), 0644)).To(Succeed())
+	})
+
+	it("computes the same digests for a directory and the jar created from it", func() {
+		Expect(os.Symlink(filepath.Join(source, "BOOT-INF", "classes", "Application.class"), filepath.Join(source, "link.class"))).To(Succeed())
+		jar := filepath.Join(t.TempDir(), "runner.jar")
+		Expect(boot.CreateJar(source, jar, boot.DefaultTimestamp)).To(Succeed())
+
+		contents, err := boot.ContentDigests(source)
+		Expect(err).NotTo(HaveOccurred())
+		entries, err := boot.JarDigests(jar)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(contents).To(HaveLen(3))
+		Expect(contents).To(HaveKey("BOOT-INF/classes/Application.class"))
+		Expect(contents["link.class"]).To(Equal(contents["BOOT-INF/classes/Application.class"]))
+		Expect(boot.DiffContents(contents, entries)).To(BeEmpty())
+		Expect(boot.ContentsDigest(entries)).To(Equal(boot.ContentsDigest(contents)))
+	})
+
+	it("reports the differences between contents", func() {
+		expected := map[string]string{"a.class": "1", "b.class": "2", boot.ManifestEntryName: "3"}
+		actual := map[string]string{"a.class": "1", "b.class": "4", "c.class": "5", boot.ManifestEntryName: "6"}
+
+		Expect(boot.DiffContents(expected, actual, boot.ManifestEntryName)).To(Equal([]string{
+			"b.class: expected sha256:2, found sha256:4",
+			"c.class: unexpected",
+		}))
+		Expect(boot.DiffContents(actual, map[string]string{})).To(ContainElement("a.class: missing"))
+	})
+
+	it("changes the contents digest with the contents", func() {
+		Expect(boot.ContentsDigest(map[string]string{"a.class": "1"})).NotTo(Equal(boot.ContentsDigest(map[string]string{"a.class": "2"})))
+		Expect(boot.ContentsDigest(map[string]string{"a.class": "1"})).NotTo(Equal(boot.ContentsDigest(map[string]string{"b.class": "1"})))
+	})
+
+	it("computes the digest of a file", func() {
+		digest, err := boot.FileDigest(filepath.Join(source, "BOOT-INF", "classes", "Application.class"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(Equal("0889113e04d3203f0c401c17c0fd8b352b740dc607433779d3edcaa13320b001"))
+	})
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+// MinimumTrainingJDK is the oldest major version of the JDK accepted by SelfTest, the oldest supported by the versions
+// of Spring Boot extracting the CDS layout.
+const MinimumTrainingJDK = 17
+
+// jdkMajorPattern matches the major version of a JDK version, such as 21 in 21.0.3 or 8 in 1.8.0_392.
+var jdkMajorPattern = regexp.MustCompile(`^(?:1\.)?(\d+)`)
+
+// JDKMajorVersion returns the major version of the JDK version, such as 21 for 21.0.3.
+func JDKMajorVersion(version string) (int, bool) {
+	m := jdkMajorPattern.FindStringSubmatch(strings.TrimSpace(version))
+	if m == nil {
+		return 0, false
+	}
+	major, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, false
+	}
+	return major, true
+}
+
+// SelfTest checks with exec that the build environment is able to contribute the CDS archive of the application. It
+// fails with a message naming the first capability missing: the java of the configuration, a JDK of at least
+// MinimumTrainingJDK, the JVM flag creating the archive of the format, and the jarmode extracting the layout when it
+// is required by BP_JVM_CDS_JARMODE.
+func (s SpringPerformance) SelfTest(exec effect.Executor) error {
+	javaCommand := s.Config.JavaCommand()
+	buf := &bytes.Buffer{}
+	if err := exec.Execute(effect.Execution{
+		Command: javaCommand,
+		Args:    []string{"-XshowSettings:properties", "-XX:+PrintFlagsFinal", "-version"},
+		Env:     s.Config.JavaEnv(nil),
+		Stdout:  buf,
+		Stderr:  buf,
+	}); err != nil {
+		return fmt.Errorf("java is not available at %s, set JAVA_HOME to a JDK\n%w", javaCommand, err)
+	}
+
+	jdk := NewJDKFromSettings(buf.String())
+	if major, ok := JDKMajorVersion(jdk.Version); !ok {
+		return fmt.Errorf("unable to determine the version of %s", javaCommand)
+	} else if major < MinimumTrainingJDK {
+		return fmt.Errorf("JDK %s at %s is not supported, the training run requires JDK %d or later", jdk.Version, javaCommand, MinimumTrainingJDK)
+	}
+
+	// the flag of the training run is reported by the JVM that supports it
+	format := s.Config.Format()
+	flag, _, _ := strings.Cut(strings.TrimPrefix(format.TrainingArgs("", "")[0], "-XX:"), "=")
+	if !slices.Contains(strings.Fields(buf.String()), flag) {
+		return fmt.Errorf("JDK %s at %s does not support -XX:%s, required by the %s archive format", jdk.Version, javaCommand, flag, format.Name())
+	}
+
+	mode := JarModeTools
+	if supported, err := s.applicationJarModeSupported(mode); err != nil {
+		return fmt.Errorf("unable to check jarmode support\n%w", err)
+	} else if !supported && s.Config.JarMode != "" {
+		return WithHint(fmt.Errorf("jarmode %s required by BP_JVM_CDS_JARMODE is not supported by the application, it requires the spring-boot-jarmode-%s library", mode, mode), HintJarMode)
+	} else if !supported {
+		s.log().Bodyf("Jarmode %s is not supported by the application, its layout will be extracted without the JVM", mode)
+	}
+
+	s.log().Bodyf("Self-test passed with JDK %s at %s", jdk.Version, javaCommand)
+	return nil
+}
+
+// applicationJarModeSupported returns whether the application, exploded or a jar, ships the jarmode library for mode.
+func (s SpringPerformance) applicationJarModeSupported(mode string) (bool, error) {
+	if s.applicationJar() {
+		return JarModeSupported(s.AppPath, mode)
+	}
+
+	lib, ok := s.Manifest.Get("Spring-Boot-Lib")
+	if !ok {
+		lib = "BOOT-INF/lib"
+	}
+	libraries, err := filepath.Glob(filepath.Join(s.AppPath, lib, fmt.Sprintf("spring-boot-jarmode-%s-*.jar", mode)))
+	if err != nil {
+		return false, fmt.Errorf("unable to list the libraries of %s\n%w", s.AppPath, err)
+	}
+	return len(libraries) > 0, nil
+}
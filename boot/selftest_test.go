@@ -0,0 +1,159 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libjvm"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/paketo-buildpacks/libpak/effect/mocks"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testSelfTest(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		appPath  string
+		executor *mocks.Executor
+		buf      *bytes.Buffer
+	)
+
+	it.Before(func() {
+		appPath = t.TempDir()
+		Expect(os.MkdirAll(filepath.Join(appPath, "META-INF"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(appPath, "META-INF", "MANIFEST.MF"), []byte(`
+Spring-Boot-Version: 3.3.1
+Spring-Boot-Lib: BOOT-INF/lib
+Start-Class: com.example.Application
+`), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(appPath, "BOOT-INF", "lib"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(appPath, "BOOT-INF", "lib", "spring-boot-jarmode-tools-3.3.1.jar"), []byte{}, 0644)).To(Succeed())
+
+		executor = &mocks.Executor{}
+		buf = &bytes.Buffer{}
+	})
+
+	// java reports its settings and its flags
+	java := func(version string, flags ...string) {
+		executor.On("Execute", mock.Anything).Run(func(args mock.Arguments) {
+			e := args.Get(0).(effect.Execution)
+			_, err := fmt.Fprintf(e.Stderr, "Property settings:\n    java.version = %s\n", version)
+			Expect(err).NotTo(HaveOccurred())
+			for _, f := range flags {
+				_, err := fmt.Fprintf(e.Stdout, "    ccstr %-40s =                                           {product} {default}\n", f)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		}).Return(nil)
+	}
+
+	selfTest := func() error {
+		manifest, err := libjvm.NewManifest(appPath)
+		Expect(err).NotTo(HaveOccurred())
+		config, err := boot.NewPerformanceConfig()
+		Expect(err).NotTo(HaveOccurred())
+
+		s := boot.NewSpringPerformance(libpak.DependencyCache{}, appPath, manifest, false, true, "", config)
+		s.Logger = bard.NewLogger(buf)
+		return s.SelfTest(executor)
+	}
+
+	it("passes in a CDS capable environment", func() {
+		java("21.0.3", "ArchiveClassesAtExit", "DumpLoadedClassList")
+
+		Expect(selfTest()).To(Succeed())
+
+		e := executor.Calls[0].Arguments[0].(effect.Execution)
+		Expect(e.Args).To(Equal([]string{"-XshowSettings:properties", "-XX:+PrintFlagsFinal", "-version"}))
+		Expect(buf.String()).To(ContainSubstring("Self-test passed with JDK 21.0.3"))
+	})
+
+	it("fails without java", func() {
+		executor.On("Execute", mock.Anything).Return(fmt.Errorf("exec: \"java\": executable file not found in $PATH"))
+
+		Expect(selfTest()).To(MatchError(ContainSubstring("java is not available at java")))
+	})
+
+	it("fails when the version of the JDK cannot be determined", func() {
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		Expect(selfTest()).To(MatchError("unable to determine the version of java"))
+	})
+
+	it("fails with a JDK older than the minimum", func() {
+		java("11.0.22", "ArchiveClassesAtExit")
+
+		Expect(selfTest()).To(MatchError("JDK 11.0.22 at java is not supported, the training run requires JDK 17 or later"))
+	})
+
+	it("fails without the dynamic archive", func() {
+		java("17.0.11", "DumpLoadedClassList")
+
+		Expect(selfTest()).To(MatchError("JDK 17.0.11 at java does not support -XX:ArchiveClassesAtExit, required by the dynamic archive format"))
+	})
+
+	it("checks the flag of the archive format", func() {
+		t.Setenv("BP_JVM_CDS_ARCHIVE_FORMAT", "classic")
+		java("17.0.11", "ArchiveClassesAtExit")
+
+		Expect(selfTest()).To(MatchError("JDK 17.0.11 at java does not support -XX:DumpLoadedClassList, required by the classic archive format"))
+	})
+
+	context("jarmode", func() {
+		it.Before(func() {
+			Expect(os.Remove(filepath.Join(appPath, "BOOT-INF", "lib", "spring-boot-jarmode-tools-3.3.1.jar"))).To(Succeed())
+			java("21.0.3", "ArchiveClassesAtExit")
+		})
+
+		it("fails without the jarmode required by BP_JVM_CDS_JARMODE", func() {
+			t.Setenv("BP_JVM_CDS_JARMODE", "tools")
+
+			err := selfTest()
+			Expect(err).To(MatchError("jarmode tools required by BP_JVM_CDS_JARMODE is not supported by the application, it requires the spring-boot-jarmode-tools library"))
+			hint, ok := boot.ErrorHint(err)
+			Expect(ok).To(BeTrue())
+			Expect(hint).To(Equal(boot.HintJarMode))
+		})
+
+		it("passes when the layout can be extracted without the JVM", func() {
+			Expect(selfTest()).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring("Jarmode tools is not supported by the application, its layout will be extracted without the JVM"))
+		})
+	})
+
+	it("parses the major version of the JDK", func() {
+		for version, major := range map[string]int{"21.0.3": 21, "1.8.0_392": 8, "22-ea": 22} {
+			m, ok := boot.JDKMajorVersion(version)
+			Expect(ok).To(BeTrue())
+			Expect(m).To(Equal(major))
+		}
+
+		_, ok := boot.JDKMajorVersion("unknown")
+		Expect(ok).To(BeFalse())
+	})
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"bytes"
+	"sync"
+)
+
+const (
+	// shutdownSlowMarker starts the line of the shutdown watchdog reporting a shutdown longer than its timeout.
+	shutdownSlowMarker = "CDS training run shutdown is taking longer than "
+
+	// shutdownHaltedMarker starts the line of the shutdown watchdog halting the JVM once its timeout elapsed.
+	shutdownHaltedMarker = "CDS training run halted after a shutdown longer than "
+)
+
+// ShutdownWatch is an io.Writer detecting the reports of the shutdown watchdog in the lines written to it, so that the
+// output of the training run does not have to be kept.
+type ShutdownWatch struct {
+	mu      sync.Mutex
+	partial []byte
+	slow    bool
+	halted  bool
+}
+
+func (w *ShutdownWatch) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	if i := bytes.LastIndexByte(w.partial, '\n'); i != -1 {
+		w.parse(w.partial[:i])
+		w.partial = append([]byte(nil), w.partial[i+1:]...)
+	}
+	return len(p), nil
+}
+
+// Result returns whether the watchdog reported a shutdown longer than its timeout and whether it halted the JVM, the
+// last line written being parsed even if it is not terminated.
+func (w *ShutdownWatch) Result() (slow bool, halted bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.partial) > 0 {
+		w.parse(w.partial)
+		w.partial = nil
+	}
+	return w.slow, w.halted
+}
+
+func (w *ShutdownWatch) parse(lines []byte) {
+	for _, line := range bytes.Split(lines, []byte("\n")) {
+		switch {
+		case bytes.HasPrefix(line, []byte(shutdownHaltedMarker)):
+			w.slow, w.halted = true, true
+		case bytes.HasPrefix(line, []byte(shutdownSlowMarker)):
+			w.slow = true
+		}
+	}
+}
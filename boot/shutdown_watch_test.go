@@ -0,0 +1,65 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testShutdownWatch(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		watch *boot.ShutdownWatch
+	)
+
+	it.Before(func() {
+		watch = &boot.ShutdownWatch{}
+	})
+
+	result := func() []bool {
+		slow, halted := watch.Result()
+		return []bool{slow, halted}
+	}
+
+	it("reports nothing for a shutdown completing in time", func() {
+		Expect(watch.Write([]byte("Started Application in 1.5 seconds\n"))).To(Equal(35))
+
+		Expect(result()).To(Equal([]bool{false, false}))
+	})
+
+	it("detects a slow shutdown", func() {
+		_, err := watch.Write([]byte("Started Application in 1.5 seconds\nCDS training run shutdown is taking longer than 5000 ms, a shutdown hook"))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = watch.Write([]byte(" may block the dump of the archive\nStopping the pool\n"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(result()).To(Equal([]bool{true, false}))
+	})
+
+	it("detects a halted shutdown on a last line that is not terminated", func() {
+		_, err := watch.Write([]byte("CDS training run halted after a shutdown longer than 30000 ms"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(result()).To(Equal([]bool{true, true}))
+	})
+}
@@ -17,13 +17,20 @@
 package boot
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"io/fs"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
 
-	"github.com/paketo-buildpacks/libpak/crush"
 	"github.com/paketo-buildpacks/libpak/sherpa"
 
 	"os"
+	"path"
 	"path/filepath"
 	"time"
 
@@ -39,44 +46,230 @@ type SpringPerformance struct {
 	LayerContributor           libpak.LayerContributor
 	Logger                     bard.Logger
 	Executor                   effect.Executor
+	Metrics                    MetricsSink
 	AppPath                    string
 	Manifest                   *properties.Properties
 	AotEnabled                 bool
+	GenerateAOT                bool
 	DoTrainingRun              bool
+	Classpath                  []string
 	ClasspathString            string
 	ReZip                      bool
 	TrainingRunJavaToolOptions string
+	Config                     PerformanceConfig
+	ArgMax                     int
+	ArgsCustomizer             func([]string) []string
+	CaptureTrainingOutput      bool
+	CgroupRoot                 string
+	FreeDisk                   FreeDiskFunc
+	Context                    context.Context
+	temp                       *TempDirs
 }
 
-func NewSpringPerformance(cache libpak.DependencyCache, appPath string, manifest *properties.Properties, aotEnabled bool, doTrainingRun bool, classpathString string, reZip bool, trainingRunJavaToolOptions string) SpringPerformance {
+// PerformanceResult describes the optimizations applied by a contribution of SpringPerformance.
+type PerformanceResult struct {
+
+	// ArchivePath is the path of the CDS archive created by the training run, empty if none was created.
+	ArchivePath string
+
+	// ArchiveSize is the size in bytes of the CDS archive.
+	ArchiveSize int64
+
+	// TrainingDuration is how long the training run took, zero if it was not performed.
+	TrainingDuration time.Duration
+
+	// AOTApplied is whether the Spring AOT classes are enabled at launch.
+	AOTApplied bool
+
+	// CDSApplied is whether the CDS archive is enabled at launch.
+	CDSApplied bool
+
+	// JDKVersion is the version of the JDK that performed the training run, empty if it could not be determined.
+	JDKVersion string
+
+	// JDKVendor is the vendor of the JDK that performed the training run, empty if it could not be determined.
+	JDKVendor string
+
+	// TrainingBeanCount is the number of beans of the context refreshed by the training run, zero if its output did not
+	// describe the bean factory.
+	TrainingBeanCount int
+
+	// ArchiveRegions are the regions of the CDS archives mapped by the JVM at launch, parsed only if
+	// BP_JVM_CDS_REPORT_REGIONS is set.
+	ArchiveRegions ArchiveRegions
+
+	// TrainingOutput is the stdout and stderr of the training run, captured only if CaptureTrainingOutput is set as it
+	// is kept in memory.
+	TrainingOutput string
+}
+
+// PerformanceLaunchArguments returns the JVM arguments enabling at launch the optimizations applied at build time: the
+// CDS archive created by the training run at archiveFile and the Spring AOT classes.
+func PerformanceLaunchArguments(aotEnabled bool, trainingRun bool, archiveFile string) []string {
+	var arguments []string
+	if trainingRun {
+		arguments = append(arguments, fmt.Sprintf("-XX:SharedArchiveFile=%s", archiveFile))
+	}
+	if aotEnabled {
+		arguments = append(arguments, "-Dspring.aot.enabled=true")
+	}
+	return arguments
+}
+
+// LaunchProfile returns a profile.d script adding arguments to $JAVA_TOOL_OPTIONS at launch. Arguments already in
+// $JAVA_TOOL_OPTIONS, such as the ones contributed by the helper, are not added twice.
+func LaunchProfile(arguments []string) string {
+	return fmt.Sprintf(`for option in %s; do
+  case " ${JAVA_TOOL_OPTIONS} " in
+    *" ${option} "*) ;;
+    *) JAVA_TOOL_OPTIONS="${JAVA_TOOL_OPTIONS:+${JAVA_TOOL_OPTIONS} }${option}" ;;
+  esac
+done
+export JAVA_TOOL_OPTIONS
+`, strings.Join(arguments, " "))
+}
+
+func NewSpringPerformance(cache libpak.DependencyCache, appPath string, manifest *properties.Properties, aotEnabled bool, doTrainingRun bool, classpathString string, config PerformanceConfig) SpringPerformance {
 	contributor := libpak.NewLayerContributor("Performance", cache, libcnb.LayerTypes{
 		Build:  true,
+		Cache:  config.CacheArchive,
 		Launch: true,
 	})
 	return SpringPerformance{
 		LayerContributor:           contributor,
 		Executor:                   effect.NewExecutor(),
+		Metrics:                    NoopMetricsSink{},
 		AppPath:                    appPath,
 		Manifest:                   manifest,
 		AotEnabled:                 aotEnabled,
 		DoTrainingRun:              doTrainingRun,
-		TrainingRunJavaToolOptions: trainingRunJavaToolOptions,
+		TrainingRunJavaToolOptions: config.TrainingRunJavaToolOptions(),
+		Config:                     config,
 		ClasspathString:            classpathString,
-		ReZip:                      reZip,
+		ReZip:                      config.ReZip.ReZip(appPath),
+		ArgMax:                     ArgMax(),
+		CgroupRoot:                 DefaultCgroupRoot,
+		FreeDisk:                   FreeDisk,
 	}
 }
 
 func (s SpringPerformance) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	layer, _, err := s.ContributeWithResult(layer)
+	return layer, err
+}
+
+// ContributeWithResult contributes the layer as Contribute does, and describes the optimizations it applied.
+func (s SpringPerformance) ContributeWithResult(layer libcnb.Layer) (libcnb.Layer, PerformanceResult, error) {
 	s.LayerContributor.Logger = s.Logger
-	layer, err := s.LayerContributor.Contribute(layer, func() (libcnb.Layer, error) {
+	if s.Metrics == nil {
+		s.Metrics = NoopMetricsSink{}
+	}
+	if s.ArgMax == 0 {
+		s.ArgMax = ArgMax()
+	}
 
-		layer.LaunchEnvironment.Default("BPL_SPRING_AOT_ENABLED", s.AotEnabled)
+	// an interrupted contribution stops its executions and fails with the error of Context, unwinding as any failed
+	// contribution does, the caller deciding how the build exits. The commands are run without a terminal, whose stdin
+	// would never reach EOF for the training run.
+	if s.Context == nil {
+		ctx, stop := InterruptContext()
+		defer stop()
+		s.Context = ctx
+	}
+	s.Executor = ContextExecutor{Context: s.Context, Executor: s.Executor}
 
-		if !s.DoTrainingRun {
+	// the temporary directories are removed once the contribution is done, interrupted or not
+	s.temp = &TempDirs{}
+	defer func() {
+		if err := s.temp.RemoveAll(); err != nil {
+			s.log().Bodyf("Unable to remove the temporary directories: %s", err)
+		}
+	}()
+
+	for _, v := range UnknownPerformanceVariables(os.Environ()) {
+		if v.Suggestion != "" {
+			s.Logger.Header(Warningf("WARNING: %s is not a known configuration, did you mean %s?", v.Name, v.Suggestion))
+		} else {
+			s.Logger.Header(Warningf("WARNING: %s is not a known configuration and will be ignored", v.Name))
+		}
+	}
+
+	// the environment is checked before anything is done, for a build that cannot create the archive to fail fast
+	if s.Config.SelfTest && s.DoTrainingRun {
+		if err := s.SelfTest(s.Executor); err != nil {
+			err = WithCategory(err, ValidationFailed)
+			s.logHint(err)
+			return libcnb.Layer{}, PerformanceResult{}, fmt.Errorf("CDS self-test failed\n%w", err)
+		}
+	}
+
+	if s.Config.CheckOnly {
+		if err := s.check(); err != nil {
+			s.logHint(err)
+			return libcnb.Layer{}, PerformanceResult{}, fmt.Errorf("spring performance check failed\n%w", err)
+		}
+		s.log().Bodyf("Spring performance check passed, the layer is not contributed as BP_SPRING_PERFORMANCE_CHECK_ONLY is set")
+		return layer, PerformanceResult{}, nil
+	}
+
+	// a resumed build must not extract the removed application again, nor repeat the training run
+	if c, ok := s.completion(layer); ok {
+		s.Logger.Headerf("%s: Reusing completed layer", s.LayerContributor.Name)
+		return s.reuse(layer, c)
+	}
+
+	// the platform restores the archive cached by a previous build with the layer, which is reset before it is contributed
+	cached, err := s.takeCachedArchive(layer)
+	if err != nil {
+		s.log().Bodyf("Ignoring cached CDS archive: %s", err)
+	}
+	var archiveKey, archiveDigest string
+	layout, err := s.takeCachedLayout(layer)
+	if err != nil {
+		s.log().Bodyf("Ignoring cached layout: %s", err)
+	}
+	var layoutKey, layoutJar, cachedLayoutDigest string
+
+	var (
+		extracted *extractionSize
+		result    PerformanceResult
+	)
+	layer, err = s.LayerContributor.Contribute(layer, func() (libcnb.Layer, error) {
+
+		// launch environment is only contributed for the optimizations actually applied
+		if s.AotEnabled {
+			s.Config.LaunchEnvAliases.Default(layer.LaunchEnvironment, "BPL_SPRING_AOT_ENABLED", true)
+			result.AOTApplied = true
+		}
+
+		if !s.DoTrainingRun && !s.GenerateAOT {
+			s.checkNativeHints()
 			return layer, nil
 		}
 
-		layer.LaunchEnvironment.Default("BPL_JVM_CDS_ENABLED", s.DoTrainingRun)
+		// the AOT generation, the re-zip, the extraction and the training run modify the application, serialize
+		// concurrent contributions
+		unlock, err := LockAppPath(s.AppPath)
+		if err != nil {
+			return layer, fmt.Errorf("error locking %s\n%w", s.AppPath, err)
+		}
+		defer func() {
+			if err := unlock(); err != nil {
+				s.log().Bodyf("Unable to release the lock of %s: %s", s.AppPath, err)
+			}
+		}()
+
+		// the AOT classes are generated into the application, before it is re-zipped and used by the training run
+		if s.GenerateAOT {
+			if err := s.generateAOT(); err != nil {
+				return layer, WithCategory(fmt.Errorf("error generating AOT classes\n%w", err), executionCategory(err, ValidationFailed))
+			}
+		}
+		s.checkNativeHints()
+
+		if !s.DoTrainingRun {
+			return layer, nil
+		}
 
 		// prepare the training run JVM opts
 		var trainingRunArgs []string
@@ -85,101 +278,1543 @@ func (s SpringPerformance) Contribute(layer libcnb.Layer) (libcnb.Layer, error)
 			trainingRunArgs = append(trainingRunArgs, "-Dspring.aot.enabled=true")
 		}
 
+		// the entrypoint and the script may live in the application, resolve them before the application is re-zipped
+		entrypoint, err := s.layerExecutable(layer, s.Config.TrainingEntrypoint)
+		if err != nil {
+			return layer, WithCategory(fmt.Errorf("error resolving BP_JVM_CDS_TRAINING_ENTRYPOINT\n%w", err), ValidationFailed)
+		}
+		postExtractScript, err := s.layerExecutable(layer, s.Config.PostExtractScript)
+		if err != nil {
+			return layer, WithCategory(fmt.Errorf("error resolving BP_JVM_CDS_POST_EXTRACT_SCRIPT\n%w", err), ValidationFailed)
+		}
+		classList, err := s.layerFile(layer, s.Config.ClassList)
+		if err != nil {
+			return layer, WithCategory(fmt.Errorf("error resolving BP_JVM_CDS_CLASSLIST\n%w", err), ValidationFailed)
+		}
+		stdinFile, err := s.layerFile(layer, s.Config.TrainingStdin)
+		if err != nil {
+			return layer, WithCategory(fmt.Errorf("error resolving BP_JVM_CDS_TRAINING_STDIN\n%w", err), ValidationFailed)
+		}
+
+		// the layout is validated before the application is modified
+		if err := ValidateApplicationLayout(s.AppPath, s.ReZip); err != nil {
+			return layer, WithCategory(fmt.Errorf("invalid application layout\n%w", err), ValidationFailed)
+		}
+		s.checkLazyInitialization()
+
+		// the re-zip, the extraction and the archive need disk space, fail before rather than midway with ENOSPC
+		if err := s.checkFreeDisk(); err != nil {
+			return layer, WithCategory(err, ValidationFailed)
+		}
+
+		// an archive bundled with the application replaces the training run, it is kept as the application is re-zipped
+		bundledArchive, err := s.bundledArchive(layer)
+		if err != nil {
+			return layer, fmt.Errorf("error resolving bundled CDS archive\n%w", err)
+		}
+
+		// the cached archive is only used for the application it was trained on, before it is modified
+		var appDigest string
+		if s.Config.CacheArchive {
+			// the entries of a jar have the digests of the files of the application it was packed from
+			var (
+				contents map[string]string
+				err      error
+			)
+			if s.applicationJar() {
+				contents, err = JarDigests(s.AppPath)
+			} else {
+				contents, err = ContentDigests(filepath.Clean(s.AppPath) + string(filepath.Separator))
+			}
+			if err != nil {
+				return layer, fmt.Errorf("error computing application digests\n%w", err)
+			}
+			appDigest = ContentsDigest(contents)
+		}
+
 		jarPath := s.AppPath
+		var (
+			timings  phaseTimings
+			sequence phases
+		)
+
+		// the classpath index of the application is read before it is removed by the re-zip
+		libs, err := s.classpathIndex()
+		if err != nil {
+			return layer, err
+		}
+
+		// the original jar is kept before the manifest is completed and the application is removed by the re-zip
+		if s.Config.KeepOriginalJar {
+			original, err := s.keepOriginalJar(layer)
+			if err != nil {
+				return layer, fmt.Errorf("error keeping original jar\n%w", err)
+			}
+			s.log().Bodyf("Kept the original application jar as %s", original)
+		}
+
+		// the layout extracted by a previous build of the same application replaces the re-zip and the extraction
+		restored := false
+		if s.Config.CacheLayout && s.ReZip && appDigest != "" {
+			layoutKey = ArchiveCacheKey(appDigest, "", []string{s.Config.JarMode, s.Config.PostExtractScript})
+		}
+		if layout != nil && layoutKey != "" && layout.key == layoutKey {
+			start := time.Now()
+			if jarPath, err = s.restoreLayout(layer, layout); err != nil {
+				return layer, fmt.Errorf("error restoring the cached layout\n%w", err)
+			}
+			restored = true
+			s.log().Bodyf("Skipping the extraction, using the layout cached by a previous build")
+			timings.record("layout restore", start)
+		} else if layout != nil {
+			s.log().Bodyf("Ignoring cached layout, it was extracted from another application or configuration")
+		}
 
-		if s.ReZip {
-			jarDestDir := os.TempDir() + "/" + fmt.Sprint(time.Now().UnixMilli()) + "/jar-dest"
-			if err := os.MkdirAll(jarDestDir, 0755); err != nil {
+		if s.ReZip && !restored {
+			start := time.Now()
+			jarDestDir, err := s.temp.MkdirTemp("spring-rezip")
+			if err != nil {
 				return layer, fmt.Errorf("error creating temp directory for jar\n%w", err)
 			}
-			tempJarPath := filepath.Join(jarDestDir, "runner.jar")
-			if err := crush.CreateJar(s.AppPath+"/", tempJarPath); err != nil {
-				return layer, fmt.Errorf("error recreating jar\n%w", err)
+			archiveName := ApplicationArchiveName(s.AppPath, s.Manifest)
+			tempJarPath := filepath.Join(jarDestDir, archiveName)
+			if s.applicationJar() {
+				// the jar is complete, it is used as is rather than packed again
+				s.log().Bodyf("Using the application jar %s as is, without re-zipping it", s.AppPath)
+				if err := copyJar(s.AppPath, tempJarPath); err != nil {
+					return layer, fmt.Errorf("error copying jar\n%w", err)
+				}
+				if err := sequence.to(phaseReZipped, phaseStarted); err != nil {
+					return layer, err
+				}
+			} else if err := s.reZipApplication(tempJarPath, &sequence); err != nil {
+				return layer, err
 			}
 			f, err := os.Open(tempJarPath)
 			if err != nil {
 				return layer, fmt.Errorf("error opening jar\n%w", err)
 			}
-			if err = sherpa.CopyFile(f, filepath.Join(layer.Path, "runner.jar")); err != nil {
+			if err = sherpa.CopyFile(f, filepath.Join(layer.Path, archiveName)); err != nil {
 				return layer, fmt.Errorf("error copying jar\n%w", err)
 			}
 
 			jarPath = tempJarPath
-			os.RemoveAll(s.AppPath)
+			if err := sequence.to(phaseRemoved, phaseReZipped); err != nil {
+				return layer, err
+			}
+			if err := RemoveApplication(s.AppPath); err != nil {
+				return layer, fmt.Errorf("error removing exploded jar\n%w", err)
+			}
+			if err := os.MkdirAll(s.AppPath, 0755); err != nil {
+				return layer, fmt.Errorf("error recreating %s\n%w", s.AppPath, err)
+			}
+			timings.record("re-zip", start)
+		}
+
+		javaCommand := s.Config.JavaCommand()
+
+		start := time.Now()
+		if !restored {
+			// the extracted layout replaces the application, which must have been removed once re-zipped
+			if err := sequence.to(phaseExtracted, phaseRemoved); err != nil {
+				return layer, err
+			}
+			s.Metrics.RecordEvent("extraction.start")
+			if err := s.springBootJarCDSLayoutExtract(javaCommand, jarPath); err != nil {
+				return layer, WithCategory(fmt.Errorf("error extracting Boot jar at %s\n%w", jarPath, err), executionCategory(err, ExtractFailed))
+			}
+			s.Metrics.RecordEvent("extraction.end")
+			timings.record("extraction", start)
 		}
 
-		javaCommand := "java"
-		jreHome := sherpa.GetEnvWithDefault("JRE_HOME", sherpa.GetEnvWithDefault("JAVA_HOME", ""))
-		if jreHome != "" {
-			javaCommand = jreHome + "/bin/java"
+		entries, size, err := ExtractedSize(s.AppPath)
+		if err != nil {
+			return layer, fmt.Errorf("error measuring extraction of %s\n%w", jarPath, err)
+		}
+		s.log().Bodyf("Extracted %d files, %d bytes", entries, size)
+		if limit := s.Config.MaxExtractBytes; limit > 0 && size > limit {
+			return layer, WithCategory(fmt.Errorf("extracted layout of %s is %d bytes, more than BP_JVM_CDS_MAX_EXTRACT_BYTES %d", jarPath, size, limit), ExtractFailed)
 		}
+		extracted = &extractionSize{entries: entries, bytes: size}
 
-		if err := s.springBootJarCDSLayoutExtract(javaCommand, jarPath); err != nil {
-			return layer, fmt.Errorf("error extracting Boot jar at %s\n%w", jarPath, err)
+		if s.Config.VerifyExtraction {
+			start = time.Now()
+			extractedJarPath := filepath.Join(s.AppPath, filepath.Base(jarPath))
+			if err := VerifyExtraction(jarPath, extractedJarPath); err != nil {
+				return layer, WithCategory(fmt.Errorf("error verifying extraction of %s\n%w", jarPath, err), ExtractFailed)
+			}
+			s.log().Bodyf("Verified extracted classes of %s", extractedJarPath)
+			timings.record("extraction verification", start)
 		}
-		startClassValue, _ := s.Manifest.Get("Start-Class")
 
-		if err := fs.WalkDir(os.DirFS(s.AppPath), ".", func(path string, d fs.DirEntry, err error) error {
-			if baseTime, err := time.Parse(time.DateTime, "1980-01-01 00:00:01"); err != nil {
-				return fmt.Errorf("error parsing date-time\n%w", err)
-			} else if err := os.Chtimes(path, baseTime, baseTime); err != nil {
-				return fmt.Errorf("error resetting file times\n%w", err)
+		// the cached layout is the one modified by the script
+		if postExtractScript != "" && !restored {
+			start = time.Now()
+			s.log().Bodyf("Running post-extraction script %s", postExtractScript)
+			if err := s.Executor.Execute(effect.Execution{
+				Command: postExtractScript,
+				Dir:     s.AppPath,
+				Env:     s.Config.JavaEnv(nil),
+				Stdout:  s.log().InfoWriter(),
+				Stderr:  s.log().InfoWriter(),
+			}); err != nil {
+				return layer, fmt.Errorf("error running post-extraction script %s\n%w", postExtractScript, err)
 			}
-			return nil
-		}); err != nil {
-			return libcnb.Layer{}, err
+			timings.record("post-extraction script", start)
 		}
 
-		trainingRunArgs = append(trainingRunArgs,
-			"-Dspring.context.exit=onRefresh",
-			"-XX:ArchiveClassesAtExit=application.jsa",
-			"-cp",
-		)
-		trainingRunArgs = append(trainingRunArgs, s.ClasspathString)
+		// the layout is cached once complete, the next build of the same application restores it rather than extracting it
+		if layoutKey != "" {
+			if cachedLayoutDigest, err = cacheLayout(layer, s.AppPath); err != nil {
+				return layer, fmt.Errorf("error caching the extracted layout\n%w", err)
+			}
+			layoutJar = filepath.Base(jarPath)
+			s.log().Bodyf("Cached the extracted layout, digest sha256:%s", cachedLayoutDigest)
+		}
+
+		if s.Config.WriteFileList {
+			if err := s.writeFileList(layer); err != nil {
+				return layer, err
+			}
+		}
+
+		startClassValue, _, err := ManifestValue(s.Manifest, "Start-Class")
+		if err != nil {
+			return layer, WithCategory(fmt.Errorf("invalid application manifest\n%w", err), ValidationFailed)
+		}
+
+		classpath, err := s.extractedClasspath(filepath.Base(jarPath), libs)
+		if err != nil {
+			return layer, fmt.Errorf("error computing training run classpath\n%w", err)
+		}
+		if !s.Config.TrainingIncludeLoader {
+			classpath = ExcludeLoader(classpath)
+		}
+		// CDS does not noticeably improve the startup of an application loading few classes, it is not worth the build
+		// time and the size of the archive
+		if minimum := s.Config.MinAppClasses; minimum > 0 && bundledArchive == "" {
+			count, err := CountClasses(s.AppPath, classpath)
+			if err != nil {
+				return layer, fmt.Errorf("error counting the classes of the training run classpath\n%w", err)
+			}
+			if count < minimum {
+				s.log().Bodyf("Skipping the training run, the application has %d classes, fewer than BP_JVM_CDS_MIN_APP_CLASSES %d, CDS would not noticeably improve its startup", count, minimum)
+				return layer, nil
+			}
+			s.log().Debugf("Training run classpath has %d classes, at least BP_JVM_CDS_MIN_APP_CLASSES %d", count, minimum)
+		}
+		prepend, err := s.trainingClasspathEntries("BP_JVM_CDS_CLASSPATH_PREPEND", s.Config.ClasspathPrepend)
+		if err != nil {
+			return layer, WithCategory(err, ValidationFailed)
+		} else if len(prepend) > 0 {
+			// the JVM only maps the archive if the classpath at launch starts with the one of the training run
+			s.log().Bodyf("Classpath at launch will start with the BP_JVM_CDS_CLASSPATH_PREPEND entries too, for the CDS archive to be used")
+		}
+		appended, err := s.trainingClasspathEntries("BP_JVM_CDS_CLASSPATH_APPEND", s.Config.ClasspathAppend)
+		if err != nil {
+			return layer, WithCategory(err, ValidationFailed)
+		}
+		if s.log().IsVerbose() {
+			s.checkDuplicates(classpath)
+		}
+
+		start = time.Now()
+		if err := ResetTimestamps(s.AppPath, s.Config.Timestamp()); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("error resetting file times\n%w", err)
+		}
+		timings.record("timestamp reset", start)
+
+		if baseArchive := s.Config.BaseArchive; baseArchive != "" {
+			if err := ValidateBaseArchive(baseArchive); err != nil {
+				s.log().Bodyf("Ignoring BP_JVM_CDS_BASE_ARCHIVE, the archive will be generated from scratch: %s", err)
+			} else {
+				s.log().Bodyf("Training run will layer application.jsa on top of base archive %s", baseArchive)
+				trainingRunArgs = append(trainingRunArgs, fmt.Sprintf("-XX:SharedArchiveFile=%s", baseArchive))
+			}
+		}
+
+		if classList != "" {
+			s.log().Bodyf("Training run will archive the classes listed in %s", classList)
+			trainingRunArgs = append(trainingRunArgs, fmt.Sprintf("-XX:SharedClassListFile=%s", classList))
+		}
+
+		if s.Config.TrainingAssertions {
+			trainingRunArgs = append(trainingRunArgs, "-ea")
+		}
+
+		if profiles := s.Config.TrainingProfiles; len(profiles) > 0 {
+			s.log().Bodyf("Training run will activate the profiles %s", strings.Join(profiles, ", "))
+			trainingRunArgs = append(trainingRunArgs, fmt.Sprintf("-Dspring.profiles.active=%s", strings.Join(profiles, ",")))
+		}
+
+		// the database of the application may not be reachable at build time, the refresh connects to an in-memory one
+		if s.Config.TrainingDataSource == EmbeddedDataSource {
+			if !HasH2Database(slices.Concat(prepend, classpath, appended)) {
+				return layer, WithCategory(fmt.Errorf("BP_JVM_CDS_TRAINING_DATASOURCE=%s needs the H2 database on the training run classpath, add it to the application or to BP_JVM_CDS_CLASSPATH_APPEND", EmbeddedDataSource), ValidationFailed)
+			}
+			s.log().Bodyf("Training run will connect the datasource to the in-memory database %s", EmbeddedDataSourceURL)
+			trainingRunArgs = append(trainingRunArgs, EmbeddedDataSourceArgs()...)
+		}
+
+		if s.Config.TrainingJFR {
+			debugDir, err := s.debugDir(layer)
+			if err != nil {
+				return layer, err
+			}
+			// the recording is dumped when the context refresh exits the JVM, after the CDS archive has been written
+			recording := filepath.Join(debugDir, "training.jfr")
+			s.log().Bodyf("Training run will record a flight recording to %s", recording)
+			trainingRunArgs = append(trainingRunArgs, fmt.Sprintf("-XX:StartFlightRecording=filename=%s,dumponexit=true", recording))
+		}
+
+		if s.Config.TrainingDebug {
+			debugDir, err := s.debugDir(layer)
+			if err != nil {
+				return layer, err
+			}
+			// the CDS diagnostics are verbose, they are logged to a file rather than to the build output
+			cdsLog := filepath.Join(debugDir, "cds.log")
+			s.log().Bodyf("Training run will log CDS diagnostics to %s", cdsLog)
+			trainingRunArgs = append(trainingRunArgs, fmt.Sprintf("-Xlog:cds*=debug:file=%s", cdsLog))
+		}
+
+		if s.Config.TrainingHeapDump {
+			debugDir, err := s.debugDir(layer)
+			if err != nil {
+				return layer, err
+			}
+			s.log().Bodyf("Training run will dump the heap to %s on OutOfMemoryError", debugDir)
+			trainingRunArgs = append(trainingRunArgs, "-XX:+HeapDumpOnOutOfMemoryError", fmt.Sprintf("-XX:HeapDumpPath=%s", debugDir))
+		}
+
+		if cpus, err := s.trainingCPUs(); err != nil {
+			s.log().Bodyf("Unable to determine the CPU limit of the training run: %s", err)
+		} else if cpus > 0 {
+			s.log().Bodyf("Training run will use %d CPUs", cpus)
+			trainingRunArgs = append(trainingRunArgs, fmt.Sprintf("-XX:ActiveProcessorCount=%d", cpus))
+		}
+
+		trainingDir, err := s.trainingDir()
+		if err != nil {
+			return layer, WithCategory(fmt.Errorf("error resolving BP_JVM_CDS_TRAINING_DIR\n%w", err), ValidationFailed)
+		}
+
+		archiveArg := s.Config.ArchiveFile()
+		archive := s.archivePath()
+		if s.Config.ArchiveDir != "" {
+			if err := os.MkdirAll(filepath.Dir(archive), 0755); err != nil {
+				return layer, fmt.Errorf("unable to create BP_JVM_CDS_ARCHIVE_DIR %s\n%w", filepath.Dir(archive), err)
+			}
+			s.log().Bodyf("Training run will write the CDS archive to %s", archive)
+		}
+
+		// the launch at runtime uses the classpath relative to the application, whatever the working directory, after the
+		// BP_JVM_CDS_CLASSPATH_PREPEND entries the processes start with
+		launchClasspath := slices.Concat(prepend, classpath)
+		if trainingDir != s.AppPath {
+			// keep the archive and the classpath relative to the application rather than the working directory
+			s.log().Bodyf("Training run will use %s as working directory", trainingDir)
+			archiveArg = archive
+			for i, entry := range classpath {
+				if !filepath.IsAbs(entry) {
+					classpath[i] = filepath.Join(s.AppPath, entry)
+				}
+			}
+		}
+
+		// the archive may be dumped to a faster file system, and copied to its location once the training run is done
+		dumpArg, dump := archiveArg, archive
+		if tmpDir := s.Config.ArchiveTmpDir; tmpDir != "" {
+			if err := os.MkdirAll(tmpDir, 0755); err != nil {
+				return layer, fmt.Errorf("unable to create BP_JVM_CDS_ARCHIVE_TMPDIR %s\n%w", tmpDir, err)
+			}
+			if dump, err = filepath.Abs(filepath.Join(tmpDir, filepath.Base(archive))); err != nil {
+				return layer, fmt.Errorf("unable to resolve BP_JVM_CDS_ARCHIVE_TMPDIR %s\n%w", tmpDir, err)
+			}
+			dumpArg = dump
+			s.log().Bodyf("Training run will dump the CDS archive to %s", dump)
+		}
+
+		// the launches without the options specific to the training run, used to benchmark the archive
+		var launchArgs []string
+		if s.AotEnabled {
+			launchArgs = append(launchArgs, "-Dspring.aot.enabled=true")
+		}
+		// the benchmarked launches refresh the application context, which needs a datasource as well
+		if s.Config.TrainingDataSource == EmbeddedDataSource {
+			launchArgs = append(launchArgs, EmbeddedDataSourceArgs()...)
+		}
+		launchArgs = append(launchArgs, "-Dspring.context.exit=onRefresh", "-cp", strings.Join(classpath, string(filepath.ListSeparator)), startClassValue)
+
+		// the harness closes the context of each iteration and exits the JVM, the context must not exit it on refresh
+		var harnessArgs []string
+		switch iterations := s.Config.WarmupIterations; {
+		case iterations > 1:
+			harness, err := s.writeWarmupHarness(layer)
+			if err != nil {
+				return layer, err
+			}
+			harnessArgs = []string{harness, strconv.Itoa(iterations)}
+			s.log().Bodyf("Training run will refresh the application context %d times", iterations)
+		case filepath.Ext(jarPath) == ".war" && EmbeddedTomcat(classpath):
+			// the embedded server does not exit on refresh, the driver stops it once warm on a port free at build time
+			driver, err := s.writeWarShutdownDriver(layer)
+			if err != nil {
+				return layer, err
+			}
+			harnessArgs = []string{driver}
+			trainingRunArgs = append(trainingRunArgs, "-Dserver.port=0")
+			s.log().Bodyf("Training run will start the embedded Tomcat of the war and stop it once the application is ready")
+		case s.Config.ShutdownTimeout > 0:
+			// the context exits on refresh, the watchdog reports, or ends, a shutdown blocked by a hook of the application
+			watchdog, err := s.writeShutdownWatchdog(layer)
+			if err != nil {
+				return layer, err
+			}
+			harnessArgs = []string{watchdog, strconv.FormatInt(s.Config.ShutdownTimeout.Milliseconds(), 10), strconv.FormatBool(s.Config.ForceDump)}
+			trainingRunArgs = append(trainingRunArgs, "-Dspring.context.exit=onRefresh")
+			if s.Config.ForceDump {
+				s.log().Bodyf("Training run will halt the JVM to dump the CDS archive if its shutdown takes longer than %s", s.Config.ShutdownTimeout)
+			} else {
+				s.log().Bodyf("Training run will report a shutdown taking longer than %s", s.Config.ShutdownTimeout)
+			}
+		default:
+			trainingRunArgs = append(trainingRunArgs, "-Dspring.context.exit=onRefresh")
+		}
+		if s.Config.ShutdownTimeout > 0 && filepath.Base(harnessArgs[0]) != ShutdownWatchdogName {
+			s.log().Bodyf("Training run will not watch its shutdown, BP_JVM_CDS_SHUTDOWN_TIMEOUT does not apply to %s", filepath.Base(harnessArgs[0]))
+		}
+		// a format dumping the archive once the training run is done needs the classes it loaded
+		format := s.Config.Format()
+		loadedClasses := filepath.Join(layer.Path, "training", "loaded-classes.lst")
+		trainingRunArgs = append(trainingRunArgs, format.TrainingArgs(dumpArg, loadedClasses)...)
+		trainingRunArgs = append(trainingRunArgs, "-cp")
+		// the BP_JVM_CDS_CLASSPATH_APPEND entries are only on the classpath of the training run, not of the launches
+		trainingEntries := slices.Concat(prepend, classpath, appended)
+		trainingClasspath := strings.Join(trainingEntries, string(filepath.ListSeparator))
+		trainingRunArgs = append(trainingRunArgs, trainingClasspath)
+		trainingRunArgs = append(trainingRunArgs, harnessArgs...)
 		trainingRunArgs = append(trainingRunArgs, startClassValue)
 
 		var trainingRunEnvVariables []string
 
 		if s.TrainingRunJavaToolOptions != "" {
-			s.Logger.Bodyf("Training run will use this value as JAVA_TOOL_OPTIONS: %s", s.TrainingRunJavaToolOptions)
+			s.log().Bodyf("Training run will use this value as JAVA_TOOL_OPTIONS: %s", s.TrainingRunJavaToolOptions)
 			trainingRunEnvVariables = append(trainingRunEnvVariables, fmt.Sprintf("JAVA_TOOL_OPTIONS=%s", s.TrainingRunJavaToolOptions))
 		}
 
+		// only the names are logged, the values may be secrets
+		trainingEnvOptions := map[string]string{}
+		for _, name := range s.Config.TrainingEnv {
+			if value, ok := os.LookupEnv(name); ok {
+				s.log().Bodyf("Training run will be passed %s", name)
+				trainingRunEnvVariables = append(trainingRunEnvVariables, fmt.Sprintf("%s=%s", name, value))
+				if strings.HasSuffix(name, "_OPTIONS") {
+					trainingEnvOptions[name] = value
+				}
+			} else {
+				s.log().Bodyf("Training run will not be passed %s, it is not set", name)
+			}
+		}
+
+		// the customizer gets the last word on the arguments, before they are validated and executed
+		if s.ArgsCustomizer != nil {
+			trainingRunArgs = s.ArgsCustomizer(trainingRunArgs)
+		}
+
+		// the flags reaching the JVM are reviewed in a single place, the ones of its environment included
+		if err := s.validateTrainingRunFlags(trainingRunArgs, trainingEnvOptions); err != nil {
+			return libcnb.Layer{}, WithCategory(fmt.Errorf("invalid training run arguments\n%w", err), ValidationFailed)
+		}
+
+		trainingRunCommand := javaCommand
+		if entrypoint != "" {
+			s.log().Bodyf("Training run will be performed by the entrypoint %s", entrypoint)
+			// the arguments are only passed as arguments, a single variable could not keep the ones containing spaces
+			trainingRunEnvVariables = append(append(os.Environ(), trainingRunEnvVariables...),
+				fmt.Sprintf("CDS_TRAINING_JAVA=%s", javaCommand),
+			)
+			trainingRunCommand = entrypoint
+		}
+		// the sandbox wraps the command actually run, the entrypoint included
+		sandbox := s.trainingSandbox()
+		sandboxed := Sandboxed(sandbox, effect.Execution{Command: trainingRunCommand, Args: trainingRunArgs})
+
+		trainingRunEnvVariables = s.Config.JavaEnv(trainingRunEnvVariables)
+
+		// an empty environment is replaced by the one of the buildpack
+		effectiveEnv := trainingRunEnvVariables
+		if len(effectiveEnv) == 0 {
+			effectiveEnv = os.Environ()
+		}
+		if err := ValidateCommandLine(sandboxed.Command, sandboxed.Args, effectiveEnv, s.ArgMax); err != nil {
+			return libcnb.Layer{}, WithCategory(fmt.Errorf("unable to perform the training run, reduce the size of the classpath or of the environment\n%w", err), ValidationFailed)
+		}
+
+		// the configuration is resolved once the command line of the training run is
+		if s.Config.WriteConfig {
+			if err := s.writeResolvedConfig(layer, startClassValue, trainingEntries, javaCommand); err != nil {
+				return layer, err
+			}
+		}
+
 		// perform the training run, application.dsa, the cache file, will be created
-		if err := s.Executor.Execute(effect.Execution{
-			Command: javaCommand,
-			Env:     trainingRunEnvVariables,
-			Args:    trainingRunArgs,
-			Dir:     s.AppPath,
-			Stdout:  s.Logger.InfoWriter(),
-			Stderr:  s.Logger.InfoWriter(),
+		// the output is streamed to the build log, its tail is kept to be reported if the training run fails
+		tail := NewOutputTail(DefaultOutputTailLines)
+		beans := &BeanCounter{}
+		var writers []io.Writer
+		if w := s.log().InfoWriter(); w != nil {
+			writers = append(writers, w)
+		}
+		watch := &ShutdownWatch{}
+		writers = append(writers, tail, beans, watch)
+		captured := &bytes.Buffer{}
+		if s.CaptureTrainingOutput {
+			writers = append(writers, captured)
+		}
+		output := io.MultiWriter(writers...)
+		// an application reading stdin gets EOF rather than blocking the build
+		var stdin io.Reader = strings.NewReader("")
+		if stdinFile != "" {
+			f, err := os.Open(stdinFile)
+			if err != nil {
+				return layer, fmt.Errorf("unable to open BP_JVM_CDS_TRAINING_STDIN %s\n%w", stdinFile, err)
+			}
+			defer f.Close()
+			s.log().Bodyf("Training run will read stdin from %s", stdinFile)
+			stdin = f
+		}
+		if s.Config.CacheArchive && bundledArchive == "" {
+			if jdk, err := s.trainingJDK(javaCommand); err != nil {
+				s.log().Bodyf("Not caching the CDS archive, unable to determine the training run JDK: %s", err)
+			} else {
+				archiveKey = ArchiveCacheKey(appDigest, jdk.Version, slices.Concat(trainingRunArgs, []string{s.TrainingRunJavaToolOptions}, profileSetsKey(s.Config.TrainingProfileSets)))
+			}
+		}
+
+		if bundledArchive != "" {
+			s.log().Bodyf("Skipping the training run, using the CDS archive bundled with the application")
+			if err := moveArchive(bundledArchive, dump); err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to copy the bundled CDS archive\n%w", err)
+			}
+		} else if cached != nil && archiveKey != "" && cached.key == archiveKey {
+			s.log().Bodyf("Skipping the training run, using the CDS archive cached by a previous build")
+			if err := moveArchive(cached.path, dump); err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to copy the cached CDS archive\n%w", err)
+			}
+		} else {
+			start = time.Now()
+			s.Metrics.RecordEvent("training-run.start")
+			execute := func(args []string) error {
+				sandboxed := Sandboxed(sandbox, effect.Execution{Command: trainingRunCommand, Args: args})
+				s.log().Debugf("Running %s %s", sandboxed.Command, strings.Join(sandboxed.Args, " "))
+				return s.Executor.Execute(effect.Execution{
+					Command: sandboxed.Command,
+					Env:     trainingRunEnvVariables,
+					Args:    sandboxed.Args,
+					Dir:     trainingDir,
+					Stdin:   stdin,
+					Stdout:  output,
+					Stderr:  output,
+				})
+			}
+
+			if len(s.Config.TrainingProfileSets) > 0 {
+				err = s.trainingRunProfileSets(layer, trainingRunArgs, classList, trainingClasspath, dumpArg, execute)
+			} else {
+				err = s.trainingRunFormat(format, trainingRunArgs, classList, loadedClasses, trainingClasspath, dumpArg, execute)
+			}
+			// the output is returned whether the training run failed or not, to be inspected
+			if s.CaptureTrainingOutput {
+				result.TrainingOutput = captured.String()
+			}
+			if slow, halted := watch.Result(); halted {
+				s.Logger.Header(Warningf("WARNING: the shutdown of the training run took longer than BP_JVM_CDS_SHUTDOWN_TIMEOUT %s, the JVM was halted to dump the CDS archive, ensure the shutdown hooks of the application complete", s.Config.ShutdownTimeout))
+			} else if slow {
+				s.Logger.Header(Warningf("WARNING: the shutdown of the training run took longer than BP_JVM_CDS_SHUTDOWN_TIMEOUT %s, a blocked shutdown hook of the application prevents the CDS archive from being dumped, set BP_JVM_CDS_FORCE_DUMP=true to halt the JVM once it elapsed", s.Config.ShutdownTimeout))
+			}
+			if err != nil {
+				if out := tail.String(); out != "" {
+					err = fmt.Errorf("training run output ends with:\n%s\n%w", out, err)
+				}
+				// java is run by the sandbox, which is the command not found if any
+				category := executionCategory(err, TrainingFailed)
+				if len(sandbox) > 0 {
+					category = TrainingFailed
+				}
+				err = WithCategory(WithHint(err, trainingRunHint(err, startClassValue, len(sandbox) > 0)), category)
+				if s.Config.Required || s.Context.Err() != nil {
+					return libcnb.Layer{}, fmt.Errorf("error running build\n%w", err)
+				}
+				s.Logger.Header(Warningf("WARNING: CDS training run failed, continuing without CDS as BP_JVM_CDS_REQUIRED is false: %s", err))
+				s.logHint(err)
+				return layer, nil
+			}
+			s.Metrics.RecordEvent("training-run.end")
+			timings.record("training run", start)
+			result.TrainingDuration = timings[len(timings)-1].duration
+
+			// a low count may reveal a refresh that did not create the beans exercised at runtime
+			if count, ok := beans.Count(); ok {
+				s.log().Bodyf("Training run refreshed a context of %d beans", count)
+				result.TrainingBeanCount = count
+			}
+
+			// the archive may still be written once the application context exited
+			if stable, err := WaitForArchive(dump, s.Config.DumpGrace, dumpPollInterval); err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to wait for the CDS archive\n%w", err)
+			} else if !stable {
+				s.log().Bodyf("CDS archive %s is missing or still being written after BP_JVM_CDS_DUMP_GRACE %s", dump, s.Config.DumpGrace)
+			}
+		}
+
+		if dump != archive {
+			if err := moveArchive(dump, archive); err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to copy the CDS archive from BP_JVM_CDS_ARCHIVE_TMPDIR\n%w", err)
+			}
+		}
+
+		if info, err := os.Stat(archive); err == nil {
+			s.Metrics.RecordEvent("archive.created")
+			s.Metrics.RecordSize("archive", info.Size())
+			result.ArchivePath, result.ArchiveSize, result.CDSApplied = archive, info.Size(), true
+			if archiveKey != "" {
+				if archiveDigest, err = cacheArchive(layer, archive); err != nil {
+					return libcnb.Layer{}, fmt.Errorf("error caching the CDS archive\n%w", err)
+				}
+				s.log().Bodyf("Cached the CDS archive, digest sha256:%s", archiveDigest)
+			}
+			s.Config.LaunchEnvAliases.Default(layer.LaunchEnvironment, "BPL_JVM_CDS_ENABLED", true)
+			if s.Config.ArchiveFile() != DefaultArchiveName {
+				// the platform mounts the archive at the same location in the run image
+				s.Config.LaunchEnvAliases.Default(layer.LaunchEnvironment, "BPL_JVM_CDS_ARCHIVE_FILE", s.Config.ArchiveFile())
+			}
+
+			if s.Config.LaunchDir != "" {
+				if err := ValidateArchivePortability(s.AppPath, s.Config.LaunchDir); err != nil {
+					s.Logger.Header(Warningf("WARNING: CDS archive may not be portable to the run image: %s", err))
+				}
+			}
+
+			// the regions are logged by the JVM as it maps the archive, when the launch validating it does
+			if s.Config.ValidateArchive || s.Config.ReportRegions {
+				var logArgs []string
+				if s.Config.ReportRegions {
+					logArgs = ArchiveRegionLogArgs
+				}
+				output, err := s.validateArchive(javaCommand, launchClasspath, startClassValue, logArgs)
+				switch {
+				case err != nil && s.Config.ValidateArchive:
+					err = WithCategory(err, ArchiveRejected)
+					if s.Config.Required {
+						return libcnb.Layer{}, fmt.Errorf("error validating the CDS archive\n%w", err)
+					}
+					s.Logger.Header(Warningf("WARNING: CDS archive is rejected at launch, continuing without CDS as BP_JVM_CDS_REQUIRED is false: %s", err))
+					s.Config.LaunchEnvAliases.Delete(layer.LaunchEnvironment, "BPL_JVM_CDS_ENABLED")
+					result.CDSApplied = false
+				case err != nil:
+					s.log().Bodyf("Unable to report the regions of the CDS archive: %s", err)
+				default:
+					if s.Config.ValidateArchive {
+						s.log().Bodyf("Validated the CDS archive %s when launched from %s", s.Config.ArchiveFile(), s.AppPath)
+					}
+					if s.Config.ReportRegions {
+						result.ArchiveRegions = ParseArchiveRegions(output)
+						s.reportRegions(result.ArchiveRegions)
+					}
+				}
+			}
+
+			if s.Config.Benchmark && result.CDSApplied {
+				var benchmarkEnv []string
+				if s.TrainingRunJavaToolOptions != "" {
+					benchmarkEnv = []string{fmt.Sprintf("JAVA_TOOL_OPTIONS=%s", s.TrainingRunJavaToolOptions)}
+				}
+				if err := s.benchmark(effect.Execution{Command: javaCommand, Args: launchArgs, Env: s.Config.JavaEnv(benchmarkEnv), Dir: trainingDir}, archiveArg); err != nil {
+					s.Logger.Header(Warningf("WARNING: unable to benchmark the CDS archive: %s", err))
+				}
+			}
+		} else if !os.IsNotExist(err) {
+			return libcnb.Layer{}, fmt.Errorf("unable to check for CDS archive %s\n%w", archive, err)
+		} else if s.Config.Required && !s.Config.WarnMissingArchive {
+			return libcnb.Layer{}, WithCategory(fmt.Errorf("training run succeeded but did not create the CDS archive %s, ensure the application does not override -XX:ArchiveClassesAtExit", archive), ArchiveMissing)
+		} else {
+			s.Logger.Header(Warningf("WARNING: training run succeeded but did not create the CDS archive %s, CDS will not be effective at runtime", archive))
+		}
+
+		if jdk, err := s.trainingJDK(javaCommand); err != nil {
+			s.log().Bodyf("Unable to record the training run JDK in the SBOM: %s", err)
+		} else if err := jdk.WriteCycloneDX(layer.SBOMPath(libcnb.CycloneDXJSON)); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("error writing training run JDK SBOM\n%w", err)
+		} else {
+			result.JDKVersion, result.JDKVendor = jdk.Version, jdk.Vendor
+		}
+
+		for _, t := range timings {
+			s.Metrics.RecordDuration(t.name, t.duration)
+			s.log().Debugf("Phase %s took %s", t.name, t.duration.Round(time.Millisecond))
+		}
+		s.log().Bodyf("Timings: %s", timings)
+
+		// the executions failing once interrupted may only have been warned about, the layer is not complete
+		if err := s.Context.Err(); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("the contribution was interrupted\n%w", err)
+		}
+
+		if err := writeCompletion(layer.Path, completion{
+			AOTApplied:       result.AOTApplied,
+			CDSApplied:       result.CDSApplied,
+			ArchivePath:      result.ArchivePath,
+			ArchiveSize:      result.ArchiveSize,
+			ExtractedJar:     filepath.Join(s.AppPath, filepath.Base(jarPath)),
+			ExtractedEntries: extracted.entries,
+			ExtractedBytes:   extracted.bytes,
+			JDKVersion:       result.JDKVersion,
+			JDKVendor:        result.JDKVendor,
+			BeanCount:        result.TrainingBeanCount,
 		}); err != nil {
-			return libcnb.Layer{}, fmt.Errorf("error running build\n%w", err)
+			return libcnb.Layer{}, err
 		}
 
 		return layer, nil
 	})
 
 	if err != nil {
-		return libcnb.Layer{}, fmt.Errorf("unable to contribute spring-cds layer\n%w", err)
+		s.logHint(err)
+		return libcnb.Layer{}, PerformanceResult{}, fmt.Errorf("unable to contribute spring-cds layer\n%w", err)
+	}
+
+	// the platform restores the cached archive on the next build with this metadata, for the training run to be skipped
+	if archiveDigest != "" {
+		if layer.Metadata == nil {
+			layer.Metadata = map[string]interface{}{}
+		}
+		layer.Metadata[ArchiveKeyMetadata] = archiveKey
+		layer.Metadata[ArchiveDigestMetadata] = archiveDigest
+	}
+	if cachedLayoutDigest != "" {
+		if layer.Metadata == nil {
+			layer.Metadata = map[string]interface{}{}
+		}
+		layer.Metadata[LayoutKeyMetadata] = layoutKey
+		layer.Metadata[LayoutDigestMetadata] = cachedLayoutDigest
+		layer.Metadata[LayoutJarMetadata] = layoutJar
 	}
-	return layer, nil
+	return s.contributed(layer, result, extracted)
 }
 
-func (s SpringPerformance) Name() string {
-	return s.LayerContributor.Name
+// completion returns the completion marker of layer, if the training run of a previous contribution completed and its
+// results are still in place.
+func (s SpringPerformance) completion(layer libcnb.Layer) (completion, bool) {
+	if !s.DoTrainingRun {
+		return completion{}, false
+	}
+
+	c, ok, err := readCompletion(layer.Path)
+	if err != nil {
+		s.log().Bodyf("Ignoring completion marker: %s", err)
+		return completion{}, false
+	} else if !ok {
+		return completion{}, false
+	}
+
+	if err := c.validate(s.AotEnabled, s.archivePath()); err != nil {
+		s.log().Bodyf("Ignoring completion marker, the layer will be contributed again: %s", err)
+		return completion{}, false
+	}
+	return c, true
 }
 
-func (s SpringPerformance) springBootJarCDSLayoutExtract(javaCommand string, jarPath string) error {
-	s.Logger.Bodyf("Extracting Jar")
-	if err := s.Executor.Execute(effect.Execution{
-		Command: javaCommand,
-		Args:    []string{"-Djarmode=tools", "-jar", jarPath, "extract", "--destination", s.AppPath},
-		Dir:     filepath.Dir(jarPath),
-		Stdout:  s.Logger.InfoWriter(),
-		Stderr:  s.Logger.InfoWriter(),
-	}); err != nil {
-		return fmt.Errorf("error extracting Jar with jarmode\n%w", err)
+// reuse restores on layer the launch configuration of the completed contribution c.
+func (s SpringPerformance) reuse(layer libcnb.Layer, c completion) (libcnb.Layer, PerformanceResult, error) {
+	layer.LayerTypes = s.LayerContributor.ExpectedTypes
+
+	result := PerformanceResult{AOTApplied: c.AOTApplied, CDSApplied: c.CDSApplied, JDKVersion: c.JDKVersion, JDKVendor: c.JDKVendor, TrainingBeanCount: c.BeanCount}
+	if c.AOTApplied {
+		s.Config.LaunchEnvAliases.Default(layer.LaunchEnvironment, "BPL_SPRING_AOT_ENABLED", true)
 	}
-	return nil
+	if c.CDSApplied {
+		result.ArchivePath, result.ArchiveSize = c.ArchivePath, c.ArchiveSize
+		s.Config.LaunchEnvAliases.Default(layer.LaunchEnvironment, "BPL_JVM_CDS_ENABLED", true)
+		if s.Config.ArchiveFile() != DefaultArchiveName {
+			s.Config.LaunchEnvAliases.Default(layer.LaunchEnvironment, "BPL_JVM_CDS_ARCHIVE_FILE", s.Config.ArchiveFile())
+		}
+	}
+
+	return s.contributed(layer, result, &extractionSize{entries: c.ExtractedEntries, bytes: c.ExtractedBytes})
+}
+
+// contributed completes layer once contributed, with the launch profile, the extraction metadata and the bean count of
+// the training run.
+func (s SpringPerformance) contributed(layer libcnb.Layer, result PerformanceResult, extracted *extractionSize) (libcnb.Layer, PerformanceResult, error) {
+	if arguments := PerformanceLaunchArguments(result.AOTApplied, result.CDSApplied, s.launchArchiveFile()); len(arguments) > 0 {
+		if layer.Profile == nil {
+			layer.Profile = libcnb.Profile{}
+		}
+		layer.Profile.Add("spring-performance.sh", LaunchProfile(arguments))
+	}
+
+	// the layer contributor replaces the metadata with the expected one once the layer is contributed
+	if extracted != nil {
+		if layer.Metadata == nil {
+			layer.Metadata = map[string]interface{}{}
+		}
+		layer.Metadata["extracted_entries"] = extracted.entries
+		layer.Metadata["extracted_bytes"] = extracted.bytes
+	}
+	if result.TrainingBeanCount > 0 {
+		if layer.Metadata == nil {
+			layer.Metadata = map[string]interface{}{}
+		}
+		layer.Metadata[BeanCountMetadata] = result.TrainingBeanCount
+	}
+	if len(result.ArchiveRegions) > 0 {
+		if layer.Metadata == nil {
+			layer.Metadata = map[string]interface{}{}
+		}
+		layer.Metadata[ArchiveRegionsMetadata] = result.ArchiveRegions.Metadata()
+	}
+	if result.CDSApplied && s.Config.EnvTag != "" {
+		if layer.Metadata == nil {
+			layer.Metadata = map[string]interface{}{}
+		}
+		layer.Metadata[EnvTagMetadata] = s.Config.EnvTag
+	}
+
+	// the training run may have been skipped or have failed without failing the build, the state at launch is definitive
+	active := cdsActive(layer, result)
+	if layer.Metadata == nil {
+		layer.Metadata = map[string]interface{}{}
+	}
+	layer.Metadata[CDSActiveMetadata] = active
+	for k, v := range provenance(result, active) {
+		layer.Metadata[k] = v
+	}
+	if s.DoTrainingRun {
+		if active {
+			s.log().Summaryf("CDS is active at launch with the archive %s", result.ArchivePath)
+		} else {
+			s.log().Summaryf("CDS is not active at launch")
+		}
+	}
+	return layer, result, nil
+}
+
+// cdsActive returns whether the CDS archive of result is used at launch: it must exist and be enabled by the launch
+// environment of layer.
+func cdsActive(layer libcnb.Layer, result PerformanceResult) bool {
+	if !result.CDSApplied || result.ArchivePath == "" || layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"] != "true" {
+		return false
+	}
+	info, err := os.Stat(result.ArchivePath)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// extractionSize is the size of the layout extracted from the application jar.
+type extractionSize struct {
+	entries int
+	bytes   int64
+}
+
+func (s SpringPerformance) Name() string {
+	return s.LayerContributor.Name
+}
+
+// layerExecutable returns the executable at entrypoint, if any, resolved as layerFile does.
+func (s SpringPerformance) layerExecutable(layer libcnb.Layer, entrypoint string) (string, error) {
+	entrypoint, err := s.layerFile(layer, entrypoint)
+	if err != nil || entrypoint == "" {
+		return entrypoint, err
+	}
+
+	info, err := os.Stat(entrypoint)
+	if err != nil {
+		return "", fmt.Errorf("unable to stat %s\n%w", entrypoint, err)
+	}
+	if info.IsDir() || info.Mode()&0111 == 0 {
+		return "", fmt.Errorf("%s is not an executable file", entrypoint)
+	}
+	return entrypoint, nil
+}
+
+// layerFile returns the readable file at path, if any. A relative path is resolved against the application and copied
+// into the layer, as the application may be removed before the file is used.
+func (s SpringPerformance) layerFile(layer libcnb.Layer, path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+
+	source := path
+	if !filepath.IsAbs(path) {
+		source = filepath.Join(s.AppPath, path)
+	}
+	in, err := os.Open(source)
+	if err != nil {
+		return "", fmt.Errorf("unable to open %s\n%w", source, err)
+	}
+	defer in.Close()
+
+	if filepath.IsAbs(path) {
+		return path, nil
+	}
+
+	path = filepath.Join(layer.Path, "training", filepath.Base(path))
+	if err := sherpa.CopyFile(in, path); err != nil {
+		return "", fmt.Errorf("unable to copy %s to %s\n%w", source, path, err)
+	}
+	return path, nil
+}
+
+func (s SpringPerformance) classpathEntries() []string {
+	if len(s.Classpath) > 0 {
+		return s.Classpath
+	}
+	if s.ClasspathString == "" {
+		return nil
+	}
+	return filepath.SplitList(s.ClasspathString)
+}
+
+// reZipApplication packs the exploded application into jarPath, without the files matching its ReZipIgnoreFile, and
+// checks the jar against the application.
+func (s SpringPerformance) reZipApplication(jarPath string, sequence *phases) error {
+	ignore, err := ReadIgnoreFile(s.AppPath)
+	if err != nil {
+		return WithCategory(fmt.Errorf("error reading %s\n%w", ReZipIgnoreFile, err), ValidationFailed)
+	}
+	contents, err := ContentDigests(filepath.Clean(s.AppPath) + string(filepath.Separator))
+	if err != nil {
+		return fmt.Errorf("error computing application digests\n%w", err)
+	}
+	// the ignored files are not packed, the re-zipped jar is verified against the packed ones
+	if len(ignore) > 0 {
+		ignored := 0
+		for name := range contents {
+			if ignore.Ignored(name, false) {
+				delete(contents, name)
+				ignored++
+			}
+		}
+		s.log().Bodyf("Excluding %d files matching %s from the re-zipped jar", ignored, ReZipIgnoreFile)
+	}
+	if err := s.ensureLauncherMainClass(); err != nil {
+		return fmt.Errorf("error reconstructing jar manifest\n%w", err)
+	}
+	// the trailing separator makes the walk follow the application directory if it is a symbolic link
+	if err := CreateJarWithOptions(filepath.Clean(s.AppPath)+string(filepath.Separator), jarPath, s.reZipOptions(ignore)); err != nil {
+		return fmt.Errorf("error recreating jar\n%w", err)
+	}
+	if err := sequence.to(phaseReZipped, phaseStarted); err != nil {
+		return err
+	}
+	if err := s.checkReZippedJar(contents, jarPath); err != nil {
+		return fmt.Errorf("error checking re-zipped jar\n%w", err)
+	}
+	return nil
+}
+
+// applicationJar returns whether the application to re-zip is already a jar rather than an exploded application.
+func (s SpringPerformance) applicationJar() bool {
+	return s.ReZip && IsApplicationJar(s.AppPath)
+}
+
+// copyJar copies the jar at source to target.
+func copyJar(source string, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("unable to open %s\n%w", source, err)
+	}
+	defer in.Close()
+
+	if err := sherpa.CopyFile(in, target); err != nil {
+		return fmt.Errorf("unable to copy %s to %s\n%w", source, target, err)
+	}
+	return nil
+}
+
+// classpathIndex returns the jars listed by the classpath index of the exploded application, or nil if it is not
+// re-zipped from an exploded application or has no index.
+func (s SpringPerformance) classpathIndex() ([]string, error) {
+	if !s.ReZip || s.applicationJar() {
+		return nil, nil
+	}
+	libs, err := ParseClasspathIndex(s.AppPath)
+	if err != nil {
+		return nil, WithCategory(fmt.Errorf("invalid classpath index\n%w", err), ValidationFailed)
+	}
+	return libs, nil
+}
+
+// extractedClasspath returns the classpath of the extracted layout, in the order defined by the jar, followed by any
+// configured entries it does not contain, sorted. The configured entries are used as is if the jar was not extracted.
+// The libraries are ordered by the classpath index libs of the application, if any.
+func (s SpringPerformance) extractedClasspath(jarName string, libs []string) ([]string, error) {
+	if exists, err := sherpa.FileExists(filepath.Join(s.AppPath, jarName)); err != nil {
+		return nil, fmt.Errorf("unable to check for extracted jar %s\n%w", jarName, err)
+	} else if !exists {
+		return s.classpathEntries(), nil
+	}
+
+	cp, err := BuildClasspath(s.AppPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// the additional entries are sorted, the classpath and then the archive are the same from one build to the next
+	index := filepath.SplitList(cp)
+	if len(libs) > 0 {
+		// the libraries are extracted to lib/, so that the classpath follows the build whatever the order of Class-Path
+		ordered := []string{index[0]}
+		for _, l := range libs {
+			ordered = append(ordered, path.Join("lib", path.Base(l)))
+		}
+		index = OrderClasspath(index, ordered)
+	}
+	return OrderClasspath(append(slices.Clone(index), s.classpathEntries()...), index), nil
+}
+
+// trainingClasspathEntries returns the entries of the classpath of the training run configured by name, resolved against
+// the application. It fails if an entry does not exist.
+func (s SpringPerformance) trainingClasspathEntries(name string, entries []string) ([]string, error) {
+	resolved := ResolveClasspathEntries(s.AppPath, entries)
+	for _, entry := range resolved {
+		if _, err := os.Stat(entry); os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s entry %s does not exist", name, entry)
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to check %s entry %s\n%w", name, entry, err)
+		}
+	}
+	if len(resolved) > 0 {
+		s.log().Bodyf("Training run classpath will include %s from %s", strings.Join(resolved, ", "), name)
+	}
+	return resolved, nil
+}
+
+// ensureLauncherMainClass adds the Main-Class of the launcher matching the application layout to the exploded
+// manifest, if missing, so that the re-zipped jar can be started with -jar.
+func (s SpringPerformance) ensureLauncherMainClass() error {
+	if _, ok := s.Manifest.Get("Main-Class"); ok {
+		return nil
+	}
+
+	version, _ := s.Manifest.Get("Spring-Boot-Version")
+	mainClass := LauncherMainClass(version, LauncherType(s.AppPath, s.Manifest))
+	s.log().Bodyf("Manifest does not contain Main-Class, re-zipped jar will be started by %s", mainClass)
+
+	file := filepath.Join(s.AppPath, "META-INF", "MANIFEST.MF")
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("unable to read %s\n%w", file, err)
+	}
+	content := strings.TrimRight(string(b), "\r\n") + "\nMain-Class: " + mainClass + "\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		return fmt.Errorf("unable to write %s\n%w", file, err)
+	}
+	return nil
+}
+
+// moveArchive copies the archive dumped at dump to archive and removes the dump. Nothing is copied if the training run
+// did not dump an archive.
+func moveArchive(dump string, archive string) error {
+	in, err := os.Open(dump)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("unable to open %s\n%w", dump, err)
+	}
+	defer in.Close()
+
+	if err := sherpa.CopyFile(in, archive); err != nil {
+		return fmt.Errorf("unable to copy %s to %s\n%w", dump, archive, err)
+	}
+	if err := os.Remove(dump); err != nil {
+		return fmt.Errorf("unable to remove %s\n%w", dump, err)
+	}
+	return nil
+}
+
+// checkLazyInitialization warns if the application configuration enables the lazy initialization of the beans, most of
+// them would not be created by the training run which would archive few of the application classes.
+func (s SpringPerformance) checkLazyInitialization() {
+	// the configuration of a jar is not read before it is extracted
+	if strings.Contains(s.TrainingRunJavaToolOptions, LazyInitializationProperty) || s.applicationJar() {
+		return
+	}
+
+	classes, ok := s.Manifest.Get("Spring-Boot-Classes")
+	if !ok {
+		classes = "BOOT-INF/classes"
+	}
+	file, err := LazyInitialization(filepath.Join(s.AppPath, classes), s.Config.TrainingProfiles...)
+	if err != nil {
+		s.log().Bodyf("Unable to check for lazy initialization: %s", err)
+	} else if file != "" {
+		s.Logger.Header(Warningf("WARNING: %s enables %s, most beans are not created by the training run which significantly reduces the effectiveness of CDS, "+
+			"consider disabling it for the training run in a profile activated with BP_JVM_CDS_TRAINING_PROFILES", file, LazyInitializationProperty))
+	}
+}
+
+// bundledArchive returns the CDS archive bundled with the application, copied into layer, if it was created by the JDK
+// of the training run. It returns an empty string otherwise.
+func (s SpringPerformance) bundledArchive(layer libcnb.Layer) (string, error) {
+	// only an exploded application bundles an archive
+	if s.applicationJar() {
+		return "", nil
+	}
+	bundled := filepath.Join(s.AppPath, BundledArchiveName)
+	if exists, err := sherpa.FileExists(bundled); err != nil {
+		return "", fmt.Errorf("unable to check for %s\n%w", bundled, err)
+	} else if !exists {
+		return "", nil
+	}
+
+	jdk, err := s.trainingJDK(s.Config.JavaCommand())
+	if err != nil {
+		s.log().Bodyf("Ignoring the CDS archive bundled with the application: %s", err)
+		return "", nil
+	}
+	if err := ValidateArchiveJDK(bundled, jdk.Version); err != nil {
+		s.log().Bodyf("Ignoring the CDS archive bundled with the application, the training run will create one: %s", err)
+		return "", nil
+	}
+
+	in, err := os.Open(bundled)
+	if err != nil {
+		return "", fmt.Errorf("unable to open %s\n%w", bundled, err)
+	}
+	defer in.Close()
+
+	path := filepath.Join(layer.Path, "training", filepath.Base(bundled))
+	if err := sherpa.CopyFile(in, path); err != nil {
+		return "", fmt.Errorf("unable to copy %s to %s\n%w", bundled, path, err)
+	}
+	s.log().Bodyf("Found a CDS archive created by JDK %s bundled with the application", jdk.Version)
+	return path, nil
+}
+
+// archivePath returns the path of the CDS archive created by the training run, a relative archive file being resolved
+// against the application.
+func (s SpringPerformance) archivePath() string {
+	archive := s.Config.ArchiveFile()
+	if !filepath.IsAbs(archive) {
+		archive = filepath.Join(s.AppPath, archive)
+	}
+	return archive
+}
+
+// launchArchiveFile returns the path of the CDS archive in the run image, the archive file resolved against the launch
+// directory if it is relative.
+func (s SpringPerformance) launchArchiveFile() string {
+	archive := s.Config.ArchiveFile()
+	if !filepath.IsAbs(archive) && s.Config.LaunchDir != "" {
+		archive = filepath.Join(s.Config.LaunchDir, archive)
+	}
+	return archive
+}
+
+// keepOriginalJar packs the exploded application into a jar in the original directory of the layer and returns its
+// path.
+func (s SpringPerformance) keepOriginalJar(layer libcnb.Layer) (string, error) {
+	dir := filepath.Join(layer.Path, "original")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("unable to create %s\n%w", dir, err)
+	}
+
+	target := filepath.Join(dir, ApplicationArchiveName(s.AppPath, s.Manifest))
+	if s.applicationJar() {
+		if err := copyJar(s.AppPath, target); err != nil {
+			return "", err
+		}
+		return target, nil
+	}
+	if err := CreateJar(filepath.Clean(s.AppPath)+string(filepath.Separator), target, s.Config.Timestamp()); err != nil {
+		return "", fmt.Errorf("unable to create %s\n%w", target, err)
+	}
+	return target, nil
+}
+
+// reportRegions logs the regions of the CDS archives mapped by the JVM, and their total size by access.
+func (s SpringPerformance) reportRegions(regions ArchiveRegions) {
+	if len(regions) == 0 {
+		s.log().Bodyf("Unable to report the regions of the CDS archive, the JVM did not log any")
+		return
+	}
+	for _, r := range regions {
+		s.log().Debugf("CDS %s archive region #%d %s maps %d bytes", r.Archive, r.Index, r.Name, r.Size)
+	}
+	s.log().Bodyf("CDS archives map %d bytes read-only and %d bytes read-write in %d regions", regions.ReadOnlySize(), regions.ReadWriteSize(), len(regions))
+}
+
+// checkDuplicates warns if several versions of an artifact, or jars of different artifacts splitting a package, are on
+// the classpath of the training run. The refresh of the application context may fail, or archive the wrong classes.
+func (s SpringPerformance) checkDuplicates(classpath []string) {
+	for _, d := range DuplicateArtifacts(classpath) {
+		s.Logger.Header(Warningf("WARNING: %d versions of %s are on the training run classpath: %s", len(d.Entries), d.Artifact, strings.Join(d.Entries, ", ")))
+	}
+
+	split, err := SplitPackages(s.AppPath, classpath)
+	if err != nil {
+		s.log().Bodyf("Unable to check the training run classpath for split packages: %s", err)
+		return
+	}
+	for _, p := range split {
+		s.Logger.Header(Warningf("WARNING: package %s is split across %s on the training run classpath", p.Package, strings.Join(p.Entries, ", ")))
+	}
+}
+
+// trainingRunProfileSets performs a training run per set of BP_JVM_CDS_TRAINING_PROFILE_SETS with args and execute,
+// each listing the classes it loads, then dumps the classes of all the runs, and of classList if any, to dump. A dynamic
+// archive cannot be extended by another training run, the combined archive is a static one.
+func (s SpringPerformance) trainingRunProfileSets(layer libcnb.Layer, args []string, classList string, classpath string, dump string, execute func([]string) error) error {
+	dir := filepath.Join(layer.Path, "training")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("unable to create %s\n%w", dir, err)
+	}
+
+	var lists []string
+	if classList != "" {
+		lists = append(lists, classList)
+	}
+	sets := s.Config.TrainingProfileSets
+	for i, profiles := range sets {
+		list := filepath.Join(dir, fmt.Sprintf("classes-%d.lst", i+1))
+		s.log().Bodyf("Training run %d of %d will activate the profiles %s", i+1, len(sets), strings.Join(profiles, ", "))
+		if err := execute(ProfileSetArgs(args, profiles, list)); err != nil {
+			return fmt.Errorf("training run of the profiles %s failed\n%w", strings.Join(profiles, ","), err)
+		}
+		lists = append(lists, list)
+	}
+
+	merged := filepath.Join(dir, "classes.lst")
+	count, err := MergeClassLists(merged, lists...)
+	if err != nil {
+		return err
+	}
+	s.log().Bodyf("Dumping the %d classes loaded by the %d training runs to the CDS archive", count, len(sets))
+	return execute(StaticDumpArgs(merged, dump, classpath))
+}
+
+// trainingRunFormat performs the training run with args and execute then, if format dumps the archive once the training
+// run is done, dumps to dump the classes the training run listed to loaded, and the ones of classList if any.
+func (s SpringPerformance) trainingRunFormat(format ArchiveFormat, args []string, classList string, loaded string, classpath string, dump string, execute func([]string) error) error {
+	if format.DumpArgs(dump, loaded, classpath) == nil {
+		return execute(args)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(loaded), 0755); err != nil {
+		return fmt.Errorf("unable to create %s\n%w", filepath.Dir(loaded), err)
+	}
+	if err := execute(args); err != nil {
+		return err
+	}
+
+	list := loaded
+	if classList != "" {
+		list = filepath.Join(filepath.Dir(loaded), "classes.lst")
+		if _, err := MergeClassLists(list, classList, loaded); err != nil {
+			return err
+		}
+	}
+	s.log().Bodyf("Dumping the classes loaded by the training run to the %s CDS archive", format.Name())
+	return execute(format.DumpArgs(dump, list, classpath))
+}
+
+// profileSetsKey returns the BP_JVM_CDS_TRAINING_PROFILE_SETS part of the cache key of the archive, none if unset.
+func profileSetsKey(sets [][]string) []string {
+	var key []string
+	for _, profiles := range sets {
+		key = append(key, strings.Join(profiles, ","))
+	}
+	if len(key) == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("profile-sets=%s", strings.Join(key, ";"))}
+}
+
+// checkNativeHints warns, if verbose logging is enabled, if the AOT processed application has no runtime hints or calls
+// reflective methods that need them. Spring AOT replaces the reflection of the application context, not the one of the
+// application classes, which fails at runtime without a hint.
+func (s SpringPerformance) checkNativeHints() {
+	if !s.AotEnabled || !s.log().IsVerbose() {
+		return
+	}
+
+	classes := filepath.Join(s.AppPath, s.Manifest.GetString("Spring-Boot-Classes", "BOOT-INF/classes"))
+	if exists, err := sherpa.DirExists(classes); err != nil || !exists {
+		return
+	}
+
+	if ok, err := HasNativeHints(classes); err != nil {
+		s.log().Bodyf("Unable to check the application for runtime hints: %s", err)
+		return
+	} else if !ok {
+		s.Logger.Header(Warningf("WARNING: no %s found in META-INF/native-image, the AOT processed application may fail at runtime where it uses reflection or resources", strings.Join(NativeHintFiles, " or ")))
+	}
+
+	calls, err := FindReflectiveCalls(classes)
+	if err != nil {
+		s.log().Bodyf("Unable to check the application for reflective calls: %s", err)
+		return
+	}
+	for _, c := range calls {
+		s.Logger.Header(Warningf("WARNING: %s calls %s, which AOT may break without a runtime hint", c.Class, strings.Join(c.Methods, ", ")))
+	}
+}
+
+// reZipOptions returns the options of the re-zip of the application, without the files ignored by ignore.
+func (s SpringPerformance) reZipOptions(ignore IgnorePatterns) JarOptions {
+	modified := s.Config.Timestamp()
+	if level := s.Config.ReZipCompressionLevel; level != 0 {
+		s.log().Bodyf("Re-zipped jar will be compressed with deflate level %d, nested jars are stored", level)
+	}
+	return JarOptions{
+		Transform: func(header *zip.FileHeader) {
+			header.Modified = modified
+		},
+		Ignore:           ignore,
+		Progress:         s.reZipProgress(),
+		CompressionLevel: s.Config.ReZipCompressionLevel,
+	}
+}
+
+// reZipProgress returns the progress of the re-zip, logged every 10 percent at debug level, or nil not to
+// report it.
+func (s SpringPerformance) reZipProgress() JarProgressFunc {
+	if !s.log().IsDebugEnabled() {
+		return nil
+	}
+
+	next := int64(10)
+	return func(copied int64, total int64) {
+		if total == 0 {
+			return
+		}
+		if percent := copied * 100 / total; percent >= next {
+			s.log().Debugf("Re-zipped %d%% of %d bytes", percent, total)
+			next = percent/10*10 + 10
+		}
+	}
+}
+
+// checkFreeDisk returns an error if the file system of the application does not have BP_JVM_CDS_DISK_MULTIPLIER times
+// its size available. The check is skipped, and logged, if the free disk space cannot be determined.
+func (s SpringPerformance) checkFreeDisk() error {
+	if s.Config.DiskMultiplier == 0 || s.FreeDisk == nil {
+		return nil
+	}
+
+	_, size, err := ExtractedSize(s.AppPath)
+	if err != nil {
+		return fmt.Errorf("error measuring %s\n%w", s.AppPath, err)
+	}
+	if err := CheckFreeDisk(s.AppPath, size, s.Config.DiskMultiplier, s.FreeDisk); errors.Is(err, ErrInsufficientDisk) {
+		return err
+	} else if err != nil {
+		s.log().Bodyf("Not checking free disk space: %s", err)
+	}
+	return nil
+}
+
+// checkReZippedJar logs the digest of the application contents and of the jar re-zipped from them. If
+// BP_SPRING_REZIP_VERIFY_IDENTICAL is set, it warns if the jar does not contain the same files, ignoring the manifest
+// which may have been completed with a Main-Class.
+func (s SpringPerformance) checkReZippedJar(contents map[string]string, jarPath string) error {
+	digest, err := FileDigest(jarPath)
+	if err != nil {
+		return err
+	}
+	s.log().Bodyf("Original application digest sha256:%s", ContentsDigest(contents))
+	s.log().Bodyf("Re-zipped jar digest sha256:%s, re-zipping intentionally changes the digest of the application", digest)
+
+	if !s.Config.ReZipVerifyIdentical {
+		return nil
+	}
+	entries, err := JarDigests(jarPath)
+	if err != nil {
+		return err
+	}
+	if diff := DiffContents(contents, entries, ManifestEntryName); len(diff) > 0 {
+		s.Logger.Header(Warningf("WARNING: re-zipped jar does not have the contents of the application\n%s", strings.Join(diff, "\n")))
+	} else {
+		s.log().Bodyf("Verified re-zipped jar has the contents of the application")
+	}
+	return nil
+}
+
+// logHint logs the remediation hint carried by err, if any.
+func (s SpringPerformance) logHint(err error) {
+	if hint, ok := ErrorHint(err); ok {
+		s.Logger.Header(Warningf("HINT: %s", hint))
+	}
+}
+
+// trainingRunHint returns the remediation hint of a failed training run of startClass, sandboxed if wrapped by a
+// sandbox command.
+func trainingRunHint(err error, startClass string, sandboxed bool) string {
+	switch {
+	case sandboxed && commandNotFound(err):
+		return HintSandbox
+	case commandNotFound(err):
+		return HintJDK
+	case startClass == "":
+		return HintStartClass
+	default:
+		return HintTrainingRun
+	}
+}
+
+// trainingCPUs returns the number of CPUs of the training run: BP_JVM_CDS_TRAINING_CPUS if set, the CPU limit of the
+// build container otherwise, or 0 to let the JVM decide when the container is not limited.
+func (s SpringPerformance) trainingCPUs() (int, error) {
+	if s.Config.TrainingCPUs > 0 {
+		return s.Config.TrainingCPUs, nil
+	}
+	if s.CgroupRoot == "" {
+		return 0, nil
+	}
+	cpus, _, err := CgroupCPULimit(s.CgroupRoot)
+	return cpus, err
+}
+
+// debugDir returns the directory of the layer holding the diagnostic files of the training run, creating it if needed.
+func (s SpringPerformance) debugDir(layer libcnb.Layer) (string, error) {
+	dir := filepath.Join(layer.Path, "debug")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("unable to create %s\n%w", dir, err)
+	}
+	return dir, nil
+}
+
+// writeFileList writes the listing of the extracted layout to the debug directory of layer.
+func (s SpringPerformance) writeFileList(layer libcnb.Layer) error {
+	dir, err := s.debugDir(layer)
+	if err != nil {
+		return err
+	}
+
+	file := filepath.Join(dir, ExtractedFileListName)
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("unable to create %s\n%w", file, err)
+	}
+	defer f.Close()
+
+	if err := WriteFileList(s.AppPath, f); err != nil {
+		return err
+	}
+	s.log().Bodyf("Wrote the listing of the extracted files to %s", file)
+	return nil
+}
+
+// trainingDir returns the working directory of the training run, BP_JVM_CDS_TRAINING_DIR resolved against the
+// application, or the application itself.
+func (s SpringPerformance) trainingDir() (string, error) {
+	dir := s.Config.TrainingDir
+	if dir == "" {
+		return s.AppPath, nil
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(s.AppPath, dir)
+	}
+	dir = filepath.Clean(dir)
+
+	if exists, err := sherpa.DirExists(dir); err != nil {
+		return "", fmt.Errorf("unable to check directory %s\n%w", dir, err)
+	} else if !exists {
+		return "", fmt.Errorf("directory %s does not exist", dir)
+	}
+	return dir, nil
+}
+
+// trainingJDK describes the JDK found at javaCommand.
+func (s SpringPerformance) trainingJDK(javaCommand string) (JDK, error) {
+	buf := &bytes.Buffer{}
+	if err := s.Executor.Execute(effect.Execution{
+		Command: javaCommand,
+		Args:    []string{"-XshowSettings:properties", "-version"},
+		Env:     s.Config.JavaEnv(nil),
+		Stdout:  buf,
+		Stderr:  buf,
+	}); err != nil {
+		return JDK{}, fmt.Errorf("unable to run %s -version\n%w", javaCommand, err)
+	}
+
+	jdk := NewJDKFromSettings(buf.String())
+	if jdk.Version == "" {
+		return JDK{}, fmt.Errorf("unable to determine the version of %s", javaCommand)
+	}
+	return jdk, nil
+}
+
+// validateTrainingRunFlags checks that the arguments of the training run, derived from the BP_JVM_CDS_* configuration,
+// contain none of the DeniedJVMFlags. The flags of its JAVA_TOOL_OPTIONS and of the *_OPTIONS variables of envOptions,
+// passed with BP_JVM_CDS_TRAINING_ENV, are set by the build environment, for an APM agent for example, so the denied ones
+// are only warned about.
+func (s SpringPerformance) validateTrainingRunFlags(args []string, envOptions map[string]string) error {
+	if err := ValidateJVMFlags("the training run configuration", args, s.Config.AllowedFlags); err != nil {
+		return err
+	}
+
+	channel := "JAVA_TOOL_OPTIONS"
+	if s.Config.TrainingJavaToolOptions != "" {
+		channel = "CDS_TRAINING_JAVA_TOOL_OPTIONS"
+	}
+	s.warnDeniedTrainingRunFlags(channel, s.TrainingRunJavaToolOptions)
+
+	names := make([]string, 0, len(envOptions))
+	for name := range envOptions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		s.warnDeniedTrainingRunFlags(fmt.Sprintf("%s of BP_JVM_CDS_TRAINING_ENV", name), envOptions[name])
+	}
+	return nil
+}
+
+// warnDeniedTrainingRunFlags warns about the DeniedJVMFlags of options, supplied by channel, that reach the training run.
+func (s SpringPerformance) warnDeniedTrainingRunFlags(channel string, options string) {
+	for _, flag := range FindDeniedJVMFlags(strings.Fields(options), s.Config.AllowedFlags) {
+		s.Logger.Header(Warningf("WARNING: JVM flag %s supplied by %s is passed to the training run, it is denied from the BP_JVM_CDS_* configuration", flag, channel))
+	}
+}
+
+func (s SpringPerformance) springBootJarCDSLayoutExtract(javaCommand string, jarPath string) error {
+	s.log().Bodyf("Extracting Jar")
+
+	// the layout of the tools jarmode only exists from Spring Boot 3.3
+	if version, _ := s.Manifest.Get("Spring-Boot-Version"); !bootCDSExtractionSupported(version) {
+		return WithHint(fmt.Errorf("Spring Boot %q does not support the CDS extraction", version), HintJarMode)
+	}
+
+	// an application may not ship the jarmode, the layout is then extracted without the JVM unless the jarmode is
+	// required by BP_JVM_CDS_JARMODE
+	mode := JarModeTools
+	if supported, err := JarModeSupported(jarPath, mode); err != nil {
+		return fmt.Errorf("unable to check jarmode support\n%w", err)
+	} else if !supported && s.Config.JarMode == "" {
+		s.log().Bodyf("Jarmode %s is not supported by %s, extracting it without the JVM", mode, jarPath)
+		return ExtractBootJarLayout(jarPath, s.AppPath)
+	}
+	return extractBootJar(s.Executor, javaCommand, s.Config.JavaEnv(nil), mode, jarPath, s.AppPath, s.log().InfoWriter())
+}
+
+type phaseTiming struct {
+	name     string
+	duration time.Duration
+}
+
+// phaseTimings collects the duration of each phase of the contribution, in the order they ran.
+type phaseTimings []phaseTiming
+
+func (p *phaseTimings) record(name string, start time.Time) {
+	*p = append(*p, phaseTiming{name: name, duration: time.Since(start)})
+}
+
+func (p phaseTimings) String() string {
+	var parts []string
+	for _, t := range p {
+		parts = append(parts, fmt.Sprintf("%s %s", t.name, t.duration.Round(time.Millisecond)))
+	}
+	return strings.Join(parts, ", ")
 }
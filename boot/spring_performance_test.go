@@ -18,17 +18,28 @@ package boot_test
 
 import (
 	"archive/zip"
+	"bytes"
+	gocontext "context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/buildpacks/libcnb"
 	. "github.com/onsi/gomega"
 	"github.com/paketo-buildpacks/libjvm"
 	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
 	"github.com/paketo-buildpacks/libpak/effect"
 	"github.com/paketo-buildpacks/libpak/effect/mocks"
+	"github.com/paketo-buildpacks/libpak/sherpa"
 	"github.com/sclevine/spec"
 	"github.com/stretchr/testify/mock"
 
@@ -43,8 +54,43 @@ func testSpringPerformance(t *testing.T, context spec.G, it spec.S) {
 		executor   *mocks.Executor
 		aotEnabled bool
 		cdsEnabled bool
+		noArchive  bool
 	)
 
+	trainingRun := func(e effect.Execution) bool {
+		return slices.ContainsFunc(e.Args, func(arg string) bool {
+			return strings.HasPrefix(arg, "-XX:ArchiveClassesAtExit=")
+		})
+	}
+
+	trainingRuns := func() int {
+		var runs int
+		for _, c := range executor.Calls {
+			if trainingRun(c.Arguments[0].(effect.Execution)) {
+				runs++
+			}
+		}
+		return runs
+	}
+
+	// stubJavaVersion makes java report the settings of a Temurin JDK of version, as -XshowSettings:properties prints them
+	stubJavaVersion := func(executor *mocks.Executor, version string) {
+		executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+			return slices.Contains(e.Args, "-XshowSettings:properties")
+		})).Run(func(args mock.Arguments) {
+			_, err := fmt.Fprint(args.Get(0).(effect.Execution).Stderr, strings.ReplaceAll(jdkSettings, "21.0.4", version))
+			Expect(err).NotTo(HaveOccurred())
+		}).Return(nil)
+	}
+
+	// the next build starts again from the exploded application, the layer being restored by the platform
+	explode := func() {
+		Expect(os.RemoveAll(ctx.Application.Path)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "META-INF"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "BOOT-INF/lib"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "BOOT-INF/lib/spring-boot-jarmode-tools-3.3.1.jar"), []byte{}, 0644)).To(Succeed())
+	}
+
 	it.Before(func() {
 		var err error
 
@@ -56,16 +102,52 @@ func testSpringPerformance(t *testing.T, context spec.G, it spec.S) {
 
 		Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "META-INF"), 0755)).To(Succeed())
 		Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "BOOT-INF/lib"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "BOOT-INF/lib/spring-boot-jarmode-tools-3.3.1.jar"), []byte{}, 0644)).To(Succeed())
+
+		// the mocked training runs have written the archive, if any, once they return
+		t.Setenv("BP_JVM_CDS_DUMP_GRACE", "0")
 
 		executor = &mocks.Executor{}
+		// the training run creates the CDS archive in its working directory
+		executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+			return !noArchive && trainingRun(e)
+		})).Run(func(args mock.Arguments) {
+			e := args.Get(0).(effect.Execution)
+			for _, arg := range e.Args {
+				if archive, ok := strings.CutPrefix(arg, "-XX:ArchiveClassesAtExit="); ok {
+					if !filepath.IsAbs(archive) {
+						archive = filepath.Join(e.Dir, archive)
+					}
+					Expect(os.WriteFile(archive, []byte{}, 0644)).To(Succeed())
+				}
+			}
+		}).Return(nil)
 	})
 
 	it.After(func() {
 		Expect(os.RemoveAll(ctx.Layers.Path)).To(Succeed())
 		Expect(os.RemoveAll(ctx.Application.Path)).To(Succeed())
-		aotEnabled, cdsEnabled = false, false
+		aotEnabled, cdsEnabled, noArchive = false, false, false
 	})
 
+	newSpringPerformance := func(aotEnabled bool, cdsEnabled bool) boot.SpringPerformance {
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "META-INF", "MANIFEST.MF"), []byte(`
+Spring-Boot-Version: 3.3.1
+Spring-Boot-Classes: BOOT-INF/classes
+Spring-Boot-Lib: BOOT-INF/lib
+Start-Class: com.example.Application
+`), 0644)).To(Succeed())
+		props, err := libjvm.NewManifest(ctx.Application.Path)
+		Expect(err).NotTo(HaveOccurred())
+
+		config, err := boot.NewPerformanceConfig()
+		Expect(err).NotTo(HaveOccurred())
+
+		s := boot.NewSpringPerformance(libpak.DependencyCache{CachePath: "testdata"}, ctx.Application.Path, props, aotEnabled, cdsEnabled, "", config)
+		s.Executor = executor
+		return s
+	}
+
 	it("contributes Spring Performance for Boot 3.3+, both CDS & AOT enabled", func() {
 		aotEnabled, cdsEnabled = true, true
 		dc := libpak.DependencyCache{CachePath: "testdata"}
@@ -79,7 +161,7 @@ Spring-Boot-Lib: BOOT-INF/lib
 		props, err := libjvm.NewManifest(ctx.Application.Path)
 		Expect(err).NotTo(HaveOccurred())
 
-		s := boot.NewSpringPerformance(dc, ctx.Application.Path, props, aotEnabled, cdsEnabled, "", true, "")
+		s := boot.NewSpringPerformance(dc, ctx.Application.Path, props, aotEnabled, cdsEnabled, "", boot.PerformanceConfig{Required: true})
 		s.Executor = executor
 
 		layer, err := ctx.Layers.Layer("test-layer")
@@ -91,7 +173,7 @@ Spring-Boot-Lib: BOOT-INF/lib
 		Expect(layer.LaunchEnvironment["BPL_SPRING_AOT_ENABLED.default"]).To(Equal("true"))
 		Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"]).To(Equal("true"))
 
-		Expect(executor.Calls).To(HaveLen(2))
+		Expect(executor.Calls).To(HaveLen(3))
 		e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
 		Expect(ok).To(BeTrue())
 		Expect(e.Args).To(ContainElement("-Dspring.aot.enabled=true"))
@@ -114,7 +196,7 @@ Spring-Boot-Lib: BOOT-INF/lib
 		props, err := libjvm.NewManifest(ctx.Application.Path)
 		Expect(err).NotTo(HaveOccurred())
 
-		s := boot.NewSpringPerformance(dc, ctx.Application.Path, props, aotEnabled, cdsEnabled, "", true, "")
+		s := boot.NewSpringPerformance(dc, ctx.Application.Path, props, aotEnabled, cdsEnabled, "", boot.PerformanceConfig{Required: true})
 		s.Executor = executor
 
 		layer, err := ctx.Layers.Layer("test-layer")
@@ -144,7 +226,7 @@ Spring-Boot-Lib: BOOT-INF/lib
 		props, err := libjvm.NewManifest(ctx.Application.Path)
 		Expect(err).NotTo(HaveOccurred())
 
-		s := boot.NewSpringPerformance(dc, ctx.Application.Path, props, aotEnabled, cdsEnabled, "", true, "")
+		s := boot.NewSpringPerformance(dc, ctx.Application.Path, props, aotEnabled, cdsEnabled, "", boot.PerformanceConfig{Required: true})
 		s.Executor = executor
 
 		layer, err := ctx.Layers.Layer("test-layer")
@@ -153,9 +235,9 @@ Spring-Boot-Lib: BOOT-INF/lib
 		layer, err = s.Contribute(layer)
 		Expect(err).NotTo(HaveOccurred())
 
-		Expect(layer.LaunchEnvironment["BPL_SPRING_AOT_ENABLED.default"]).To(Equal("false"))
+		Expect(layer.LaunchEnvironment).NotTo(HaveKey("BPL_SPRING_AOT_ENABLED.default"))
 		Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"]).To(Equal("true"))
-		Expect(executor.Calls).To(HaveLen(2))
+		Expect(executor.Calls).To(HaveLen(3))
 
 		e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
 		Expect(ok).To(BeTrue())
@@ -182,7 +264,7 @@ Spring-Boot-Lib: BOOT-INF/lib
 		props, err := libjvm.NewManifest(ctx.Application.Path)
 		Expect(err).NotTo(HaveOccurred())
 
-		s := boot.NewSpringPerformance(dc, ctx.Application.Path, props, aotEnabled, cdsEnabled, "", true, "user-cds-opt")
+		s := boot.NewSpringPerformance(dc, ctx.Application.Path, props, aotEnabled, cdsEnabled, "", boot.PerformanceConfig{Required: true, TrainingJavaToolOptions: "user-cds-opt"})
 		s.Executor = executor
 
 		layer, err := ctx.Layers.Layer("test-layer")
@@ -191,7 +273,7 @@ Spring-Boot-Lib: BOOT-INF/lib
 		layer, err = s.Contribute(layer)
 		Expect(err).NotTo(HaveOccurred())
 
-		Expect(executor.Calls).To(HaveLen(2))
+		Expect(executor.Calls).To(HaveLen(3))
 		e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
 		Expect(ok).To(BeTrue())
 
@@ -221,7 +303,7 @@ Spring-Boot-Lib: BOOT-INF/lib
 		props, err := libjvm.NewManifest(ctx.Application.Path)
 		Expect(err).NotTo(HaveOccurred())
 
-		s := boot.NewSpringPerformance(dc, ctx.Application.Path, props, aotEnabled, cdsEnabled, "", true, "")
+		s := boot.NewSpringPerformance(dc, ctx.Application.Path, props, aotEnabled, cdsEnabled, "", boot.PerformanceConfig{Required: true})
 		s.Executor = executor
 
 		layer, err := ctx.Layers.Layer("test-layer")
@@ -233,7 +315,7 @@ Spring-Boot-Lib: BOOT-INF/lib
 		Expect(layer.LaunchEnvironment["BPL_SPRING_AOT_ENABLED.default"]).To(Equal("true"))
 		Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"]).To(Equal("true"))
 
-		Expect(executor.Calls).To(HaveLen(2))
+		Expect(executor.Calls).To(HaveLen(3))
 		e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
 		Expect(ok).To(BeTrue())
 		Expect(e.Args).To(ContainElement("-Dspring.aot.enabled=true"))
@@ -249,40 +331,4362 @@ Spring-Boot-Lib: BOOT-INF/lib
 
 	})
 
-	it("fails with a non existing JRE_HOME path", func() {
-		Expect(os.Setenv("JRE_HOME", "/that/does/not/exist")).To(Succeed())
+	it("logs a timing breakdown of each phase", func() {
+		executor.On("Execute", mock.Anything).Return(nil)
 
-		aotEnabled, cdsEnabled = true, true
-		dc := libpak.DependencyCache{CachePath: "testdata"}
+		buf := &bytes.Buffer{}
+		s := newSpringPerformance(false, true)
+		s.Logger = bard.NewLogger(buf)
+
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = s.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(buf.String()).To(MatchRegexp(`Timings: re-zip \S+, extraction \S+, timestamp reset \S+, training run \S+`))
+	})
+
+	context("BP_SPRING_PERFORMANCE_LOG_LEVEL", func() {
+		it.Before(func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		contribute := func(level string) string {
+			t.Setenv("BP_SPRING_PERFORMANCE_LOG_LEVEL", level)
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			return buf.String()
+		}
+
+		it("logs only the summary when quiet", func() {
+			out := contribute("quiet")
+
+			Expect(out).NotTo(ContainSubstring("Extracting Jar"))
+			Expect(out).NotTo(ContainSubstring("Timings:"))
+			Expect(out).To(ContainSubstring("CDS is active at launch with the archive"))
+		})
+
+		it("logs the progress but not the commands by default", func() {
+			out := contribute("normal")
+
+			Expect(out).To(ContainSubstring("Extracting Jar"))
+			Expect(out).To(ContainSubstring("Timings:"))
+			Expect(out).NotTo(ContainSubstring("Phase training run took"))
+			Expect(out).NotTo(ContainSubstring("-Dspring.context.exit=onRefresh"))
+		})
+
+		it("logs the resolved commands and the duration of each phase when debug", func() {
+			out := contribute("debug")
+
+			Expect(out).To(MatchRegexp(`Running \S*java .*-Dspring.context.exit=onRefresh`))
+			Expect(out).To(ContainSubstring("Phase extraction took"))
+			Expect(out).To(ContainSubstring("Phase training run took"))
+		})
+	})
+
+	it("puts the Java home on the PATH of the extraction and the training run", func() {
+		t.Setenv("JRE_HOME", "/jdk")
+		t.Setenv("PATH", "/usr/bin")
 		executor.On("Execute", mock.Anything).Return(nil)
 
-		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "META-INF", "MANIFEST.MF"), []byte(`
-Spring-Boot-Version: 3.3.1
-Spring-Boot-Classes: BOOT-INF/classes
-Spring-Boot-Lib: BOOT-INF/lib
-`), 0644)).To(Succeed())
-		props, err := libjvm.NewManifest(ctx.Application.Path)
+		layer, err := ctx.Layers.Layer("test-layer")
 		Expect(err).NotTo(HaveOccurred())
 
-		s := boot.NewSpringPerformance(dc, ctx.Application.Path, props, aotEnabled, cdsEnabled, "", true, "")
-		s.Executor = executor
+		_, err = newSpringPerformance(false, true).Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, c := range executor.Calls {
+			e, ok := c.Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Command).To(Equal("/jdk/bin/java"))
+			Expect(e.Env).To(ContainElement("PATH=/jdk/bin:/usr/bin"))
+		}
+	})
+
+	context("application layouts", func() {
+		var jar string
+
+		it.Before(func() {
+			jar = filepath.Join(t.TempDir(), "application.jar")
+			Expect(os.WriteFile(jar, []byte("jar"), 0644)).To(Succeed())
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		contribute := func(appPath string, reZip bool) error {
+			s := newSpringPerformance(false, true)
+			s.AppPath, s.ReZip = appPath, reZip
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			return err
+		}
+
+		it("re-zips an exploded application before extracting it in its place", func() {
+			Expect(contribute(ctx.Application.Path, true)).To(Succeed())
+
+			e, ok := executor.Calls[0].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).To(ContainElement("extract"))
+			Expect(e.Args[2]).NotTo(HavePrefix(ctx.Application.Path))
+			Expect(e.Args[5]).To(Equal(ctx.Application.Path))
+			Expect(filepath.Join(ctx.Application.Path, "META-INF", "MANIFEST.MF")).NotTo(BeAnExistingFile())
+		})
+
+		it("does not extract an exploded application that is not re-zipped", func() {
+			Expect(contribute(ctx.Application.Path, false)).To(MatchError(ContainSubstring("must be re-zipped before it is extracted")))
+
+			Expect(executor.Calls).To(BeEmpty())
+			Expect(filepath.Join(ctx.Application.Path, "META-INF", "MANIFEST.MF")).To(BeARegularFile())
+		})
+
+		it("extracts a jar as is instead of re-zipping it", func() {
+			writeJarWithManifest(t, jar, "Manifest-Version: 1.0\nStart-Class: com.example.Application\n")
+			original, err := os.ReadFile(jar)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(contribute(jar, true)).To(Succeed())
+
+			Expect(os.ReadFile(filepath.Join(ctx.Layers.Path, "test-layer", "runner.jar"))).To(Equal(original))
+			Expect(jar).To(BeADirectory())
+			Expect(filepath.Join(jar, "runner.jar")).To(BeARegularFile())
+		})
+
+		it("does not re-zip a file that is not a jar", func() {
+			file := filepath.Join(t.TempDir(), "application.txt")
+			Expect(os.WriteFile(file, []byte("txt"), 0644)).To(Succeed())
+
+			Expect(contribute(file, true)).To(MatchError(ContainSubstring("cannot be re-zipped")))
+
+			Expect(executor.Calls).To(BeEmpty())
+			Expect(os.ReadFile(file)).To(Equal([]byte("txt")))
+		})
+
+		it("does not extract a jar over itself", func() {
+			Expect(contribute(jar, false)).To(MatchError(ContainSubstring("the extraction requires an exploded application")))
+
+			Expect(executor.Calls).To(BeEmpty())
+			Expect(os.ReadFile(jar)).To(Equal([]byte("jar")))
+		})
+
+		context("re-zip detection", func() {
+			detected := func(appPath string) boot.SpringPerformance {
+				manifest := newSpringPerformance(false, true).Manifest
+
+				config, err := boot.NewPerformanceConfig()
+				Expect(err).NotTo(HaveOccurred())
+
+				s := boot.NewSpringPerformance(libpak.DependencyCache{CachePath: "testdata"}, appPath, manifest, false, true, "", config)
+				s.Executor = executor
+				return s
+			}
+
+			contributeDetected := func(s boot.SpringPerformance) error {
+				layer, err := ctx.Layers.Layer("test-layer")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = s.Contribute(layer)
+				return err
+			}
+
+			it("re-zips an exploded application", func() {
+				s := detected(ctx.Application.Path)
+				Expect(s.ReZip).To(BeTrue())
+
+				Expect(contributeDetected(s)).To(Succeed())
+
+				e, ok := executor.Calls[0].Arguments[0].(effect.Execution)
+				Expect(ok).To(BeTrue())
+				Expect(e.Args[5]).To(Equal(ctx.Application.Path))
+				Expect(filepath.Join(ctx.Application.Path, "META-INF", "MANIFEST.MF")).NotTo(BeAnExistingFile())
+			})
+
+			it("extracts a jar as is", func() {
+				writeJarWithManifest(t, jar, "Manifest-Version: 1.0\nStart-Class: com.example.Application\n")
+				original, err := os.ReadFile(jar)
+				Expect(err).NotTo(HaveOccurred())
+
+				s := detected(jar)
+				Expect(s.ReZip).To(BeTrue())
+
+				Expect(contributeDetected(s)).To(Succeed())
+
+				Expect(os.ReadFile(filepath.Join(ctx.Layers.Path, "test-layer", "runner.jar"))).To(Equal(original))
+				Expect(jar).To(BeADirectory())
+			})
+
+			it("does not re-zip a file that is not a jar", func() {
+				file := filepath.Join(t.TempDir(), "application.txt")
+				Expect(os.WriteFile(file, []byte("txt"), 0644)).To(Succeed())
+
+				s := detected(file)
+				Expect(s.ReZip).To(BeFalse())
+
+				Expect(contributeDetected(s)).To(MatchError(ContainSubstring("cannot be re-zipped")))
+				Expect(executor.Calls).To(BeEmpty())
+			})
+
+			it("does not re-zip an exploded application with BP_SPRING_REZIP=false", func() {
+				t.Setenv("BP_SPRING_REZIP", "false")
+
+				s := detected(ctx.Application.Path)
+				Expect(s.ReZip).To(BeFalse())
+
+				Expect(contributeDetected(s)).To(MatchError(ContainSubstring("must be re-zipped before it is extracted")))
+				Expect(executor.Calls).To(BeEmpty())
+				Expect(filepath.Join(ctx.Application.Path, "META-INF", "MANIFEST.MF")).To(BeARegularFile())
+			})
+
+			it("re-zips a file that is not a jar with BP_SPRING_REZIP=true", func() {
+				t.Setenv("BP_SPRING_REZIP", "true")
+				file := filepath.Join(t.TempDir(), "application.txt")
+				Expect(os.WriteFile(file, []byte("txt"), 0644)).To(Succeed())
+
+				s := detected(file)
+				Expect(s.ReZip).To(BeTrue())
+
+				Expect(contributeDetected(s)).To(MatchError(ContainSubstring("cannot be re-zipped")))
+			})
+		})
+	})
+
+	context("launch profile", func() {
+		it.Before(func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("exports the CDS and AOT flags with the archive in the run image", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(true, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layer.Profile).To(HaveKey("spring-performance.sh"))
+			script := layer.Profile["spring-performance.sh"]
+			Expect(script).To(ContainSubstring("for option in -XX:SharedArchiveFile=/workspace/application.jsa -Dspring.aot.enabled=true; do"))
+			Expect(script).To(ContainSubstring("export JAVA_TOOL_OPTIONS"))
+		})
+
+		it("uses BP_JVM_CDS_ARCHIVE_DIR", func() {
+			t.Setenv("BP_JVM_CDS_ARCHIVE_DIR", filepath.Join(ctx.Layers.Path, "cds"))
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layer.Profile["spring-performance.sh"]).To(ContainSubstring(fmt.Sprintf("for option in -XX:SharedArchiveFile=%s; do", filepath.Join(ctx.Layers.Path, "cds", "application.jsa"))))
+		})
+
+		it("exports the AOT flag only without an archive", func() {
+			noArchive = true
+			t.Setenv("BP_JVM_CDS_WARN_MISSING_ARCHIVE", "true")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(true, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layer.Profile["spring-performance.sh"]).To(ContainSubstring("for option in -Dspring.aot.enabled=true; do"))
+		})
+
+		it("does not export anything without optimizations", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, false).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layer.Profile).NotTo(HaveKey("spring-performance.sh"))
+		})
+
+		it("does not add options twice", func() {
+			script := filepath.Join(t.TempDir(), "profile.sh")
+			Expect(os.WriteFile(script, []byte(boot.LaunchProfile([]string{"-XX:SharedArchiveFile=/workspace/application.jsa", "-Dspring.aot.enabled=true"})), 0644)).To(Succeed())
+
+			cmd := exec.Command("sh", "-c", `. "$1"; echo "$JAVA_TOOL_OPTIONS"`, "sh", script)
+			cmd.Env = []string{"JAVA_TOOL_OPTIONS=-Xmx1g -Dspring.aot.enabled=true"}
+			out, err := cmd.Output()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(out)).To(Equal("-Xmx1g -Dspring.aot.enabled=true -XX:SharedArchiveFile=/workspace/application.jsa\n"))
+		})
+	})
+
+	it("records the size of the extraction in the layer metadata", func() {
+		executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+			return slices.Contains(e.Args, "extract")
+		})).Run(func(args mock.Arguments) {
+			dest := args.Get(0).(effect.Execution).Args[5]
+			Expect(os.MkdirAll(filepath.Join(dest, "lib"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dest, "application.classlist"), []byte("0123456789"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dest, "lib", "a.jar"), []byte("01234"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dest, "lib", "b.jar"), []byte("012"), 0644)).To(Succeed())
+		}).Return(nil)
+		executor.On("Execute", mock.Anything).Return(nil)
 
 		layer, err := ctx.Layers.Layer("test-layer")
 		Expect(err).NotTo(HaveOccurred())
 
-		layer, err = s.Contribute(layer)
+		layer, err = newSpringPerformance(false, true).Contribute(layer)
 		Expect(err).NotTo(HaveOccurred())
 
-		Expect(executor.Calls).To(HaveLen(2))
-		e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
-		Expect(ok).To(BeTrue())
+		Expect(layer.Metadata).To(HaveKeyWithValue("extracted_entries", 3))
+		Expect(layer.Metadata).To(HaveKeyWithValue("extracted_bytes", int64(18)))
+	})
 
-		Expect(e.Command).To(Equal("/that/does/not/exist/bin/java"))
-		Expect(layer.Build).To(BeTrue())
+	context("BP_JVM_CDS_KEEP_ORIGINAL_JAR", func() {
+		it.Before(func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
 
-		Expect(os.Unsetenv("JRE_HOME")).To(Succeed())
+		it("keeps the original jar in the layer", func() {
+			t.Setenv("BP_JVM_CDS_KEEP_ORIGINAL_JAR", "true")
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "BOOT-INF", "classes"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "BOOT-INF", "classes", "Application.class"), []byte("class"), 0644)).To(Succeed())
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			original := filepath.Join(layer.Path, "original", "runner.jar")
+			entries, err := boot.JarDigests(original)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveKey("BOOT-INF/classes/Application.class"))
+
+			// the original manifest is kept, before re-zip adds the Main-Class of the launcher
+			r, err := zip.OpenReader(original)
+			Expect(err).NotTo(HaveOccurred())
+			defer r.Close()
+			f, err := r.Open(boot.ManifestEntryName)
+			Expect(err).NotTo(HaveOccurred())
+			manifest, err := io.ReadAll(f)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(manifest)).To(ContainSubstring("Start-Class: com.example.Application"))
+			Expect(string(manifest)).NotTo(ContainSubstring("Main-Class"))
+		})
+
+		it("does not keep the original jar by default", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Join(layer.Path, "original")).NotTo(BeADirectory())
+		})
+	})
+
+	context("re-zip digests", func() {
+		it.Before(func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("logs the digests of the application and of the re-zipped jar", func() {
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			digest, err := boot.FileDigest(filepath.Join(layer.Path, "runner.jar"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buf.String()).To(MatchRegexp(`Original application digest sha256:[0-9a-f]{64}`))
+			Expect(buf.String()).To(ContainSubstring("Re-zipped jar digest sha256:%s, re-zipping intentionally changes the digest", digest))
+			Expect(buf.String()).NotTo(ContainSubstring("Verified re-zipped jar"))
+		})
+
+		it("verifies the re-zipped jar with BP_SPRING_REZIP_VERIFY_IDENTICAL", func() {
+			t.Setenv("BP_SPRING_REZIP_VERIFY_IDENTICAL", "true")
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buf.String()).To(ContainSubstring("Verified re-zipped jar has the contents of the application"))
+			Expect(buf.String()).NotTo(ContainSubstring("WARNING: re-zipped jar"))
+		})
+
+		it("compresses the re-zipped jar with BP_SPRING_REZIP_COMPRESSION_LEVEL", func() {
+			t.Setenv("BP_SPRING_REZIP_COMPRESSION_LEVEL", "9")
+			s := newSpringPerformance(false, true)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			r, err := zip.OpenReader(filepath.Join(layer.Path, "runner.jar"))
+			Expect(err).NotTo(HaveOccurred())
+			defer CloseOrPanic(r)()
+			methods := map[string]uint16{}
+			for _, f := range r.File {
+				methods[f.Name] = f.Method
+			}
+			Expect(methods).To(HaveKeyWithValue("META-INF/MANIFEST.MF", zip.Deflate))
+			Expect(methods).To(HaveKeyWithValue("BOOT-INF/lib/spring-boot-jarmode-tools-3.3.1.jar", zip.Store))
+		})
+
+		it("logs the progress of the re-zip with debug logging", func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "BOOT-INF/lib/huge.jar"), make([]byte, 4096), 0644)).To(Succeed())
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLoggerWithOptions(buf, bard.WithDebug(buf))
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buf.String()).To(MatchRegexp(`Re-zipped 100% of \d+ bytes`))
+		})
+
+		it("does not log the progress of the re-zip by default", func() {
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buf.String()).NotTo(ContainSubstring("Re-zipped 100%"))
+		})
+
+		it("excludes the files of the ignore file from the re-zipped jar", func() {
+			t.Setenv("BP_SPRING_REZIP_VERIFY_IDENTICAL", "true")
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "BOOT-INF", "build.log"), []byte("log"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, boot.ReZipIgnoreFile), []byte("*.log\n"), 0644)).To(Succeed())
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			entries, err := boot.JarDigests(filepath.Join(layer.Path, "runner.jar"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveKey("BOOT-INF/lib/spring-boot-jarmode-tools-3.3.1.jar"))
+			Expect(entries).NotTo(HaveKey("BOOT-INF/build.log"))
+			Expect(entries).NotTo(HaveKey(boot.ReZipIgnoreFile))
+			Expect(buf.String()).To(ContainSubstring("Excluding 2 files matching .rezipignore from the re-zipped jar"))
+			Expect(buf.String()).To(ContainSubstring("Verified re-zipped jar has the contents of the application"))
+		})
+	})
+
+	it("resets the file times of the extracted layout", func() {
+		executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+			return e.Args[0] == "-Djarmode=tools"
+		})).Run(func(args mock.Arguments) {
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "lib"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "lib", "test.jar"), []byte{}, 0644)).To(Succeed())
+		}).Return(nil)
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = newSpringPerformance(false, true).Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		info, err := os.Stat(filepath.Join(ctx.Application.Path, "lib", "test.jar"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.ModTime().UTC()).To(Equal(time.Date(1980, 1, 1, 0, 0, 1, 0, time.UTC)))
+	})
+
+	it("resets the file times of the extracted layout to SOURCE_DATE_EPOCH", func() {
+		t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+		executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+			return e.Args[0] == "-Djarmode=tools"
+		})).Run(func(args mock.Arguments) {
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "lib"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "lib", "test.jar"), []byte{}, 0644)).To(Succeed())
+		}).Return(nil)
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = newSpringPerformance(false, true).Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		info, err := os.Stat(filepath.Join(ctx.Application.Path, "lib", "test.jar"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.ModTime().UTC()).To(Equal(time.Unix(1700000000, 0).UTC()))
+	})
+
+	it("adds the launcher Main-Class to the re-zipped jar when missing", func() {
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = newSpringPerformance(false, true).Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		manifest, err := libjvm.NewManifestFromJAR(filepath.Join(layer.Path, "runner.jar"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.GetString("Main-Class", "")).To(Equal("org.springframework.boot.loader.launch.JarLauncher"))
+		Expect(manifest.GetString("Start-Class", "")).To(Equal("com.example.Application"))
+	})
+
+	it("warns about misspelled performance variables", func() {
+		t.Setenv("BP_JVM_CDS_ENABELD", "true")
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		buf := &bytes.Buffer{}
+		s := newSpringPerformance(false, true)
+		s.Logger = bard.NewLogger(buf)
+
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = s.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(buf.String()).To(ContainSubstring("WARNING: BP_JVM_CDS_ENABELD is not a known configuration, did you mean BP_JVM_CDS_ENABLED?"))
+	})
+
+	it("fails early when the training run command line exceeds ARG_MAX", func() {
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		s := newSpringPerformance(false, true)
+		s.ArgMax = 64 * 1024
+		for i := 0; i < 2000; i++ {
+			s.Classpath = append(s.Classpath, fmt.Sprintf("lib/spring-synthetic-dependency-%04d-1.0.0.jar", i))
+		}
+
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = s.Contribute(layer)
+		Expect(err).To(MatchError(ContainSubstring("exceeding ARG_MAX of 65536 bytes")))
+		Expect(executor.Calls).To(HaveLen(1))
+	})
+
+	context("war packaging", func() {
+		it.Before(func() {
+			Expect(os.RemoveAll(filepath.Join(ctx.Application.Path, "BOOT-INF"))).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "WEB-INF", "lib"), 0755)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "WEB-INF", "classes"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "WEB-INF/lib/spring-boot-jarmode-tools-3.3.1.jar"), []byte{}, 0644)).To(Succeed())
+		})
+
+		it("re-zips, extracts and trains a war", func() {
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "extract")
+			})).Run(func(args mock.Arguments) {
+				dest := args.Get(0).(effect.Execution).Args[5]
+				Expect(os.MkdirAll(filepath.Join(dest, "lib"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(dest, "lib", "spring-web-6.1.10.jar"), []byte{}, 0644)).To(Succeed())
+				writeJarWithManifest(t, filepath.Join(dest, "runner.war"), "Class-Path: lib/spring-web-6.1.10.jar\n")
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "META-INF", "MANIFEST.MF"), []byte(`
+Spring-Boot-Version: 3.3.1
+Spring-Boot-Classes: WEB-INF/classes/
+Spring-Boot-Lib: WEB-INF/lib/
+Start-Class: com.example.Application
+`), 0644)).To(Succeed())
+			props, err := libjvm.NewManifest(ctx.Application.Path)
+			Expect(err).NotTo(HaveOccurred())
+			s := boot.NewSpringPerformance(libpak.DependencyCache{CachePath: "testdata"}, ctx.Application.Path, props, false, true, "", boot.PerformanceConfig{Required: true})
+			s.Executor = executor
+			s.Classpath = []string{"runner.war"}
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Join(layer.Path, "runner.war")).To(BeARegularFile())
+			manifest, err := libjvm.NewManifestFromJAR(filepath.Join(layer.Path, "runner.war"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.GetString("Main-Class", "")).To(Equal("org.springframework.boot.loader.launch.WarLauncher"))
+
+			extraction, ok := executor.Calls[0].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(filepath.Base(extraction.Args[2])).To(Equal("runner.war"))
+
+			training, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(training.Args).To(ContainElement("runner.war:lib/spring-web-6.1.10.jar"))
+			Expect(training.Args).To(ContainElement("-Dspring.context.exit=onRefresh"))
+			Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"]).To(Equal("true"))
+		})
+
+		it("stops the embedded Tomcat of a war once the application is warm", func() {
+			Expect(os.RemoveAll(ctx.Application.Path)).To(Succeed())
+			Expect(sherpa.CopyDir("testdata/war-tomcat", ctx.Application.Path)).To(Succeed())
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "extract")
+			})).Run(func(args mock.Arguments) {
+				dest := args.Get(0).(effect.Execution).Args[5]
+				Expect(os.MkdirAll(filepath.Join(dest, "lib"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(dest, "lib", "tomcat-embed-core-10.1.25.jar"), []byte{}, 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(dest, "lib", "spring-webmvc-6.1.10.jar"), []byte{}, 0644)).To(Succeed())
+				writeJarWithManifest(t, filepath.Join(dest, "runner.war"), "Class-Path: lib/spring-webmvc-6.1.10.jar lib/tomcat-embed-core-10.1.25.jar\n")
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			props, err := libjvm.NewManifest(ctx.Application.Path)
+			Expect(err).NotTo(HaveOccurred())
+			buf := &bytes.Buffer{}
+			s := boot.NewSpringPerformance(libpak.DependencyCache{CachePath: "testdata"}, ctx.Application.Path, props, false, true, "", boot.PerformanceConfig{Required: true})
+			s.Executor = executor
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			driver := filepath.Join(layer.Path, "training", boot.WarShutdownDriverName)
+			training, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(training.Args).NotTo(ContainElement("-Dspring.context.exit=onRefresh"))
+			Expect(training.Args).To(ContainElement("-Dserver.port=0"))
+			Expect(training.Args).To(ContainElement("-XX:ArchiveClassesAtExit=application.jsa"))
+			Expect(training.Args[len(training.Args)-4:]).To(Equal([]string{"-cp",
+				"runner.war:lib/spring-webmvc-6.1.10.jar:lib/tomcat-embed-core-10.1.25.jar", driver, "com.example.Application"}))
+			Expect(buf.String()).To(ContainSubstring("Training run will start the embedded Tomcat of the war and stop it once the application is ready"))
+			Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"]).To(Equal("true"))
+
+			b, err := os.ReadFile(driver)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("public final class CdsWarShutdown"))
+		})
+
+		it("detects the embedded Tomcat on the classpath", func() {
+			Expect(boot.EmbeddedTomcat([]string{"runner.war", "lib/tomcat-embed-core-10.1.25.jar"})).To(BeTrue())
+			Expect(boot.EmbeddedTomcat([]string{"runner.war", "lib/jetty-server-12.0.10.jar"})).To(BeFalse())
+		})
+	})
+
+	context("application path with spaces", func() {
+		var parent string
+
+		it.Before(func() {
+			parent = ctx.Application.Path
+			ctx.Application.Path = filepath.Join(parent, "my app (1) & co")
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "META-INF"), 0755)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "BOOT-INF/lib"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "BOOT-INF/lib/spring-boot-jarmode-tools-3.3.1.jar"), []byte{}, 0644)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(parent)).To(Succeed())
+		})
+
+		it("extracts, resets and trains", func() {
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "extract")
+			})).Run(func(args mock.Arguments) {
+				dest := args.Get(0).(effect.Execution).Args[5]
+				Expect(os.MkdirAll(filepath.Join(dest, "lib"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(dest, "lib", "my lib-1.0.0.jar"), []byte{}, 0644)).To(Succeed())
+				writeJarWithManifest(t, filepath.Join(dest, "runner.jar"), "Class-Path: lib/my%20lib-1.0.0.jar\n")
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			extraction, ok := executor.Calls[0].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(extraction.Args[5]).To(Equal(ctx.Application.Path))
+
+			training, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(training.Dir).To(Equal(ctx.Application.Path))
+			Expect(training.Args).To(ContainElement("runner.jar:lib/my lib-1.0.0.jar"))
+
+			info, err := os.Stat(filepath.Join(ctx.Application.Path, "lib", "my lib-1.0.0.jar"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.ModTime().UTC()).To(Equal(boot.DefaultTimestamp))
+			Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"]).To(Equal("true"))
+		})
 	})
 
+	context("BP_JVM_CDS_TRAINING_CPUS", func() {
+		var cgroupRoot string
+
+		it.Before(func() {
+			cgroupRoot = t.TempDir()
+			Expect(os.WriteFile(filepath.Join(cgroupRoot, "cpu.max"), []byte("150000 100000\n"), 0644)).To(Succeed())
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		trainingArgs := func() []string {
+			s := newSpringPerformance(false, true)
+			s.CgroupRoot = cgroupRoot
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			return e.Args
+		}
+
+		it("derives the CPU count from the cgroup limit", func() {
+			Expect(trainingArgs()).To(ContainElement("-XX:ActiveProcessorCount=2"))
+		})
+
+		it("prefers BP_JVM_CDS_TRAINING_CPUS", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_CPUS", "3")
+			Expect(trainingArgs()).To(ContainElement("-XX:ActiveProcessorCount=3"))
+		})
+
+		it("lets the JVM decide when the container is not limited", func() {
+			Expect(os.WriteFile(filepath.Join(cgroupRoot, "cpu.max"), []byte("max 100000\n"), 0644)).To(Succeed())
+			Expect(trainingArgs()).NotTo(ContainElement(HavePrefix("-XX:ActiveProcessorCount")))
+		})
+	})
+
+	context("ArgsCustomizer", func() {
+		it("executes the arguments returned by the customizer", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			var received []string
+			s := newSpringPerformance(false, true)
+			s.ArgsCustomizer = func(args []string) []string {
+				received = slices.Clone(args)
+				return append([]string{"-Xlog:cds"}, slices.DeleteFunc(args, func(arg string) bool {
+					return arg == "-Dspring.context.exit=onRefresh"
+				})...)
+			}
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(received).To(ContainElement("-Dspring.context.exit=onRefresh"))
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args[0]).To(Equal("-Xlog:cds"))
+			Expect(e.Args).NotTo(ContainElement("-Dspring.context.exit=onRefresh"))
+			Expect(e.Args).To(HaveLen(len(received)))
+		})
+	})
+
+	context("Spring Boot loader", func() {
+		it.Before(func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		trainingClasspath := func() string {
+			s := newSpringPerformance(false, true)
+			s.Classpath = []string{"runner.jar", "lib/spring-boot-loader-3.3.1.jar", "lib/spring-core-6.1.10.jar"}
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			return e.Args[slices.Index(e.Args, "-cp")+1]
+		}
+
+		it("is excluded from the training run classpath", func() {
+			Expect(trainingClasspath()).To(Equal("runner.jar:lib/spring-core-6.1.10.jar"))
+		})
+
+		it("is kept with BP_JVM_CDS_TRAINING_INCLUDE_LOADER", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_INCLUDE_LOADER", "true")
+			Expect(trainingClasspath()).To(Equal("runner.jar:lib/spring-boot-loader-3.3.1.jar:lib/spring-core-6.1.10.jar"))
+		})
+	})
+
+	it("joins the classpath entries for the training run", func() {
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		s := newSpringPerformance(false, true)
+		s.Classpath = []string{"runner.jar", "lib/spring-cloud-bindings-1.2.3.jar"}
+		s.ClasspathString = "ignored.jar"
+
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = s.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+		Expect(ok).To(BeTrue())
+		Expect(e.Args).To(ContainElement("runner.jar:lib/spring-cloud-bindings-1.2.3.jar"))
+		Expect(e.Args).NotTo(ContainElement("ignored.jar"))
+	})
+
+	it("builds the training run classpath from the extracted layout", func() {
+		executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+			return e.Args[0] == "-Djarmode=tools"
+		})).Run(func(args mock.Arguments) {
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "lib"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "lib", "spring-core-6.1.10.jar"), []byte{}, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "lib", "spring-boot-3.3.1.jar"), []byte{}, 0644)).To(Succeed())
+			writeJarWithManifest(t, filepath.Join(ctx.Application.Path, "runner.jar"),
+				"Class-Path: lib/spring-core-6.1.10.jar lib/spring-boot-3.3.1.jar\n")
+		}).Return(nil)
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		s := newSpringPerformance(false, true)
+		s.Classpath = []string{"runner.jar", "lib/spring-cloud-bindings-1.2.3.jar"}
+
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = s.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+		Expect(ok).To(BeTrue())
+		Expect(e.Args).To(ContainElement(
+			"runner.jar:lib/spring-core-6.1.10.jar:lib/spring-boot-3.3.1.jar:lib/spring-cloud-bindings-1.2.3.jar"))
+	})
+
+	it("orders the training run classpath by the classpath index of the application", func() {
+		Expect(sherpa.CopyDir("testdata/classpath-index/BOOT-INF", filepath.Join(ctx.Application.Path, "BOOT-INF"))).To(Succeed())
+		executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+			return e.Args[0] == "-Djarmode=tools"
+		})).Run(func(args mock.Arguments) {
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "lib"), 0755)).To(Succeed())
+			for _, jar := range []string{"spring-boot-3.3.1.jar", "spring-core-6.1.10.jar", "jackson-databind-2.17.1.jar", "spring-context-6.1.10.jar"} {
+				Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "lib", jar), []byte{}, 0644)).To(Succeed())
+			}
+			writeJarWithManifest(t, filepath.Join(ctx.Application.Path, "runner.jar"),
+				"Class-Path: lib/spring-context-6.1.10.jar lib/jackson-databind-2.17.1.jar lib/spring-core-6.1.10.jar lib/spring-boot-3.3.1.jar\n")
+		}).Return(nil)
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = newSpringPerformance(false, true).Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+		Expect(ok).To(BeTrue())
+		Expect(e.Args).To(ContainElement("runner.jar:lib/spring-boot-3.3.1.jar:lib/spring-core-6.1.10.jar:" +
+			"lib/jackson-databind-2.17.1.jar:lib/spring-context-6.1.10.jar"))
+	})
+
+	it("falls back to the classpath string for the training run", func() {
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		s := newSpringPerformance(false, true)
+		s.ClasspathString = "runner.jar:lib/test.jar"
+
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = s.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+		Expect(ok).To(BeTrue())
+		Expect(e.Args).To(ContainElement("runner.jar:lib/test.jar"))
+	})
+
+	context("duplicates on the training run classpath", func() {
+		it.Before(func() {
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return e.Args[0] == "-Djarmode=tools"
+			})).Run(func(args mock.Arguments) {
+				Expect(sherpa.CopyDir("testdata/duplicates/lib", filepath.Join(ctx.Application.Path, "lib"))).To(Succeed())
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		newDuplicatesPerformance := func(buf *bytes.Buffer, debug bool) boot.SpringPerformance {
+			s := newSpringPerformance(false, true)
+			s.Classpath = []string{"runner.jar", "lib/jackson-core-2.17.1.jar", "lib/jackson-core-2.15.4.jar",
+				"lib/spring-context-6.1.8.jar", "lib/spring-context-support-6.1.8.jar"}
+			if debug {
+				s.Logger = bard.NewLoggerWithOptions(buf, bard.WithDebug(buf))
+			} else {
+				s.Logger = bard.NewLogger(buf)
+			}
+			return s
+		}
+
+		it("warns about duplicate artifacts and split packages with debug logging", func() {
+			buf := &bytes.Buffer{}
+			s := newDuplicatesPerformance(buf, true)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buf.String()).To(ContainSubstring(
+				"WARNING: 2 versions of jackson-core are on the training run classpath: lib/jackson-core-2.17.1.jar, lib/jackson-core-2.15.4.jar"))
+			Expect(buf.String()).To(ContainSubstring(
+				"WARNING: package org.springframework.context is split across lib/spring-context-6.1.8.jar, lib/spring-context-support-6.1.8.jar"))
+		})
+
+		it("does not scan the classpath by default", func() {
+			buf := &bytes.Buffer{}
+			s := newDuplicatesPerformance(buf, false)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buf.String()).NotTo(ContainSubstring("versions of jackson-core"))
+			Expect(buf.String()).NotTo(ContainSubstring("is split across"))
+		})
+	})
+
+	context("native hints of the AOT processed application", func() {
+		it.Before(func() {
+			Expect(sherpa.CopyDir("testdata/native-hints/BOOT-INF", filepath.Join(ctx.Application.Path, "BOOT-INF"))).To(Succeed())
+		})
+
+		contribute := func(debug bool) string {
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(true, false)
+			if debug {
+				s.Logger = bard.NewLoggerWithOptions(buf, bard.WithDebug(buf))
+			} else {
+				s.Logger = bard.NewLogger(buf)
+			}
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			return buf.String()
+		}
+
+		it("warns about the missing hints and the reflective calls with debug logging", func() {
+			output := contribute(true)
+
+			Expect(output).To(ContainSubstring("WARNING: no reachability-metadata.json or reflect-config.json found in META-INF/native-image"))
+			Expect(output).To(ContainSubstring(
+				"WARNING: com.example.Reflective calls Class.forName, ClassLoader.getResourceAsStream, Proxy.newProxyInstance, which AOT may break without a runtime hint"))
+			Expect(output).NotTo(ContainSubstring("com.example.Plain"))
+			Expect(output).NotTo(ContainSubstring("com.example.Application__BeanDefinitions"))
+		})
+
+		it("does not warn about the hints the application has", func() {
+			hints := filepath.Join(ctx.Application.Path, "BOOT-INF", "classes", "META-INF", "native-image", "com.example", "application")
+			Expect(os.MkdirAll(hints, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(hints, "reflect-config.json"), []byte("[]"), 0644)).To(Succeed())
+
+			Expect(contribute(true)).NotTo(ContainSubstring("WARNING: no reachability-metadata.json"))
+		})
+
+		it("does not scan the application by default", func() {
+			Expect(contribute(false)).NotTo(ContainSubstring("WARNING"))
+		})
+	})
+
+	it("records metrics for each phase", func() {
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		metrics := &recordingMetricsSink{}
+		s := newSpringPerformance(false, true)
+		s.Metrics = metrics
+
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = s.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(metrics.events).To(Equal([]string{
+			"extraction.start", "extraction.end", "training-run.start", "training-run.end", "archive.created",
+		}))
+		Expect(metrics.durations).To(HaveKey("re-zip"))
+		Expect(metrics.durations).To(HaveKey("extraction"))
+		Expect(metrics.durations).To(HaveKey("timestamp reset"))
+		Expect(metrics.durations).To(HaveKey("training run"))
+		Expect(metrics.sizes).To(HaveKeyWithValue("archive", int64(0)))
+	})
+
+	it("records the training run JDK in the layer SBOM", func() {
+		stubJavaVersion(executor, "21.0.4")
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = newSpringPerformance(false, true).Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		content, err := os.ReadFile(layer.SBOMPath(libcnb.CycloneDXJSON))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(ContainSubstring(`"name":"OpenJDK Runtime Environment"`))
+		Expect(string(content)).To(ContainSubstring(`"version":"21.0.4"`))
+	})
+
+	context("ContributeWithResult", func() {
+		it("describes the applied optimizations", func() {
+			stubJavaVersion(executor, "21.0.4")
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, result, err := newSpringPerformance(true, true).ContributeWithResult(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.ArchivePath).To(Equal(filepath.Join(ctx.Application.Path, "application.jsa")))
+			Expect(result.ArchiveSize).To(BeZero())
+			Expect(result.TrainingDuration).To(BeNumerically(">", 0))
+			Expect(result.AOTApplied).To(BeTrue())
+			Expect(result.CDSApplied).To(BeTrue())
+			Expect(result.JDKVersion).To(Equal("21.0.4"))
+		})
+
+		it("records the bean count of the training run", func() {
+			noArchive = true
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "-Dspring.context.exit=onRefresh")
+			})).Run(func(args mock.Arguments) {
+				e := args.Get(0).(effect.Execution)
+				Expect(os.WriteFile(filepath.Join(e.Dir, "application.jsa"), []byte{}, 0644)).To(Succeed())
+				_, err := e.Stdout.Write([]byte("TRACE 1 --- [main] o.s.b.f.s.DefaultListableBeanFactory : Pre-instantiating singletons in " +
+					"org.springframework.beans.factory.support.DefaultListableBeanFactory@1b2c3d4: defining beans [application,dataSource]; root of factory hierarchy\n"))
+				Expect(err).NotTo(HaveOccurred())
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, result, err := s.ContributeWithResult(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.TrainingBeanCount).To(Equal(2))
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.BeanCountMetadata, 2))
+			Expect(buf.String()).To(ContainSubstring("Training run refreshed a context of 2 beans"))
+		})
+
+		it("does not record a bean count the training run did not log", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, result, err := newSpringPerformance(false, true).ContributeWithResult(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.TrainingBeanCount).To(BeZero())
+			Expect(layer.Metadata).NotTo(HaveKey(boot.BeanCountMetadata))
+		})
+
+		context("CaptureTrainingOutput", func() {
+			training := func(err error) {
+				noArchive = true
+				executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+					return slices.Contains(e.Args, "com.example.Application")
+				})).Run(func(args mock.Arguments) {
+					e := args.Get(0).(effect.Execution)
+					Expect(os.WriteFile(filepath.Join(e.Dir, "application.jsa"), []byte{}, 0644)).To(Succeed())
+					_, err := e.Stdout.Write([]byte("Started Application in 1.5 seconds\n"))
+					Expect(err).NotTo(HaveOccurred())
+					_, err = e.Stderr.Write([]byte("WARNING: test-warning\n"))
+					Expect(err).NotTo(HaveOccurred())
+				}).Return(err)
+				executor.On("Execute", mock.Anything).Return(nil)
+			}
+
+			it("returns the output of the training run when captured", func() {
+				training(nil)
+				s := newSpringPerformance(false, true)
+				s.CaptureTrainingOutput = true
+
+				layer, err := ctx.Layers.Layer("test-layer")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, result, err := s.ContributeWithResult(layer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.TrainingOutput).To(Equal("Started Application in 1.5 seconds\nWARNING: test-warning\n"))
+			})
+
+			it("returns the output of a training run that failed without failing the build", func() {
+				t.Setenv("BP_JVM_CDS_REQUIRED", "false")
+				training(fmt.Errorf("test-error"))
+				s := newSpringPerformance(false, true)
+				s.CaptureTrainingOutput = true
+
+				layer, err := ctx.Layers.Layer("test-layer")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, result, err := s.ContributeWithResult(layer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.TrainingOutput).To(ContainSubstring("WARNING: test-warning"))
+			})
+
+			it("does not keep the output unless captured", func() {
+				training(nil)
+
+				layer, err := ctx.Layers.Layer("test-layer")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, result, err := newSpringPerformance(false, true).ContributeWithResult(layer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.TrainingOutput).To(BeEmpty())
+			})
+		})
+
+		it("describes a contribution without training run", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, result, err := newSpringPerformance(true, false).ContributeWithResult(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result).To(Equal(boot.PerformanceResult{AOTApplied: true}))
+		})
+
+		it("describes a training run that did not create an archive", func() {
+			noArchive = true
+			t.Setenv("BP_JVM_CDS_WARN_MISSING_ARCHIVE", "true")
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, result, err := newSpringPerformance(false, true).ContributeWithResult(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.CDSApplied).To(BeFalse())
+			Expect(result.ArchivePath).To(BeEmpty())
+			Expect(result.TrainingDuration).To(BeNumerically(">", 0))
+		})
+	})
+
+	context("BP_JVM_CDS_JARMODE", func() {
+		it("extracts with the requested jarmode", func() {
+			t.Setenv("BP_JVM_CDS_JARMODE", "tools")
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[0].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args[0]).To(Equal("-Djarmode=tools"))
+		})
+
+		it("extracts the jar without the JVM when it does not ship the jarmode", func() {
+			Expect(os.Remove(filepath.Join(ctx.Application.Path, "BOOT-INF/lib/spring-boot-jarmode-tools-3.3.1.jar"))).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "BOOT-INF/lib/spring-core-6.1.10.jar"), []byte{}, 0644)).To(Succeed())
+			executor.On("Execute", mock.Anything).Return(nil)
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buf.String()).To(ContainSubstring("Jarmode tools is not supported"))
+			Expect(filepath.Join(ctx.Application.Path, "lib", "spring-core-6.1.10.jar")).To(BeARegularFile())
+			e, ok := executor.Calls[0].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).NotTo(ContainElement("-Djarmode=tools"))
+			Expect(e.Args).To(ContainElement(HaveSuffix(string(filepath.ListSeparator) + "lib/spring-core-6.1.10.jar")))
+		})
+
+		it("fails when the jar does not support the requested jarmode", func() {
+			Expect(os.Remove(filepath.Join(ctx.Application.Path, "BOOT-INF/lib/spring-boot-jarmode-tools-3.3.1.jar"))).To(Succeed())
+			t.Setenv("BP_JVM_CDS_JARMODE", "tools")
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("jarmode tools is not supported")))
+			Expect(executor.Calls).To(BeEmpty())
+		})
+
+		it("fails to extract a Spring Boot version older than 3.3", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+			s := newSpringPerformance(false, true)
+			_, _, err := s.Manifest.Set("Spring-Boot-Version", "3.2.5")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring(`Spring Boot "3.2.5" does not support the CDS extraction`)))
+			Expect(errorHint(err)).To(Equal(boot.HintJarMode))
+			Expect(executor.Calls).To(BeEmpty())
+		})
+	})
+
+	context("BP_JVM_CDS_ARCHIVE_TMPDIR", func() {
+		var tmpDir string
+
+		it.Before(func() {
+			tmpDir = filepath.Join(t.TempDir(), "tmpfs")
+			t.Setenv("BP_JVM_CDS_ARCHIVE_TMPDIR", tmpDir)
+		})
+
+		it("dumps the archive to the temporary directory and copies it to the application", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).To(ContainElement(fmt.Sprintf("-XX:ArchiveClassesAtExit=%s", filepath.Join(tmpDir, "application.jsa"))))
+
+			Expect(filepath.Join(ctx.Application.Path, "application.jsa")).To(BeARegularFile())
+			Expect(filepath.Join(tmpDir, "application.jsa")).NotTo(BeAnExistingFile())
+			Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"]).To(Equal("true"))
+		})
+
+		it("does not copy an archive the training run did not dump", func() {
+			noArchive = true
+			t.Setenv("BP_JVM_CDS_WARN_MISSING_ARCHIVE", "true")
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Join(ctx.Application.Path, "application.jsa")).NotTo(BeAnExistingFile())
+			Expect(layer.LaunchEnvironment).NotTo(HaveKey("BPL_JVM_CDS_ENABLED.default"))
+		})
+	})
+
+	context("BP_JVM_CDS_ARCHIVE_DIR", func() {
+		it("writes the archive to the configured directory", func() {
+			archiveDir := t.TempDir()
+			t.Setenv("BP_JVM_CDS_ARCHIVE_DIR", filepath.Join(archiveDir, "cds"))
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			archive := filepath.Join(archiveDir, "cds", "application.jsa")
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).To(ContainElement(fmt.Sprintf("-XX:ArchiveClassesAtExit=%s", archive)))
+			Expect(archive).To(BeARegularFile())
+			Expect(filepath.Join(ctx.Application.Path, "application.jsa")).NotTo(BeAnExistingFile())
+			Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"]).To(Equal("true"))
+			Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ARCHIVE_FILE.default"]).To(Equal(archive))
+		})
+
+		it("does not export the archive location by default", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layer.LaunchEnvironment).NotTo(HaveKey("BPL_JVM_CDS_ARCHIVE_FILE.default"))
+		})
+	})
+
+	context("interrupted contribution", func() {
+		var rezipped func() []string
+
+		it.Before(func() {
+			// the application is located in the temporary directory, which cannot be isolated
+			existing, err := filepath.Glob(filepath.Join(os.TempDir(), "spring-rezip*"))
+			Expect(err).NotTo(HaveOccurred())
+			rezipped = func() []string {
+				matches, err := filepath.Glob(filepath.Join(os.TempDir(), "spring-rezip*"))
+				Expect(err).NotTo(HaveOccurred())
+				return slices.DeleteFunc(matches, func(m string) bool { return slices.Contains(existing, m) })
+			}
+		})
+
+		it("fails and removes the temporary directories once cancelled during the training run", func() {
+			noArchive = true
+			interrupt, cancel := gocontext.WithCancel(gocontext.Background())
+			defer cancel()
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "-Dspring.context.exit=onRefresh")
+			})).Run(func(args mock.Arguments) {
+				e := args.Get(0).(effect.Execution)
+				Expect(os.WriteFile(filepath.Join(e.Dir, "application.jsa"), []byte{}, 0644)).To(Succeed())
+				Expect(rezipped()).To(HaveLen(1))
+				cancel()
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			s := newSpringPerformance(false, true)
+			s.Context = interrupt
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).To(MatchError(gocontext.Canceled))
+			Expect(rezipped()).To(BeEmpty())
+			Expect(filepath.Join(layer.Path, boot.CompletionMarkerName)).NotTo(BeAnExistingFile())
+		})
+
+		it("fails once cancelled even if a failed training run is not required", func() {
+			t.Setenv("BP_JVM_CDS_REQUIRED", "false")
+			noArchive = true
+			interrupt, cancel := gocontext.WithCancel(gocontext.Background())
+			defer cancel()
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "-Dspring.context.exit=onRefresh")
+			})).Run(func(mock.Arguments) { cancel() }).Return(fmt.Errorf("signal: interrupt"))
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			s := newSpringPerformance(false, true)
+			s.Context = interrupt
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).To(MatchError(gocontext.Canceled))
+		})
+
+		it("does not run the training run once cancelled", func() {
+			interrupt, cancel := gocontext.WithCancel(gocontext.Background())
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return e.Args[0] == "-Djarmode=tools"
+			})).Run(func(mock.Arguments) { cancel() }).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			s := newSpringPerformance(false, true)
+			s.Context = interrupt
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).To(MatchError(gocontext.Canceled))
+			for _, c := range executor.Calls {
+				Expect(c.Arguments[0].(effect.Execution).Args).NotTo(ContainElement("-Dspring.context.exit=onRefresh"))
+			}
+		})
+
+		it("removes the temporary directories once done", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(rezipped()).To(BeEmpty())
+		})
+	})
+
+	context("BP_JVM_CDS_ARCHIVE_FORMAT", func() {
+		it.Before(func() {
+			t.Setenv("BP_JVM_CDS_ARCHIVE_FORMAT", "classic")
+
+			// the training run lists the classes it loads, the static dump archives them
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.ContainsFunc(e.Args, func(arg string) bool { return strings.HasPrefix(arg, "-XX:DumpLoadedClassList=") })
+			})).Run(func(args mock.Arguments) {
+				for _, arg := range args.Get(0).(effect.Execution).Args {
+					if list, ok := strings.CutPrefix(arg, "-XX:DumpLoadedClassList="); ok {
+						Expect(os.WriteFile(list, []byte("java/lang/Object id: 0\ncom/example/Application id: 1\n"), 0644)).To(Succeed())
+					}
+				}
+			}).Return(nil)
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "-Xshare:dump")
+			})).Run(func(args mock.Arguments) {
+				e := args.Get(0).(effect.Execution)
+				Expect(os.WriteFile(filepath.Join(e.Dir, "application.jsa"), []byte{}, 0644)).To(Succeed())
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("dumps a static archive of the classes listed by the training run", func() {
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			training, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			loaded := filepath.Join(layer.Path, "training", "loaded-classes.lst")
+			Expect(training.Args).To(ContainElement(fmt.Sprintf("-XX:DumpLoadedClassList=%s", loaded)))
+			Expect(training.Args).NotTo(ContainElement(HavePrefix("-XX:ArchiveClassesAtExit=")))
+
+			dump, ok := executor.Calls[2].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			classpath := training.Args[slices.Index(training.Args, "-cp")+1]
+			Expect(dump.Args).To(Equal(boot.StaticDumpArgs(loaded, "application.jsa", classpath)))
+			Expect(dump.Dir).To(Equal(training.Dir))
+
+			Expect(buf.String()).To(ContainSubstring("Dumping the classes loaded by the training run to the classic CDS archive"))
+			Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"]).To(Equal("true"))
+		})
+
+		it("dumps the classes of BP_JVM_CDS_CLASSLIST with the ones of the training run", func() {
+			classList := filepath.Join(t.TempDir(), "classes.lst")
+			Expect(os.WriteFile(classList, []byte("com/example/Configuration\n"), 0644)).To(Succeed())
+			t.Setenv("BP_JVM_CDS_CLASSLIST", classList)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			merged := filepath.Join(layer.Path, "training", "classes.lst")
+			Expect(executor.Calls[2].Arguments[0].(effect.Execution).Args).To(ContainElement(fmt.Sprintf("-XX:SharedClassListFile=%s", merged)))
+			Expect(os.ReadFile(merged)).To(Equal([]byte("com/example/Configuration\njava/lang/Object\ncom/example/Application\n")))
+		})
+
+		it("does not dump the archive when the training run fails", func() {
+			t.Setenv("BP_JVM_CDS_REQUIRED", "true")
+			executor.ExpectedCalls = nil
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.ContainsFunc(e.Args, func(arg string) bool { return strings.HasPrefix(arg, "-XX:DumpLoadedClassList=") })
+			})).Return(fmt.Errorf("exit status 1"))
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(boot.TrainingFailed))
+			Expect(executor.Calls).To(HaveLen(2))
+		})
+	})
+
+	context("BP_JVM_CDS_TRAINING_PROFILE_SETS", func() {
+		it.Before(func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_PROFILE_SETS", "default;cloud,kafka")
+
+			// each training run lists the classes of its profiles, the static dump archives them
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.ContainsFunc(e.Args, func(arg string) bool { return strings.HasPrefix(arg, "-XX:DumpLoadedClassList=") })
+			})).Run(func(args mock.Arguments) {
+				e := args.Get(0).(effect.Execution)
+				classes := "java/lang/Object id: 0\ncom/example/Application id: 1\n"
+				if slices.Contains(e.Args, "-Dspring.profiles.active=cloud,kafka") {
+					classes = "java/lang/Object id: 0\ncom/example/KafkaConfiguration id: 1\n"
+				}
+				for _, arg := range e.Args {
+					if list, ok := strings.CutPrefix(arg, "-XX:DumpLoadedClassList="); ok {
+						Expect(os.WriteFile(list, []byte(classes), 0644)).To(Succeed())
+					}
+				}
+			}).Return(nil)
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "-Xshare:dump")
+			})).Run(func(args mock.Arguments) {
+				e := args.Get(0).(effect.Execution)
+				Expect(os.WriteFile(filepath.Join(e.Dir, "application.jsa"), []byte{}, 0644)).To(Succeed())
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("runs the training run once per profile set and dumps the classes of all the runs", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			var runs [][]string
+			for _, call := range executor.Calls[1:4] {
+				runs = append(runs, call.Arguments[0].(effect.Execution).Args)
+			}
+			training := filepath.Join(layer.Path, "training")
+			Expect(runs[0]).To(ContainElements("-Dspring.profiles.active=default", fmt.Sprintf("-XX:DumpLoadedClassList=%s", filepath.Join(training, "classes-1.lst"))))
+			Expect(runs[1]).To(ContainElements("-Dspring.profiles.active=cloud,kafka", fmt.Sprintf("-XX:DumpLoadedClassList=%s", filepath.Join(training, "classes-2.lst"))))
+			for _, run := range runs[:2] {
+				Expect(run).NotTo(ContainElement(HavePrefix("-XX:ArchiveClassesAtExit=")))
+			}
+			// the archive is dumped with the classpath of the training runs
+			classpath := runs[0][slices.Index(runs[0], "-cp")+1]
+			Expect(runs[2]).To(Equal(boot.StaticDumpArgs(filepath.Join(training, "classes.lst"), "application.jsa", classpath)))
+
+			Expect(os.ReadFile(filepath.Join(training, "classes.lst"))).To(Equal(
+				[]byte("java/lang/Object\ncom/example/Application\ncom/example/KafkaConfiguration\n")))
+			Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"]).To(Equal("true"))
+		})
+
+		it("fails when the training run of a profile set fails", func() {
+			t.Setenv("BP_JVM_CDS_REQUIRED", "true")
+			executor.ExpectedCalls = nil
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "-Dspring.profiles.active=cloud,kafka")
+			})).Return(fmt.Errorf("exit status 1"))
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("training run of the profiles cloud,kafka failed")))
+		})
+	})
+
+	context("BP_JVM_CDS_ENV_TAG", func() {
+		it("names the archive and the layer metadata after the tag", func() {
+			t.Setenv("BP_JVM_CDS_ENV_TAG", "prod")
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).To(ContainElement("-XX:ArchiveClassesAtExit=application-prod.jsa"))
+			Expect(filepath.Join(ctx.Application.Path, "application-prod.jsa")).To(BeARegularFile())
+			Expect(filepath.Join(ctx.Application.Path, "application.jsa")).NotTo(BeAnExistingFile())
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.EnvTagMetadata, "prod"))
+			Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ARCHIVE_FILE.default"]).To(Equal("application-prod.jsa"))
+		})
+
+		it("does not record a tag by default", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layer.Metadata).NotTo(HaveKey(boot.EnvTagMetadata))
+		})
+	})
+
+	context("BP_JVM_CDS_LAUNCH_DIR", func() {
+		it("warns when the application is launched from another location", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buf.String()).To(ContainSubstring("WARNING: CDS archive may not be portable to the run image"))
+		})
+
+		it("does not warn when the application is launched from the same location", func() {
+			t.Setenv("BP_JVM_CDS_LAUNCH_DIR", ctx.Application.Path)
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buf.String()).NotTo(ContainSubstring("may not be portable"))
+		})
+	})
+
+	context("BP_JVM_CDS_BENCHMARK", func() {
+		it.Before(func() {
+			t.Setenv("BP_JVM_CDS_BENCHMARK", "true")
+		})
+
+		it("logs the startup improvement of the archive", func() {
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "-XX:SharedArchiveFile=application.jsa")
+			})).Run(func(args mock.Arguments) {
+				_, err := args.Get(0).(effect.Execution).Stdout.Write([]byte("Started Application in 0.75 seconds (process running for 1.0)\n"))
+				Expect(err).NotTo(HaveOccurred())
+			}).Return(nil)
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "com.example.Application")
+			})).Run(func(args mock.Arguments) {
+				_, err := args.Get(0).(effect.Execution).Stdout.Write([]byte("Started Application in 1.5 seconds (process running for 1.8)\n"))
+				Expect(err).NotTo(HaveOccurred())
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executor.Calls).To(HaveLen(5))
+			Expect(buf.String()).To(ContainSubstring("CDS benchmark: startup without archive 1.5s, with archive 750ms, estimated improvement 50.0%"))
+		})
+
+		it("warns when the benchmark fails", func() {
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "-XX:SharedArchiveFile=application.jsa")
+			})).Return(fmt.Errorf("test-error"))
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buf.String()).To(ContainSubstring("WARNING: unable to benchmark the CDS archive"))
+			Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"]).To(Equal("true"))
+		})
+	})
+
+	context("BP_JVM_CDS_REPORT_REGIONS", func() {
+		validation := func(e effect.Execution) bool {
+			return slices.Contains(e.Args, "-XX:+PrintSharedArchiveAndExit")
+		}
+
+		it.Before(func() {
+			t.Setenv("BP_JVM_CDS_REPORT_REGIONS", "true")
+		})
+
+		it("records the regions mapped by the launch validating the archive", func() {
+			executor.On("Execute", mock.MatchedBy(validation)).Run(func(args mock.Arguments) {
+				output, err := os.ReadFile(filepath.Join("testdata", "cds", "regions", "jdk21.log"))
+				Expect(err).NotTo(HaveOccurred())
+				_, err = args.Get(0).(effect.Execution).Stdout.Write(output)
+				Expect(err).NotTo(HaveOccurred())
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, result, err := s.ContributeWithResult(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			i := slices.IndexFunc(executor.Calls, func(c mock.Call) bool { return validation(c.Arguments[0].(effect.Execution)) })
+			Expect(i).NotTo(Equal(-1))
+			Expect(executor.Calls[i].Arguments[0].(effect.Execution).Args[0]).To(Equal("-Xlog:cds=info,cds+heap=info"))
+			Expect(result.ArchiveRegions).To(HaveLen(6))
+			Expect(layer.Metadata[boot.ArchiveRegionsMetadata]).To(HaveKeyWithValue("read_only_bytes", int64(0x660000+0x523000)))
+			Expect(layer.Metadata[boot.ArchiveRegionsMetadata]).To(HaveKeyWithValue("dynamic_readwrite_bytes", int64(0x1c4000)))
+			Expect(buf.String()).To(ContainSubstring("CDS archives map 12070912 bytes read-only and 5980160 bytes read-write in 6 regions"))
+			Expect(buf.String()).NotTo(ContainSubstring("Validated the CDS archive"))
+		})
+
+		it("keeps the archive when the launch reporting its regions fails", func() {
+			executor.On("Execute", mock.MatchedBy(validation)).Return(fmt.Errorf("exit status 1"))
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"]).To(Equal("true"))
+			Expect(layer.Metadata).NotTo(HaveKey(boot.ArchiveRegionsMetadata))
+			Expect(buf.String()).To(ContainSubstring("Unable to report the regions of the CDS archive"))
+		})
+	})
+
+	context("BP_JVM_CDS_VALIDATE_ARCHIVE", func() {
+		validation := func(e effect.Execution) bool {
+			return slices.Contains(e.Args, "-XX:+PrintSharedArchiveAndExit")
+		}
+
+		validations := func() []effect.Execution {
+			var executions []effect.Execution
+			for _, c := range executor.Calls {
+				if e := c.Arguments[0].(effect.Execution); validation(e) {
+					executions = append(executions, e)
+				}
+			}
+			return executions
+		}
+
+		it.Before(func() {
+			t.Setenv("BP_JVM_CDS_VALIDATE_ARCHIVE", "true")
+		})
+
+		it("launches the application from its directory with the archive required", func() {
+			executor.On("Execute", mock.MatchedBy(validation)).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+			s.Classpath = []string{"runner.jar", "lib/test.jar"}
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(validations()).To(HaveLen(1))
+			e := validations()[0]
+			Expect(e.Dir).To(Equal(ctx.Application.Path))
+			Expect(e.Args).To(Equal([]string{"-XX:SharedArchiveFile=application.jsa", "-Xshare:on", "-XX:+PrintSharedArchiveAndExit",
+				"-cp", "runner.jar:lib/test.jar", "com.example.Application"}))
+			Expect(buf.String()).To(ContainSubstring(fmt.Sprintf("Validated the CDS archive application.jsa when launched from %s", ctx.Application.Path)))
+			Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"]).To(Equal("true"))
+		})
+
+		it("keeps the classpath relative to the application with BP_JVM_CDS_TRAINING_DIR", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_DIR", t.TempDir())
+			executor.On("Execute", mock.MatchedBy(validation)).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			s := newSpringPerformance(false, true)
+			s.Classpath = []string{"runner.jar", "lib/test.jar"}
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(validations()).To(HaveLen(1))
+			Expect(validations()[0].Dir).To(Equal(ctx.Application.Path))
+			Expect(validations()[0].Args).To(ContainElement("runner.jar:lib/test.jar"))
+		})
+
+		it("fails when the archive records the classpath at other paths", func() {
+			executor.On("Execute", mock.MatchedBy(validation)).Run(func(args mock.Arguments) {
+				_, err := args.Get(0).(effect.Execution).Stderr.Write([]byte("An error has occurred while processing the shared archive file.\nshared class paths mismatch\n"))
+				Expect(err).NotTo(HaveOccurred())
+			}).Return(fmt.Errorf("exit status 1"))
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(boot.ArchiveRejected))
+			Expect(err).To(MatchError(ContainSubstring("shared class paths mismatch")))
+		})
+
+		it("warns and disables the archive when BP_JVM_CDS_REQUIRED is false", func() {
+			t.Setenv("BP_JVM_CDS_REQUIRED", "false")
+			t.Setenv("BP_JVM_CDS_BENCHMARK", "true")
+			executor.On("Execute", mock.MatchedBy(validation)).Return(fmt.Errorf("exit status 1"))
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(validations()).To(HaveLen(1))
+			Expect(buf.String()).NotTo(ContainSubstring("CDS benchmark"))
+			Expect(buf.String()).To(ContainSubstring("WARNING: CDS archive is rejected at launch, continuing without CDS as BP_JVM_CDS_REQUIRED is false"))
+			Expect(buf.String()).To(ContainSubstring("CDS is not active at launch"))
+			Expect(layer.LaunchEnvironment).NotTo(HaveKey("BPL_JVM_CDS_ENABLED.default"))
+			Expect(layer.Profile).NotTo(HaveKey("spring-performance.sh"))
+		})
+
+		it("does not validate the archive by default", func() {
+			t.Setenv("BP_JVM_CDS_VALIDATE_ARCHIVE", "false")
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(validations()).To(BeEmpty())
+		})
+	})
+
+	context("BP_JVM_CDS_VERIFY_EXTRACTION", func() {
+		extractWith := func(class string) {
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "extract")
+			})).Run(func(args mock.Arguments) {
+				e := args.Get(0).(effect.Execution)
+				writeJarEntries(t, filepath.Join(e.Args[len(e.Args)-1], "runner.jar"), map[string]string{
+					"com/example/Application.class": class,
+				})
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+		}
+
+		it.Before(func() {
+			t.Setenv("BP_JVM_CDS_VERIFY_EXTRACTION", "true")
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "BOOT-INF/classes/com/example"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "BOOT-INF/classes/com/example/Application.class"), []byte("application"), 0644)).To(Succeed())
+		})
+
+		it("accepts a matching extraction", func() {
+			extractWith("application")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(executor.Calls).To(HaveLen(3))
+		})
+
+		it("fails on a tampered extraction", func() {
+			extractWith("tampered")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("error verifying extraction")))
+			Expect(err).To(MatchError(ContainSubstring("com/example/Application.class: expected sha256:")))
+			Expect(executor.Calls).To(HaveLen(1))
+		})
+	})
+
+	context("BP_JVM_CDS_WRITE_FILELIST", func() {
+		it.Before(func() {
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "extract")
+			})).Run(func(args mock.Arguments) {
+				e := args.Get(0).(effect.Execution)
+				dir := e.Args[len(e.Args)-1]
+				Expect(os.MkdirAll(filepath.Join(dir, "lib"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(dir, "lib", "spring-core-6.1.10.jar"), []byte("core"), 0644)).To(Succeed())
+				writeJarWithManifest(t, filepath.Join(dir, "runner.jar"), "Class-Path: lib/spring-core-6.1.10.jar\n")
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("writes the sorted listing of the extracted files to the debug directory", func() {
+			t.Setenv("BP_JVM_CDS_WRITE_FILELIST", "true")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			b, err := os.ReadFile(filepath.Join(layer.Path, "debug", boot.ExtractedFileListName))
+			Expect(err).NotTo(HaveOccurred())
+			lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+			Expect(lines).To(HaveLen(2))
+			Expect(lines[0]).To(HavePrefix("lib/spring-core-6.1.10.jar 4 "))
+			Expect(lines[1]).To(HavePrefix("runner.jar "))
+		})
+
+		it("does not write the listing by default", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Join(layer.Path, "debug", boot.ExtractedFileListName)).NotTo(BeAnExistingFile())
+		})
+	})
+
+	context("BP_JVM_CDS_WRITE_CONFIG", func() {
+		it.Before(func() {
+			stubJavaVersion(executor, "21.0.4")
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "extract")
+			})).Run(func(args mock.Arguments) {
+				e := args.Get(0).(effect.Execution)
+				dir := e.Args[len(e.Args)-1]
+				Expect(os.MkdirAll(filepath.Join(dir, "lib"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(dir, "lib", "spring-core-6.1.10.jar"), []byte{}, 0644)).To(Succeed())
+				writeJarWithManifest(t, filepath.Join(dir, "runner.jar"), "Class-Path: lib/spring-core-6.1.10.jar\n")
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("writes the resolved configuration to the debug directory", func() {
+			t.Setenv("BP_JVM_CDS_WRITE_CONFIG", "true")
+			t.Setenv("BP_JVM_CDS_TRAINING_PROFILES", "cloud,kafka")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			b, err := os.ReadFile(filepath.Join(layer.Path, "debug", boot.ResolvedConfigName))
+			Expect(err).NotTo(HaveOccurred())
+			var config struct {
+				Variables        map[string]string `json:"variables"`
+				StartClass       string            `json:"start_class"`
+				ClasspathEntries int               `json:"classpath_entries"`
+				ClasspathLength  int               `json:"classpath_length"`
+				JDKVersion       string            `json:"jdk_version"`
+			}
+			Expect(json.Unmarshal(b, &config)).To(Succeed())
+
+			Expect(config.Variables).To(HaveLen(len(boot.KnownPerformanceVariables)))
+			Expect(config.Variables).To(HaveKeyWithValue("BP_JVM_CDS_TRAINING_PROFILES", "cloud,kafka"))
+			Expect(config.Variables).To(HaveKeyWithValue("BP_JVM_CDS_REQUIRED", "true"))
+			Expect(config.Variables).To(HaveKeyWithValue("BP_JVM_CDS_WARMUP_ITERATIONS", "1"))
+			Expect(config.Variables).To(HaveKeyWithValue("BP_JVM_CDS_DUMP_GRACE", "0s"))
+			Expect(config.Variables).To(HaveKeyWithValue("BP_JVM_CDS_ENABLED", "true"))
+			Expect(config.Variables).To(HaveKeyWithValue("BP_SPRING_AOT_ENABLED", "false"))
+			Expect(config.StartClass).To(Equal("com.example.Application"))
+			Expect(config.ClasspathEntries).To(Equal(2))
+			Expect(config.ClasspathLength).To(Equal(len("runner.jar:lib/spring-core-6.1.10.jar")))
+			Expect(config.JDKVersion).To(Equal("21.0.4"))
+		})
+
+		it("does not write the resolved configuration by default", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Join(layer.Path, "debug", boot.ResolvedConfigName)).NotTo(BeAnExistingFile())
+		})
+	})
+
+	context("training run does not create an archive", func() {
+		it.Before(func() {
+			noArchive = true
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("fails the build", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring(
+				fmt.Sprintf("did not create the CDS archive %s", filepath.Join(ctx.Application.Path, "application.jsa")))))
+			Expect(err).To(MatchError(boot.ArchiveMissing))
+		})
+
+		it("warns when BP_JVM_CDS_WARN_MISSING_ARCHIVE is set", func() {
+			t.Setenv("BP_JVM_CDS_WARN_MISSING_ARCHIVE", "true")
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buf.String()).To(ContainSubstring(
+				fmt.Sprintf("WARNING: training run succeeded but did not create the CDS archive %s", filepath.Join(ctx.Application.Path, "application.jsa"))))
+			Expect(layer.LaunchEnvironment).NotTo(HaveKey("BPL_JVM_CDS_ENABLED.default"))
+		})
+
+		it("continues without CDS when BP_JVM_CDS_REQUIRED is false", func() {
+			t.Setenv("BP_JVM_CDS_REQUIRED", "false")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(layer.LaunchEnvironment).NotTo(HaveKey("BPL_JVM_CDS_ENABLED.default"))
+		})
+	})
+
+	context("BP_JVM_CDS_CLASSPATH_PREPEND and BP_JVM_CDS_CLASSPATH_APPEND", func() {
+		var stubs, overrides string
+
+		it.Before(func() {
+			stubs = filepath.Join(t.TempDir(), "stubs.jar")
+			Expect(os.WriteFile(stubs, []byte{}, 0644)).To(Succeed())
+			overrides = t.TempDir()
+		})
+
+		it("adds the entries to the front and the back of the training run classpath", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+			extra := filepath.Join(t.TempDir(), "extra.jar")
+			Expect(os.WriteFile(extra, []byte{}, 0644)).To(Succeed())
+			t.Setenv("BP_JVM_CDS_CLASSPATH_PREPEND", stubs)
+			t.Setenv("BP_JVM_CDS_CLASSPATH_APPEND", strings.Join([]string{overrides, extra}, string(filepath.ListSeparator)))
+
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+			s.Classpath = []string{"runner.jar"}
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args[slices.Index(e.Args, "-cp")+1]).To(Equal(strings.Join([]string{stubs, "runner.jar", overrides, extra}, string(filepath.ListSeparator))))
+			Expect(buf.String()).To(ContainSubstring("Classpath at launch will start with the BP_JVM_CDS_CLASSPATH_PREPEND entries too, for the CDS archive to be used"))
+		})
+
+		it("launches the archive validation with the classpath prefix of the training run", func() {
+			t.Setenv("BP_JVM_CDS_CLASSPATH_PREPEND", stubs)
+			t.Setenv("BP_JVM_CDS_CLASSPATH_APPEND", overrides)
+			t.Setenv("BP_JVM_CDS_VALIDATE_ARCHIVE", "true")
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			s := newSpringPerformance(false, true)
+			s.Classpath = []string{"runner.jar", "lib/test.jar"}
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			training, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			var validation effect.Execution
+			for _, c := range executor.Calls {
+				if e := c.Arguments[0].(effect.Execution); slices.Contains(e.Args, "-XX:+PrintSharedArchiveAndExit") {
+					validation = e
+				}
+			}
+			launchClasspath := validation.Args[slices.Index(validation.Args, "-cp")+1]
+			Expect(launchClasspath).To(Equal(strings.Join([]string{stubs, "runner.jar", "lib/test.jar"}, string(filepath.ListSeparator))))
+			Expect(training.Args[slices.Index(training.Args, "-cp")+1]).To(HavePrefix(launchClasspath + string(filepath.ListSeparator)))
+		})
+
+		it("resolves relative entries against the extracted application", func() {
+			t.Setenv("BP_JVM_CDS_CLASSPATH_APPEND", "overrides")
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return e.Args[0] == "-Djarmode=tools"
+			})).Run(func(args mock.Arguments) {
+				Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "overrides"), 0755)).To(Succeed())
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args[slices.Index(e.Args, "-cp")+1]).To(HaveSuffix(filepath.Join(ctx.Application.Path, "overrides")))
+		})
+
+		it("fails when an entry does not exist", func() {
+			t.Setenv("BP_JVM_CDS_CLASSPATH_PREPEND", filepath.Join(overrides, "missing.jar"))
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("BP_JVM_CDS_CLASSPATH_PREPEND entry %s does not exist", filepath.Join(overrides, "missing.jar")))))
+			Expect(err).To(MatchError(boot.ValidationFailed))
+		})
+	})
+
+	context("cds_active", func() {
+		contribute := func(cdsEnabled bool) (libcnb.Layer, string) {
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, cdsEnabled)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			return layer, buf.String()
+		}
+
+		it("is true once the archive is created and enabled at launch", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, output := contribute(true)
+
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.CDSActiveMetadata, true))
+			Expect(output).To(ContainSubstring(fmt.Sprintf("CDS is active at launch with the archive %s", filepath.Join(ctx.Application.Path, "application.jsa"))))
+		})
+
+		it("is false when the training run is skipped", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, output := contribute(false)
+
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.CDSActiveMetadata, false))
+			Expect(output).NotTo(ContainSubstring("CDS is"))
+		})
+
+		it("is false when the training run fails and BP_JVM_CDS_REQUIRED is false", func() {
+			t.Setenv("BP_JVM_CDS_REQUIRED", "false")
+			noArchive = true
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "-Dspring.context.exit=onRefresh")
+			})).Return(fmt.Errorf("test-error"))
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, output := contribute(true)
+
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.CDSActiveMetadata, false))
+			Expect(output).To(ContainSubstring("CDS is not active at launch"))
+		})
+
+		it("is false when the training run does not create the archive and BP_JVM_CDS_WARN_MISSING_ARCHIVE is true", func() {
+			t.Setenv("BP_JVM_CDS_WARN_MISSING_ARCHIVE", "true")
+			noArchive = true
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, output := contribute(true)
+
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.CDSActiveMetadata, false))
+			Expect(output).To(ContainSubstring("CDS is not active at launch"))
+		})
+	})
+
+	context("provenance", func() {
+		it.Before(func() {
+			stubJavaVersion(executor, "21.0.3")
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("records the optimizations and the JDK of the training run", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(true, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.OptimizationsMetadata, []string{"aot", "cds"}))
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.JDKVersionMetadata, "21.0.3"))
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.JDKVendorMetadata, "Eclipse Adoptium"))
+		})
+
+		it("records no optimization when the training run is skipped", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, false).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.OptimizationsMetadata, BeEmpty()))
+			Expect(layer.Metadata).NotTo(HaveKey(boot.JDKVersionMetadata))
+			Expect(layer.Metadata).NotTo(HaveKey(boot.JDKVendorMetadata))
+		})
+
+		it("keeps the JDK of a completed contribution", func() {
+			first, second := newSpringPerformance(false, true), newSpringPerformance(false, true)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = first.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			// the extracted jar recorded by the marker is created as the mocked extraction would
+			b, err := os.ReadFile(filepath.Join(layer.Path, boot.CompletionMarkerName))
+			Expect(err).NotTo(HaveOccurred())
+			marker := map[string]interface{}{}
+			Expect(json.Unmarshal(b, &marker)).To(Succeed())
+			Expect(os.WriteFile(marker["extracted_jar"].(string), []byte{}, 0644)).To(Succeed())
+			calls := len(executor.Calls)
+
+			layer, err = ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+			layer, result, err := second.ContributeWithResult(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executor.Calls).To(HaveLen(calls))
+			Expect(result.JDKVendor).To(Equal("Eclipse Adoptium"))
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.OptimizationsMetadata, []string{"cds"}))
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.JDKVersionMetadata, "21.0.3"))
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.JDKVendorMetadata, "Eclipse Adoptium"))
+		})
+	})
+
+	context("training run fails", func() {
+		it.Before(func() {
+			noArchive = true
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "-Dspring.context.exit=onRefresh")
+			})).Return(fmt.Errorf("test-error"))
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("fails the build by default", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("test-error")))
+			Expect(err).To(MatchError(boot.TrainingFailed))
+		})
+
+		it("logs a hint to verify the application starts", func() {
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(errorHint(err)).To(Equal(boot.HintTrainingRun))
+			Expect(buf.String()).To(ContainSubstring("HINT: verify your app can start with -Dspring.context.exit=onRefresh"))
+		})
+
+		it("hints at the Start-Class when the manifest does not contain one", func() {
+			s := newSpringPerformance(false, true)
+			s.Manifest.Delete("Start-Class")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(errorHint(err)).To(Equal(boot.HintStartClass))
+		})
+
+		it("logs the failure but not the progress when quiet", func() {
+			t.Setenv("BP_JVM_CDS_REQUIRED", "false")
+			t.Setenv("BP_SPRING_PERFORMANCE_LOG_LEVEL", "quiet")
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buf.String()).To(ContainSubstring("WARNING: CDS training run failed"))
+			Expect(buf.String()).To(ContainSubstring("HINT: " + boot.HintTrainingRun))
+			Expect(buf.String()).NotTo(ContainSubstring("Extracting Jar"))
+		})
+
+		it("returns the error when quiet", func() {
+			t.Setenv("BP_SPRING_PERFORMANCE_LOG_LEVEL", "quiet")
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("test-error")))
+			Expect(buf.String()).NotTo(ContainSubstring("Extracting Jar"))
+		})
+
+		it("logs the hint when BP_JVM_CDS_REQUIRED is false", func() {
+			t.Setenv("BP_JVM_CDS_REQUIRED", "false")
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buf.String()).To(ContainSubstring("HINT: " + boot.HintTrainingRun))
+		})
+
+		it("continues without CDS when BP_JVM_CDS_REQUIRED is false", func() {
+			t.Setenv("BP_JVM_CDS_REQUIRED", "false")
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(true, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buf.String()).To(ContainSubstring("WARNING: CDS training run failed, continuing without CDS"))
+			Expect(layer.LaunchEnvironment).NotTo(HaveKey("BPL_JVM_CDS_ENABLED.default"))
+			Expect(layer.LaunchEnvironment["BPL_SPRING_AOT_ENABLED.default"]).To(Equal("true"))
+		})
+
+		it("contributes no launch environment when AOT is disabled and BP_JVM_CDS_REQUIRED is false", func() {
+			t.Setenv("BP_JVM_CDS_REQUIRED", "false")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layer.LaunchEnvironment).NotTo(HaveKey("BPL_SPRING_AOT_ENABLED.default"))
+			Expect(layer.LaunchEnvironment).NotTo(HaveKey("BPL_JVM_CDS_ENABLED.default"))
+		})
+	})
+
+	context("training run fails with output", func() {
+		it.Before(func() {
+			noArchive = true
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "-Dspring.context.exit=onRefresh")
+			})).Run(func(args mock.Arguments) {
+				e := args.Get(0).(effect.Execution)
+				for i := 1; i <= 30; i++ {
+					_, err := fmt.Fprintf(e.Stdout, "output line %d\n", i)
+					Expect(err).NotTo(HaveOccurred())
+				}
+				_, err := fmt.Fprintln(e.Stderr, "Error: Could not find or load main class com.example.Application")
+				Expect(err).NotTo(HaveOccurred())
+			}).Return(fmt.Errorf("exit status 1"))
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("includes the tail of the output in the error", func() {
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("training run output ends with:\noutput line 12\n")))
+			Expect(err).To(MatchError(ContainSubstring("output line 30\nError: Could not find or load main class com.example.Application\nexit status 1")))
+			Expect(err).NotTo(MatchError(ContainSubstring("output line 11\n")))
+			Expect(errorHint(err)).To(Equal(boot.HintTrainingRun))
+
+			// the whole output is still streamed to the build log
+			Expect(buf.String()).To(ContainSubstring("output line 1\n"))
+		})
+	})
+
+	context("BP_JVM_CDS_SHUTDOWN_TIMEOUT", func() {
+		// the application blocks in a shutdown hook, the watchdog reports it and halts the JVM if forced
+		blockingShutdown := func(report string) {
+			noArchive = true
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.ContainsFunc(e.Args, func(arg string) bool { return strings.HasSuffix(arg, boot.ShutdownWatchdogName) })
+			})).Run(func(args mock.Arguments) {
+				e := args.Get(0).(effect.Execution)
+				_, err := e.Stdout.Write([]byte("Started Application in 1.5 seconds\n"))
+				Expect(err).NotTo(HaveOccurred())
+				_, err = e.Stderr.Write([]byte(report))
+				Expect(err).NotTo(HaveOccurred())
+				if strings.HasPrefix(report, "CDS training run halted") {
+					Expect(os.WriteFile(filepath.Join(e.Dir, "application.jsa"), []byte{}, 0644)).To(Succeed())
+				}
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+		}
+
+		it("runs the application through the shutdown watchdog", func() {
+			t.Setenv("BP_JVM_CDS_SHUTDOWN_TIMEOUT", "10s")
+			executor.On("Execute", mock.Anything).Return(nil)
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			watchdog := filepath.Join(layer.Path, "training", boot.ShutdownWatchdogName)
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args[len(e.Args)-4:]).To(Equal([]string{watchdog, "10000", "false", "com.example.Application"}))
+			Expect(e.Args).To(ContainElements("-Dspring.context.exit=onRefresh", "-XX:ArchiveClassesAtExit=application.jsa"))
+			Expect(buf.String()).To(ContainSubstring("Training run will report a shutdown taking longer than 10s"))
+
+			b, err := os.ReadFile(watchdog)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("public final class CdsShutdownWatchdog"))
+		})
+
+		it("halts the JVM of a blocked shutdown and keeps the archive dumped", func() {
+			t.Setenv("BP_JVM_CDS_FORCE_DUMP", "true")
+			blockingShutdown("CDS training run halted after a shutdown longer than 30000 ms\n")
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args[len(e.Args)-3 : len(e.Args)-1]).To(Equal([]string{"30000", "true"}))
+			Expect(buf.String()).To(ContainSubstring("Training run will halt the JVM to dump the CDS archive if its shutdown takes longer than 30s"))
+			Expect(buf.String()).To(ContainSubstring("WARNING: the shutdown of the training run took longer than BP_JVM_CDS_SHUTDOWN_TIMEOUT 30s, the JVM was halted to dump the CDS archive"))
+			Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"]).To(Equal("true"))
+		})
+
+		it("reports a slow shutdown with guidance", func() {
+			t.Setenv("BP_JVM_CDS_SHUTDOWN_TIMEOUT", "5s")
+			t.Setenv("BP_JVM_CDS_WARN_MISSING_ARCHIVE", "true")
+			blockingShutdown("CDS training run shutdown is taking longer than 5000 ms, a shutdown hook may block the dump of the archive\n")
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buf.String()).To(ContainSubstring("set BP_JVM_CDS_FORCE_DUMP=true to halt the JVM once it elapsed"))
+			Expect(buf.String()).NotTo(ContainSubstring("the JVM was halted"))
+		})
+
+		it("does not watch the shutdown of the warmup harness", func() {
+			t.Setenv("BP_JVM_CDS_SHUTDOWN_TIMEOUT", "5s")
+			t.Setenv("BP_JVM_CDS_WARMUP_ITERATIONS", "2")
+			executor.On("Execute", mock.Anything).Return(nil)
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buf.String()).To(ContainSubstring(fmt.Sprintf("BP_JVM_CDS_SHUTDOWN_TIMEOUT does not apply to %s", boot.WarmupHarnessName)))
+			Expect(filepath.Join(layer.Path, "training", boot.ShutdownWatchdogName)).NotTo(BeAnExistingFile())
+		})
+	})
+
+	context("BP_JVM_CDS_WARMUP_ITERATIONS", func() {
+		it.Before(func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("runs the application through the warmup harness", func() {
+			t.Setenv("BP_JVM_CDS_WARMUP_ITERATIONS", "3")
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			harness := filepath.Join(layer.Path, "training", boot.WarmupHarnessName)
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args[len(e.Args)-3:]).To(Equal([]string{harness, "3", "com.example.Application"}))
+			Expect(e.Args).To(ContainElement("-XX:ArchiveClassesAtExit=application.jsa"))
+			Expect(e.Args).NotTo(ContainElement("-Dspring.context.exit=onRefresh"))
+			Expect(buf.String()).To(ContainSubstring("Training run will refresh the application context 3 times"))
+
+			b, err := os.ReadFile(harness)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("public final class CdsWarmup"))
+		})
+
+		it("exits on the first refresh by default", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).To(ContainElement("-Dspring.context.exit=onRefresh"))
+			Expect(e.Args[len(e.Args)-1]).To(Equal("com.example.Application"))
+			Expect(filepath.Join(layer.Path, "training", boot.WarmupHarnessName)).NotTo(BeAnExistingFile())
+		})
+	})
+
+	context("training run sandbox", func() {
+		trainingRun := func() effect.Execution {
+			for _, c := range executor.Calls {
+				e := c.Arguments[0].(effect.Execution)
+				if slices.Contains(e.Args, "-Dspring.context.exit=onRefresh") {
+					return e
+				}
+			}
+			t.Fatal("no training run")
+			return effect.Execution{}
+		}
+
+		it.Before(func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("runs the training run without network access", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_NETWORK", "false")
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e := trainingRun()
+			Expect(e.Command).To(Equal("unshare"))
+			Expect(e.Args[:3]).To(Equal([]string{"--net", "--map-root-user", "java"}))
+			Expect(e.Args).To(ContainElement("-XX:ArchiveClassesAtExit=application.jsa"))
+			Expect(buf.String()).To(ContainSubstring("Training run will not have network access, it will be wrapped by unshare --net --map-root-user"))
+		})
+
+		it("wraps the training run in BP_JVM_CDS_TRAINING_SANDBOX", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_SANDBOX", "/usr/bin/sandbox --profile build")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e := trainingRun()
+			Expect(e.Command).To(Equal("/usr/bin/sandbox"))
+			Expect(e.Args[:3]).To(Equal([]string{"--profile", "build", "java"}))
+		})
+
+		it("wraps the entrypoint", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_SANDBOX", "sandbox")
+			entrypoint := filepath.Join(ctx.Application.Path, "train.sh")
+			Expect(os.WriteFile(entrypoint, []byte("#!/bin/sh\n"), 0755)).To(Succeed())
+			t.Setenv("BP_JVM_CDS_TRAINING_ENTRYPOINT", "train.sh")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e := trainingRun()
+			Expect(e.Command).To(Equal("sandbox"))
+			Expect(filepath.Base(e.Args[0])).To(Equal("train.sh"))
+		})
+
+		it("wraps the launches of the benchmark", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_NETWORK", "false")
+			t.Setenv("BP_JVM_CDS_BENCHMARK", "true")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executor.Calls).To(HaveLen(5))
+			for _, c := range executor.Calls[2:4] {
+				Expect(c.Arguments[0].(effect.Execution).Command).To(Equal("unshare"))
+			}
+		})
+
+		it("does not wrap the commands of the buildpack", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_NETWORK", "false")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[0].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Command).To(Equal("java"))
+			Expect(e.Args[0]).To(Equal("-Djarmode=tools"))
+		})
+	})
+
+	context("the sandbox cannot be found", func() {
+		it.Before(func() {
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return e.Command == "unshare"
+			})).Return(fmt.Errorf("unable to start\n%w", exec.ErrNotFound))
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("hints at the sandbox", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_NETWORK", "false")
+			noArchive = true
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(errorHint(err)).To(Equal(boot.HintSandbox))
+			Expect(err).To(MatchError(boot.TrainingFailed))
+			Expect(err).NotTo(MatchError(boot.JavaNotFound))
+		})
+	})
+
+	context("java cannot be found", func() {
+		it.Before(func() {
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "-Dspring.context.exit=onRefresh")
+			})).Return(fmt.Errorf("unable to start\n%w", exec.ErrNotFound))
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("hints at the JDK", func() {
+			noArchive = true
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(errorHint(err)).To(Equal(boot.HintJDK))
+			Expect(buf.String()).To(ContainSubstring("HINT: " + boot.HintJDK))
+			Expect(err).To(MatchError(boot.JavaNotFound))
+			Expect(err).NotTo(MatchError(boot.TrainingFailed))
+		})
+	})
+
+	context("BP_JVM_CDS_MAX_EXTRACT_BYTES", func() {
+		it.Before(func() {
+			// the extracted layout holds a library of 4KiB
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return e.Args[0] == "-Djarmode=tools"
+			})).Run(func(args mock.Arguments) {
+				Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "lib"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "lib", "huge.jar"), make([]byte, 4096), 0644)).To(Succeed())
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("fails when the extracted layout exceeds the cap", func() {
+			t.Setenv("BP_JVM_CDS_MAX_EXTRACT_BYTES", "1024")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("is 4096 bytes, more than BP_JVM_CDS_MAX_EXTRACT_BYTES 1024")))
+			Expect(err).To(MatchError(boot.ExtractFailed))
+			Expect(executor.Calls).To(HaveLen(1))
+		})
+
+		it("performs the training run when the extracted layout is within the cap", func() {
+			t.Setenv("BP_JVM_CDS_MAX_EXTRACT_BYTES", "4096")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	context("BP_JVM_CDS_DISK_MULTIPLIER", func() {
+		var free uint64
+
+		it.Before(func() {
+			// the application holds a library of 4KiB
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "BOOT-INF/lib/huge.jar"), make([]byte, 4096), 0644)).To(Succeed())
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		newSpringPerformance := func() boot.SpringPerformance {
+			s := newSpringPerformance(false, true)
+			s.FreeDisk = func(path string) (uint64, error) {
+				Expect(path).To(Equal(ctx.Application.Path))
+				return free, nil
+			}
+			return s
+		}
+
+		it("fails before modifying the application without enough free disk space", func() {
+			t.Setenv("BP_JVM_CDS_DISK_MULTIPLIER", "2")
+			free = 4096
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance().Contribute(layer)
+			Expect(err).To(MatchError(boot.ErrInsufficientDisk))
+			Expect(err).To(MatchError(ContainSubstring("bytes needed, 4096 bytes available")))
+			Expect(err).To(MatchError(boot.ValidationFailed))
+			Expect(executor.Calls).To(BeEmpty())
+			Expect(filepath.Join(ctx.Application.Path, "BOOT-INF/lib/huge.jar")).To(BeARegularFile())
+		})
+
+		it("performs the training run with enough free disk space", func() {
+			free = 1 << 20
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance().Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it("does not check the free disk space with a multiplier of 0", func() {
+			t.Setenv("BP_JVM_CDS_DISK_MULTIPLIER", "0")
+			free = 0
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance().Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it("performs the training run when the free disk space cannot be determined", func() {
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance()
+			s.Logger = bard.NewLogger(buf)
+			s.FreeDisk = func(string) (uint64, error) {
+				return 0, fmt.Errorf("test-error")
+			}
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buf.String()).To(ContainSubstring("Not checking free disk space"))
+		})
+	})
+
+	context("failure categories", func() {
+		extraction := func(err error) {
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return e.Args[0] == "-Djarmode=tools"
+			})).Return(err)
+			executor.On("Execute", mock.Anything).Return(nil)
+		}
+
+		it("categorizes a failed extraction", func() {
+			extraction(fmt.Errorf("exit status 1"))
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(boot.ExtractFailed))
+			category, ok := boot.ErrorCategory(err)
+			Expect(ok).To(BeTrue())
+			Expect(category).To(Equal(boot.ExtractFailed))
+		})
+
+		it("categorizes an extraction without java", func() {
+			extraction(fmt.Errorf("unable to start\n%w", exec.ErrNotFound))
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(boot.JavaNotFound))
+			Expect(err).NotTo(MatchError(boot.ExtractFailed))
+		})
+
+		it("categorizes an invalid application layout", func() {
+			extraction(nil)
+			s := newSpringPerformance(false, true)
+			s.ReZip = false
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).To(MatchError(boot.ValidationFailed))
+			Expect(executor.Calls).To(BeEmpty())
+		})
+	})
+
+	context("BP_JVM_CDS_TRAINING_ENTRYPOINT", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "cds-training.sh"), []byte(`#!/bin/sh
+echo "$CDS_TRAINING_JAVA" > "$PWD/training.out"
+echo "${CDS_TRAINING_ARGS-unset}" >> "$PWD/training.out"
+printf '%s\n' "$@" >> "$PWD/training.out"
+touch "$PWD/application.jsa"
+`), 0755)).To(Succeed())
+			t.Setenv("BP_JVM_CDS_TRAINING_ENTRYPOINT", "cds-training.sh")
+			noArchive = true
+		})
+
+		it("runs the training run through the entrypoint", func() {
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return filepath.Base(e.Command) == "cds-training.sh"
+			})).Run(func(args mock.Arguments) {
+				Expect(effect.CommandExecutor{}.Execute(args.Get(0).(effect.Execution))).To(Succeed())
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Command).To(Equal(filepath.Join(layer.Path, "training", "cds-training.sh")))
+
+			out, err := os.ReadFile(filepath.Join(ctx.Application.Path, "training.out"))
+			Expect(err).NotTo(HaveOccurred())
+			lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+			Expect(lines[0]).To(Equal("java"))
+			Expect(lines[1]).To(Equal("unset"))
+			Expect(lines[2:]).To(Equal(e.Args))
+			Expect(lines[2:]).To(ContainElement("-XX:ArchiveClassesAtExit=application.jsa"))
+		})
+
+		it("fails when the entrypoint is not executable", func() {
+			Expect(os.Chmod(filepath.Join(ctx.Application.Path, "cds-training.sh"), 0644)).To(Succeed())
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("is not an executable file")))
+			Expect(executor.Calls).To(BeEmpty())
+		})
+	})
+
+	context("BP_JVM_CDS_TRAINING_STDIN", func() {
+		var stdin string
+
+		it.Before(func() {
+			noArchive = true
+			// the training run reads its stdin to the end, as an application waiting for input would
+			executor.On("Execute", mock.MatchedBy(trainingRun)).Run(func(args mock.Arguments) {
+				e := args.Get(0).(effect.Execution)
+				Expect(e.Stdin).NotTo(BeNil())
+
+				out := &bytes.Buffer{}
+				cmd := exec.Command("cat")
+				cmd.Stdin, cmd.Stdout = e.Stdin, out
+				Expect(cmd.Start()).To(Succeed())
+				done := make(chan error, 1)
+				go func() { done <- cmd.Wait() }()
+				select {
+				case err := <-done:
+					Expect(err).NotTo(HaveOccurred())
+				case <-time.After(5 * time.Second):
+					Expect(cmd.Process.Kill()).To(Succeed())
+					t.Fatal("training run blocked reading stdin")
+				}
+				stdin = out.String()
+
+				Expect(os.WriteFile(filepath.Join(e.Dir, "application.jsa"), []byte{}, 0644)).To(Succeed())
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("gives the training run an empty stdin by default", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stdin).To(BeEmpty())
+		})
+
+		it("gives the training run the file of the application as stdin", func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "training-input.txt"), []byte("yes\n"), 0644)).To(Succeed())
+			t.Setenv("BP_JVM_CDS_TRAINING_STDIN", "training-input.txt")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stdin).To(Equal("yes\n"))
+		})
+
+		it("fails if the file does not exist", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_STDIN", "does-not-exist.txt")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("error resolving BP_JVM_CDS_TRAINING_STDIN")))
+		})
+	})
+
+	context("BP_JVM_CDS_CLASSLIST", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "application.classlist"), []byte("java/lang/Object\ncom/example/Application\n"), 0644)).To(Succeed())
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("passes the classlist copied into the layer to the training run", func() {
+			t.Setenv("BP_JVM_CDS_CLASSLIST", "application.classlist")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			classList := filepath.Join(layer.Path, "training", "application.classlist")
+			Expect(os.ReadFile(classList)).To(Equal([]byte("java/lang/Object\ncom/example/Application\n")))
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).To(ContainElement(fmt.Sprintf("-XX:SharedClassListFile=%s", classList)))
+		})
+
+		it("uses an absolute classlist as is", func() {
+			classList := filepath.Join(t.TempDir(), "application.classlist")
+			Expect(os.WriteFile(classList, []byte("java/lang/Object\n"), 0644)).To(Succeed())
+			t.Setenv("BP_JVM_CDS_CLASSLIST", classList)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).To(ContainElement(fmt.Sprintf("-XX:SharedClassListFile=%s", classList)))
+		})
+
+		it("fails when the classlist does not exist", func() {
+			t.Setenv("BP_JVM_CDS_CLASSLIST", "missing.classlist")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("error resolving BP_JVM_CDS_CLASSLIST")))
+			Expect(executor.Calls).To(BeEmpty())
+		})
+	})
+
+	context("BP_JVM_CDS_POST_EXTRACT_SCRIPT", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "post-extract.sh"), []byte(`#!/bin/sh
+touch "$PWD/post-extract.marker"
+`), 0755)).To(Succeed())
+			t.Setenv("BP_JVM_CDS_POST_EXTRACT_SCRIPT", "post-extract.sh")
+		})
+
+		it("runs the script against the extracted layout before the training run", func() {
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return filepath.Base(e.Command) == "post-extract.sh"
+			})).Run(func(args mock.Arguments) {
+				Expect(effect.NewExecutor().Execute(args.Get(0).(effect.Execution))).To(Succeed())
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executor.Calls).To(HaveLen(4))
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Command).To(Equal(filepath.Join(layer.Path, "training", "post-extract.sh")))
+			Expect(e.Dir).To(Equal(ctx.Application.Path))
+
+			info, err := os.Stat(filepath.Join(ctx.Application.Path, "post-extract.marker"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.ModTime().UTC()).To(Equal(boot.DefaultTimestamp))
+		})
+
+		it("fails the build when the script fails", func() {
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return filepath.Base(e.Command) == "post-extract.sh"
+			})).Return(fmt.Errorf("exit status 1"))
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("error running post-extraction script")))
+			Expect(executor.Calls).To(HaveLen(2))
+		})
+	})
+
+	it("fails with a corrupt Start-Class", func() {
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		s := newSpringPerformance(false, true)
+		s.Manifest.Set("Start-Class", "com.example.Application\r\n-XX:+Unlock")
+
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = s.Contribute(layer)
+		Expect(err).To(MatchError(ContainSubstring("manifest entry Start-Class contains the control character")))
+	})
+
+	it("trims the Start-Class", func() {
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		s := newSpringPerformance(false, true)
+		s.Manifest.Set("Start-Class", "com.example.Application  ")
+
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = s.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+		Expect(ok).To(BeTrue())
+		Expect(e.Args[len(e.Args)-1]).To(Equal("com.example.Application"))
+	})
+
+	context("BP_JVM_CDS_TRAINING_DIR", func() {
+		var trainingDir string
+
+		it.Before(func() {
+			var err error
+			trainingDir, err = os.MkdirTemp("", "spring-performance-training-dir")
+			Expect(err).NotTo(HaveOccurred())
+			t.Setenv("BP_JVM_CDS_TRAINING_DIR", trainingDir)
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(trainingDir)).To(Succeed())
+		})
+
+		it("runs the training run in the configured directory", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			s := newSpringPerformance(false, true)
+			s.Classpath = []string{"runner.jar", "lib/test.jar"}
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			extract, ok := executor.Calls[0].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(extract.Args).To(ContainElements("--destination", ctx.Application.Path))
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Dir).To(Equal(trainingDir))
+			Expect(e.Args).To(ContainElements(
+				"-XX:ArchiveClassesAtExit="+filepath.Join(ctx.Application.Path, "application.jsa"),
+				filepath.Join(ctx.Application.Path, "runner.jar")+":"+filepath.Join(ctx.Application.Path, "lib/test.jar"),
+			))
+			Expect(filepath.Join(ctx.Application.Path, "application.jsa")).To(BeARegularFile())
+		})
+
+		it("fails when the directory does not exist", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_DIR", filepath.Join(trainingDir, "does-not-exist"))
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("does-not-exist does not exist")))
+		})
+	})
+
+	context("BP_JVM_CDS_TRAINING_PROFILES", func() {
+		it("activates the profiles for the training run only", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_PROFILES", "prod,cloud")
+			t.Setenv("BP_JVM_CDS_BENCHMARK", "true")
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).To(ContainElement("-Dspring.profiles.active=prod,cloud"))
+			for _, c := range executor.Calls[2:] {
+				Expect(c.Arguments[0].(effect.Execution).Args).NotTo(ContainElement(HavePrefix("-Dspring.profiles.active")))
+			}
+			for _, v := range layer.LaunchEnvironment {
+				Expect(v).NotTo(ContainSubstring("spring.profiles.active"))
+			}
+		})
+
+		it("does not activate profiles by default", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).NotTo(ContainElement(HavePrefix("-Dspring.profiles.active")))
+		})
+	})
+
+	context("BP_JVM_CDS_ALLOWED_FLAGS", func() {
+		it.Before(func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("rejects a denied flag before the training run", func() {
+			s := newSpringPerformance(false, true)
+			_, _, err := s.Manifest.Set("Start-Class", "-javaagent:evil.jar")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("JVM flag -javaagent:evil.jar supplied by the training run configuration is denied")))
+			for _, c := range executor.Calls {
+				Expect(c.Arguments[0].(effect.Execution).Args).NotTo(ContainElement("-javaagent:evil.jar"))
+			}
+		})
+
+		it("rejects a denied flag returned by the customizer", func() {
+			s := newSpringPerformance(false, true)
+			s.ArgsCustomizer = func(args []string) []string {
+				return append([]string{"-agentlib:jdwp=transport=dt_socket"}, args...)
+			}
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("JVM flag -agentlib:jdwp=transport=dt_socket supplied by the training run configuration is denied")))
+			Expect(err).To(MatchError(boot.ValidationFailed))
+			for _, c := range executor.Calls {
+				Expect(c.Arguments[0].(effect.Execution).Args).NotTo(ContainElement(HavePrefix("-agentlib:")))
+			}
+		})
+
+		it("warns about a denied flag of CDS_TRAINING_JAVA_TOOL_OPTIONS", func() {
+			t.Setenv("CDS_TRAINING_JAVA_TOOL_OPTIONS", "-Dfoo=bar -javaagent:/agents/otel.jar")
+
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buf.String()).To(ContainSubstring("WARNING: JVM flag -javaagent:/agents/otel.jar supplied by CDS_TRAINING_JAVA_TOOL_OPTIONS is passed to the training run"))
+		})
+
+		it("warns about a denied flag of JAVA_TOOL_OPTIONS", func() {
+			t.Setenv("JAVA_TOOL_OPTIONS", "-XX:OnError=notify.sh")
+
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buf.String()).To(ContainSubstring("WARNING: JVM flag -XX:OnError=notify.sh supplied by JAVA_TOOL_OPTIONS is passed to the training run"))
+		})
+
+		it("warns about a denied flag of an options variable of BP_JVM_CDS_TRAINING_ENV", func() {
+			t.Setenv("JDK_JAVA_OPTIONS", "-javaagent:/agents/otel.jar")
+			t.Setenv("BP_JVM_CDS_TRAINING_ENV", "JDK_JAVA_OPTIONS")
+
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buf.String()).To(ContainSubstring("WARNING: JVM flag -javaagent:/agents/otel.jar supplied by JDK_JAVA_OPTIONS of BP_JVM_CDS_TRAINING_ENV is passed to the training run"))
+		})
+
+		it("does not warn about a denied flag of CDS_TRAINING_JAVA_TOOL_OPTIONS allowed explicitly", func() {
+			t.Setenv("CDS_TRAINING_JAVA_TOOL_OPTIONS", "-javaagent:/agents/otel.jar")
+			t.Setenv("BP_JVM_CDS_ALLOWED_FLAGS", "-javaagent:/agents/")
+
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buf.String()).NotTo(ContainSubstring("WARNING: JVM flag"))
+		})
+
+		it("accepts the flags of all the training run options", func() {
+			for _, name := range []string{"BP_JVM_CDS_TRAINING_ASSERTIONS", "BP_JVM_CDS_TRAINING_DEBUG", "BP_JVM_CDS_TRAINING_HEAPDUMP", "BP_JVM_CDS_TRAINING_JFR"} {
+				t.Setenv(name, "true")
+			}
+			t.Setenv("BP_JVM_CDS_TRAINING_PROFILES", "prod")
+			t.Setenv("BP_JVM_CDS_TRAINING_CPUS", "2")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(true, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	context("BP_JVM_CDS_DUMP_GRACE", func() {
+		it("waits for the archive to be written once the training run exited", func() {
+			t.Setenv("BP_JVM_CDS_DUMP_GRACE", "5s")
+			noArchive = true
+			done := make(chan struct{})
+			// the archive is only written once the training run returned
+			executor.On("Execute", mock.MatchedBy(trainingRun)).Run(func(args mock.Arguments) {
+				archive := filepath.Join(args.Get(0).(effect.Execution).Dir, "application.jsa")
+				go func() {
+					defer close(done)
+					time.Sleep(300 * time.Millisecond)
+					if err := os.WriteFile(archive, []byte("archive"), 0644); err != nil {
+						panic(err)
+					}
+				}()
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, result, err := newSpringPerformance(false, true).ContributeWithResult(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(done).To(BeClosed())
+			Expect(result.CDSApplied).To(BeTrue())
+			Expect(result.ArchiveSize).To(Equal(int64(len("archive"))))
+			Expect(layer.LaunchEnvironment).To(HaveKeyWithValue("BPL_JVM_CDS_ENABLED.default", "true"))
+		})
+	})
+
+	context("BP_SPRING_PERFORMANCE_CHECK_ONLY", func() {
+		it.Before(func() {
+			t.Setenv("BP_SPRING_PERFORMANCE_CHECK_ONLY", "true")
+		})
+
+		it("checks the application without contributing the layer", func() {
+			var extracted string
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return e.Args[0] == "-Djarmode=tools"
+			})).Run(func(args mock.Arguments) {
+				e := args.Get(0).(effect.Execution)
+				extracted = e.Args[len(e.Args)-1]
+				Expect(e.Args[2]).To(BeARegularFile())
+			}).Return(nil)
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, result, err := s.ContributeWithResult(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executor.Calls).To(HaveLen(1))
+			Expect(extracted).NotTo(HavePrefix(ctx.Application.Path))
+			Expect(extracted).NotTo(BeAnExistingFile())
+			Expect(layer.Path).NotTo(BeAnExistingFile())
+			Expect(layer.LayerTypes).To(Equal(libcnb.LayerTypes{}))
+			Expect(result).To(Equal(boot.PerformanceResult{}))
+			Expect(filepath.Join(ctx.Application.Path, "BOOT-INF/lib/spring-boot-jarmode-tools-3.3.1.jar")).To(BeARegularFile())
+			Expect(filepath.Join(ctx.Application.Path, "runner.jar")).NotTo(BeAnExistingFile())
+			manifest, err := os.ReadFile(filepath.Join(ctx.Application.Path, "META-INF", "MANIFEST.MF"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(manifest)).NotTo(ContainSubstring("Main-Class"))
+			Expect(buf.String()).To(ContainSubstring("Spring performance check passed"))
+		})
+
+		it("fails when the manifest does not contain Start-Class", func() {
+			s := newSpringPerformance(false, true)
+			s.Manifest.Delete("Start-Class")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("manifest does not contain Start-Class")))
+			Expect(err).To(MatchError(boot.ValidationFailed))
+			Expect(executor.Calls).To(BeEmpty())
+		})
+
+		it("fails when the Spring Boot version does not support the CDS extraction", func() {
+			s := newSpringPerformance(false, true)
+			_, _, err := s.Manifest.Set("Spring-Boot-Version", "3.2.5")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring(`Spring Boot "3.2.5" does not support the CDS extraction`)))
+			Expect(executor.Calls).To(BeEmpty())
+		})
+
+		it("fails when the extraction fails", func() {
+			executor.On("Execute", mock.Anything).Return(fmt.Errorf("exit status 1"))
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("spring performance check failed")))
+			Expect(err).To(MatchError(boot.ExtractFailed))
+			Expect(layer.Path).NotTo(BeAnExistingFile())
+		})
+	})
+
+	context("BP_JVM_CDS_CACHE_LAYOUT", func() {
+		extraction := func(e effect.Execution) bool {
+			return e.Args[0] == "-Djarmode=tools"
+		}
+
+		it.Before(func() {
+			t.Setenv("BP_JVM_CDS_CACHE_LAYOUT", "true")
+			stubJavaVersion(executor, "21.0.3")
+			executor.On("Execute", mock.MatchedBy(extraction)).Run(func(args mock.Arguments) {
+				Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "lib"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "lib", "test.jar"), []byte("test"), 0644)).To(Succeed())
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		count := func(matches func(effect.Execution) bool) int {
+			var n int
+			for _, c := range executor.Calls {
+				if matches(c.Arguments[0].(effect.Execution)) {
+					n++
+				}
+			}
+			return n
+		}
+
+		it("caches the extracted layout and the archive in a cache layer", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layer.Cache).To(BeTrue())
+			Expect(filepath.Join(layer.Path, boot.CachedLayoutDir, "lib", "test.jar")).To(BeARegularFile())
+			Expect(filepath.Join(layer.Path, boot.CachedArchiveName)).To(BeARegularFile())
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.LayoutKeyMetadata, MatchRegexp(`^[0-9a-f]{64}$`)))
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.LayoutDigestMetadata, MatchRegexp(`^[0-9a-f]{64}$`)))
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.LayoutJarMetadata, "runner.jar"))
+		})
+
+		it("restores the layout and the archive cached by a previous build", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			digest := layer.Metadata[boot.LayoutDigestMetadata]
+
+			buf := &bytes.Buffer{}
+			explode()
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(count(extraction)).To(Equal(1))
+			Expect(trainingRuns()).To(Equal(1))
+			Expect(buf.String()).To(ContainSubstring("Skipping the extraction, using the layout cached by a previous build"))
+			Expect(buf.String()).To(ContainSubstring("Skipping the training run, using the CDS archive cached by a previous build"))
+			Expect(filepath.Join(ctx.Application.Path, "lib", "test.jar")).To(BeARegularFile())
+			Expect(filepath.Join(ctx.Application.Path, "BOOT-INF")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(ctx.Application.Path, "application.jsa")).To(BeARegularFile())
+			Expect(filepath.Join(layer.Path, "runner.jar")).To(BeARegularFile())
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.LayoutDigestMetadata, digest))
+			Expect(layer.LaunchEnvironment).To(HaveKeyWithValue("BPL_JVM_CDS_ENABLED.default", "true"))
+		})
+
+		it("extracts the application again when it changed", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			explode()
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "BOOT-INF/classes"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "BOOT-INF/classes/application.properties"), []byte("test=true"), 0644)).To(Succeed())
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(count(extraction)).To(Equal(2))
+			Expect(trainingRuns()).To(Equal(2))
+		})
+
+		it("extracts the application again when the cached layout is not the one recorded", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(layer.Path, boot.CachedLayoutDir, "lib", "test.jar"), []byte("tampered"), 0644)).To(Succeed())
+
+			buf := &bytes.Buffer{}
+			explode()
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(count(extraction)).To(Equal(2))
+			Expect(buf.String()).To(ContainSubstring("is not the one recorded"))
+		})
+	})
+
+	context("BP_JVM_CDS_SELF_TEST", func() {
+		it.Before(func() {
+			t.Setenv("BP_JVM_CDS_SELF_TEST", "true")
+		})
+
+		selfTest := func(e effect.Execution) bool {
+			return slices.Contains(e.Args, "-XX:+PrintFlagsFinal")
+		}
+
+		it("fails before the training run without a capable JDK", func() {
+			executor.On("Execute", mock.MatchedBy(selfTest)).Run(func(args mock.Arguments) {
+				_, err := fmt.Fprintf(args.Get(0).(effect.Execution).Stderr, "    java.version = 11.0.22\n")
+				Expect(err).NotTo(HaveOccurred())
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("CDS self-test failed")))
+			Expect(err).To(MatchError(boot.ValidationFailed))
+			Expect(executor.Calls).To(HaveLen(1))
+		})
+
+		it("contributes once the self-test passed", func() {
+			executor.On("Execute", mock.MatchedBy(selfTest)).Run(func(args mock.Arguments) {
+				_, err := fmt.Fprintf(args.Get(0).(effect.Execution).Stdout, "    java.version = 21.0.3\n     ccstr ArchiveClassesAtExit                     =                                           {product} {default}\n")
+				Expect(err).NotTo(HaveOccurred())
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(executor.Calls[0].Arguments[0].(effect.Execution).Args).To(ContainElement("-XX:+PrintFlagsFinal"))
+		})
+	})
+
+	context("BP_JVM_CDS_CACHE_ARCHIVE", func() {
+		it.Before(func() {
+			t.Setenv("BP_JVM_CDS_CACHE_ARCHIVE", "true")
+			stubJavaVersion(executor, "21.0.3")
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("caches the archive and records it in the layer metadata", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layer.Cache).To(BeTrue())
+			digest, err := boot.FileDigest(filepath.Join(layer.Path, boot.CachedArchiveName))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.ArchiveDigestMetadata, digest))
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.ArchiveKeyMetadata, MatchRegexp(`^[0-9a-f]{64}$`)))
+		})
+
+		it("skips the training run with the archive cached by a previous build", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			key := layer.Metadata[boot.ArchiveKeyMetadata]
+
+			buf := &bytes.Buffer{}
+			explode()
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(trainingRuns()).To(Equal(1))
+			Expect(buf.String()).To(ContainSubstring("Skipping the training run, using the CDS archive cached by a previous build"))
+			Expect(filepath.Join(ctx.Application.Path, "application.jsa")).To(BeARegularFile())
+			Expect(filepath.Join(layer.Path, boot.CachedArchiveName)).To(BeARegularFile())
+			Expect(layer.Metadata).To(HaveKeyWithValue(boot.ArchiveKeyMetadata, key))
+			Expect(layer.LaunchEnvironment).To(HaveKeyWithValue("BPL_JVM_CDS_ENABLED.default", "true"))
+		})
+
+		it("performs the training run when the application changed", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			key := layer.Metadata[boot.ArchiveKeyMetadata]
+
+			explode()
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "BOOT-INF/classes"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "BOOT-INF/classes/application.properties"), []byte("test=true"), 0644)).To(Succeed())
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(trainingRuns()).To(Equal(2))
+			Expect(layer.Metadata[boot.ArchiveKeyMetadata]).NotTo(Equal(key))
+		})
+
+		it("performs the training run when the cached archive is not the one recorded", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(layer.Path, boot.CachedArchiveName), []byte("tampered"), 0644)).To(Succeed())
+
+			explode()
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trainingRuns()).To(Equal(2))
+		})
+
+		it("does not cache the archive by default", func() {
+			t.Setenv("BP_JVM_CDS_CACHE_ARCHIVE", "false")
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layer.Cache).To(BeFalse())
+			Expect(layer.Metadata).NotTo(HaveKey(boot.ArchiveKeyMetadata))
+			Expect(filepath.Join(layer.Path, boot.CachedArchiveName)).NotTo(BeAnExistingFile())
+
+			explode()
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trainingRuns()).To(Equal(2))
+		})
+	})
+
+	context("bundled archive", func() {
+		it.Before(func() {
+			fixture, err := os.ReadFile(filepath.Join("testdata", "cds", "bundled", boot.BundledArchiveName))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, filepath.Dir(boot.BundledArchiveName)), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, boot.BundledArchiveName), fixture, 0644)).To(Succeed())
+		})
+
+		it("uses an archive created by the JDK instead of a training run", func() {
+			stubJavaVersion(executor, "21.0.3")
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, result, err := newSpringPerformance(false, true).ContributeWithResult(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(trainingRuns()).To(BeZero())
+			archive, err := os.ReadFile(filepath.Join(ctx.Application.Path, "application.jsa"))
+			Expect(err).NotTo(HaveOccurred())
+			fixture, err := os.ReadFile(filepath.Join("testdata", "cds", "bundled", boot.BundledArchiveName))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(archive).To(Equal(fixture))
+			Expect(layer.LaunchEnvironment).To(HaveKeyWithValue("BPL_JVM_CDS_ENABLED.default", "true"))
+			Expect(result.CDSApplied).To(BeTrue())
+			Expect(result.TrainingDuration).To(BeZero())
+		})
+
+		it("performs the training run if the archive was created by another JDK", func() {
+			stubJavaVersion(executor, "17.0.11")
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(trainingRuns()).To(Equal(1))
+		})
+	})
+
+	context("lazy initialization", func() {
+		it.Before(func() {
+			fixture, err := os.ReadFile("testdata/lazy-initialization/application.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "BOOT-INF", "classes"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "BOOT-INF", "classes", "application.yml"), fixture, 0644)).To(Succeed())
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("warns that lazy initialization reduces the effectiveness of CDS", func() {
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buf.String()).To(ContainSubstring("WARNING: %s enables spring.main.lazy-initialization",
+				filepath.Join(ctx.Application.Path, "BOOT-INF", "classes", "application.yml")))
+		})
+
+		it("does not warn if the training run profiles disable lazy initialization", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_PROFILES", "eager")
+			buf := &bytes.Buffer{}
+			s := newSpringPerformance(false, true)
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buf.String()).NotTo(ContainSubstring("lazy-initialization"))
+		})
+	})
+
+	context("completion marker", func() {
+		// the extraction is mocked, the extracted jar recorded by the marker is created as the extraction would
+		extract := func(layer libcnb.Layer) {
+			b, err := os.ReadFile(filepath.Join(layer.Path, boot.CompletionMarkerName))
+			Expect(err).NotTo(HaveOccurred())
+			marker := map[string]interface{}{}
+			Expect(json.Unmarshal(b, &marker)).To(Succeed())
+			Expect(os.WriteFile(marker["extracted_jar"].(string), []byte{}, 0644)).To(Succeed())
+		}
+
+		it("skips a second contribution of a completed layer", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			first, second := newSpringPerformance(false, true), newSpringPerformance(false, true)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = first.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filepath.Join(layer.Path, boot.CompletionMarkerName)).To(BeARegularFile())
+			extract(layer)
+			calls := len(executor.Calls)
+
+			layer, err = ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+			layer, result, err := second.ContributeWithResult(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executor.Calls).To(HaveLen(calls))
+			Expect(layer.Build).To(BeTrue())
+			Expect(layer.Launch).To(BeTrue())
+			Expect(layer.LaunchEnvironment).To(HaveKeyWithValue("BPL_JVM_CDS_ENABLED.default", "true"))
+			Expect(layer.Profile).To(HaveKey("spring-performance.sh"))
+			Expect(result.CDSApplied).To(BeTrue())
+			Expect(result.ArchivePath).To(Equal(filepath.Join(ctx.Application.Path, "application.jsa")))
+			Expect(filepath.Join(ctx.Application.Path, "application.jsa")).To(BeARegularFile())
+		})
+
+		it("contributes the layer again if the archive is gone", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			first, second := newSpringPerformance(false, true), newSpringPerformance(false, true)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = first.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			extract(layer)
+			Expect(os.Remove(filepath.Join(ctx.Application.Path, "application.jsa"))).To(Succeed())
+
+			layer, err = ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = second.Contribute(layer)
+
+			// the application was removed by the first contribution, it cannot be re-zipped again
+			Expect(err).To(MatchError(ContainSubstring("unable to contribute spring-cds layer")))
+		})
+
+		it("contributes the layer again if the Spring AOT configuration changed", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			first, second := newSpringPerformance(false, true), newSpringPerformance(true, true)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = first.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			extract(layer)
+
+			layer, err = ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = second.Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("unable to contribute spring-cds layer")))
+		})
+	})
+
+	context("BP_JVM_CDS_TRAINING_DATASOURCE", func() {
+		it.Before(func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_DATASOURCE", "embedded")
+		})
+
+		it("completes the training run of an application needing a datasource", func() {
+			noArchive = true
+			// the refresh of the application context fails unless its datasource is connected to a database
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return slices.Contains(e.Args, "-XX:ArchiveClassesAtExit=application.jsa")
+			})).Return(func(e effect.Execution) error {
+				if !slices.Contains(e.Args, "-Dspring.datasource.url=jdbc:h2:mem:cds-training;DB_CLOSE_DELAY=-1") {
+					return fmt.Errorf("Failed to configure a DataSource: 'url' attribute is not specified")
+				}
+				return os.WriteFile(filepath.Join(e.Dir, "application.jsa"), []byte{}, 0644)
+			})
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			s := newSpringPerformance(false, true)
+			s.Classpath = []string{"lib/h2-2.2.224.jar"}
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).To(ContainElements("-Dspring.datasource.driver-class-name=org.h2.Driver", "-Dspring.datasource.username=sa"))
+			Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"]).To(Equal("true"))
+			for _, v := range layer.LaunchEnvironment {
+				Expect(v).NotTo(ContainSubstring("jdbc:h2:mem"))
+			}
+		})
+
+		it("fails without the H2 database on the classpath", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("BP_JVM_CDS_TRAINING_DATASOURCE=embedded needs the H2 database on the training run classpath")))
+			Expect(err).To(MatchError(boot.ValidationFailed))
+		})
+	})
+
+	context("BP_JVM_CDS_TRAINING_ENV", func() {
+		it("forwards the named variables to the training run only", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_ENV", "SPRING_CONFIG_LOCATION,TEST_NOT_SET")
+			t.Setenv("SPRING_CONFIG_LOCATION", "classpath:/training/")
+			t.Setenv("TEST_SECRET", "s3cr3t")
+			os.Unsetenv("TEST_NOT_SET")
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Env).To(ContainElement("SPRING_CONFIG_LOCATION=classpath:/training/"))
+			Expect(e.Env).NotTo(ContainElement(HavePrefix("TEST_SECRET=")))
+			Expect(e.Env).NotTo(ContainElement(HavePrefix("TEST_NOT_SET=")))
+			for _, v := range layer.LaunchEnvironment {
+				Expect(v).NotTo(ContainSubstring("classpath:/training/"))
+			}
+		})
+	})
+
+	context("BP_JVM_CDS_MIN_APP_CLASSES", func() {
+		var buf *bytes.Buffer
+
+		it.Before(func() {
+			t.Setenv("BP_JVM_CDS_MIN_APP_CLASSES", "3")
+			buf = &bytes.Buffer{}
+		})
+
+		// the extraction lays out the classpath of the training run, a jar of the given number of classes
+		extract := func(classes int) {
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return e.Args[0] == "-Djarmode=tools"
+			})).Run(func(args mock.Arguments) {
+				entries := map[string]string{"META-INF/MANIFEST.MF": ""}
+				for i := 0; i < classes; i++ {
+					entries[fmt.Sprintf("com/example/Class%d.class", i)] = ""
+				}
+				Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "lib"), 0755)).To(Succeed())
+				writeJarEntries(t, filepath.Join(ctx.Application.Path, "lib", "application.jar"), entries)
+			}).Return(nil)
+			executor.On("Execute", mock.Anything).Return(nil)
+		}
+
+		contribute := func() libcnb.Layer {
+			s := newSpringPerformance(false, true)
+			s.Classpath = []string{"lib/application.jar"}
+			s.Logger = bard.NewLogger(buf)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			return layer
+		}
+
+		it("skips the training run of a small application", func() {
+			extract(2)
+
+			layer := contribute()
+
+			Expect(executor.Calls).To(HaveLen(1))
+			Expect(layer.LaunchEnvironment).NotTo(HaveKey("BPL_JVM_CDS_ENABLED.default"))
+			Expect(layer.Profile).NotTo(HaveKey("spring-performance.sh"))
+			Expect(buf.String()).To(ContainSubstring("Skipping the training run, the application has 2 classes, fewer than BP_JVM_CDS_MIN_APP_CLASSES 3"))
+		})
+
+		it("performs the training run of a large application", func() {
+			extract(3)
+
+			layer := contribute()
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).To(ContainElement("-XX:ArchiveClassesAtExit=application.jsa"))
+			Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"]).To(Equal("true"))
+			Expect(buf.String()).NotTo(ContainSubstring("Skipping the training run"))
+		})
+	})
+
+	context("BP_SPRING_PERFORMANCE_LAUNCH_ENV_ALIASES", func() {
+		it.Before(func() {
+			t.Setenv("BP_SPRING_PERFORMANCE_LAUNCH_ENV_ALIASES", "BPL_JVM_CDS_ENABLED=CDS_ON,BPL_SPRING_AOT_ENABLED=AOT_ON,BPL_SPRING_AOT_ENABLED=NATIVE_AOT")
+			executor.On("Execute", mock.Anything).Return(nil)
+		})
+
+		it("writes the aliases alongside the defaults", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(true, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layer.LaunchEnvironment["BPL_JVM_CDS_ENABLED.default"]).To(Equal("true"))
+			Expect(layer.LaunchEnvironment["CDS_ON.default"]).To(Equal("true"))
+			Expect(layer.LaunchEnvironment["BPL_SPRING_AOT_ENABLED.default"]).To(Equal("true"))
+			Expect(layer.LaunchEnvironment["AOT_ON.default"]).To(Equal("true"))
+			Expect(layer.LaunchEnvironment["NATIVE_AOT.default"]).To(Equal("true"))
+		})
+
+		it("writes only the aliases of the optimizations applied", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layer.LaunchEnvironment["CDS_ON.default"]).To(Equal("true"))
+			Expect(layer.LaunchEnvironment).NotTo(HaveKey("AOT_ON.default"))
+			Expect(layer.LaunchEnvironment).NotTo(HaveKey("NATIVE_AOT.default"))
+		})
+	})
+
+	context("BP_JVM_CDS_TRAINING_ASSERTIONS", func() {
+		it("enables assertions for the training run only", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_ASSERTIONS", "true")
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).To(ContainElement("-ea"))
+			for _, v := range layer.LaunchEnvironment {
+				Expect(v).NotTo(ContainSubstring("-ea"))
+			}
+		})
+
+		it("does not enable assertions by default", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).NotTo(ContainElement("-ea"))
+		})
+	})
+
+	context("BP_JVM_CDS_TRAINING_JFR", func() {
+		it("records the training run into the debug directory of the layer", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_JFR", "true")
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			recording := filepath.Join(layer.Path, "debug", "training.jfr")
+			Expect(e.Args).To(ContainElement(fmt.Sprintf("-XX:StartFlightRecording=filename=%s,dumponexit=true", recording)))
+			Expect(e.Args).To(ContainElement("-XX:ArchiveClassesAtExit=application.jsa"))
+			Expect(filepath.Join(layer.Path, "debug")).To(BeADirectory())
+		})
+
+		it("does not record the training run by default", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).NotTo(ContainElement(HavePrefix("-XX:StartFlightRecording")))
+		})
+	})
+
+	context("BP_JVM_CDS_TRAINING_DEBUG", func() {
+		it("logs the CDS diagnostics to the debug directory of the layer", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_DEBUG", "true")
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).To(ContainElement(fmt.Sprintf("-Xlog:cds*=debug:file=%s", filepath.Join(layer.Path, "debug", "cds.log"))))
+			Expect(filepath.Join(layer.Path, "debug")).To(BeADirectory())
+		})
+
+		it("does not log the CDS diagnostics by default", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).NotTo(ContainElement(HavePrefix("-Xlog:cds")))
+		})
+	})
+
+	context("BP_JVM_CDS_TRAINING_HEAPDUMP", func() {
+		it("dumps the heap into the debug directory of the layer on OutOfMemoryError", func() {
+			t.Setenv("BP_JVM_CDS_TRAINING_HEAPDUMP", "true")
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).To(ContainElements(
+				"-XX:+HeapDumpOnOutOfMemoryError",
+				fmt.Sprintf("-XX:HeapDumpPath=%s", filepath.Join(layer.Path, "debug")),
+			))
+			Expect(filepath.Join(layer.Path, "debug")).To(BeADirectory())
+		})
+
+		it("does not dump the heap by default", func() {
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).NotTo(ContainElement("-XX:+HeapDumpOnOutOfMemoryError"))
+		})
+	})
+
+	context("BP_JVM_CDS_BASE_ARCHIVE", func() {
+		var baseArchive string
+
+		it.Before(func() {
+			baseArchive = filepath.Join(ctx.Layers.Path, "base.jsa")
+			Expect(os.Setenv("BP_JVM_CDS_BASE_ARCHIVE", baseArchive)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_JVM_CDS_BASE_ARCHIVE")).To(Succeed())
+		})
+
+		it("layers the training run archive on top of a static base archive", func() {
+			Expect(os.WriteFile(baseArchive, binary.NativeEndian.AppendUint32(nil, boot.CDSStaticArchiveMagic), 0644)).To(Succeed())
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).To(ContainElements("-XX:SharedArchiveFile="+baseArchive, "-XX:ArchiveClassesAtExit=application.jsa"))
+		})
+
+		it("generates the archive from scratch when the base archive is not compatible", func() {
+			Expect(os.WriteFile(baseArchive, binary.NativeEndian.AppendUint32(nil, boot.CDSDynamicArchiveMagic), 0644)).To(Succeed())
+			executor.On("Execute", mock.Anything).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newSpringPerformance(false, true).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(ok).To(BeTrue())
+			Expect(e.Args).NotTo(ContainElement(HavePrefix("-XX:SharedArchiveFile")))
+			Expect(e.Args).To(ContainElement("-XX:ArchiveClassesAtExit=application.jsa"))
+		})
+	})
+
+	it("fails with a non existing JRE_HOME path", func() {
+		Expect(os.Setenv("JRE_HOME", "/that/does/not/exist")).To(Succeed())
+
+		aotEnabled, cdsEnabled = true, true
+		dc := libpak.DependencyCache{CachePath: "testdata"}
+		executor.On("Execute", mock.Anything).Return(nil)
+
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "META-INF", "MANIFEST.MF"), []byte(`
+Spring-Boot-Version: 3.3.1
+Spring-Boot-Classes: BOOT-INF/classes
+Spring-Boot-Lib: BOOT-INF/lib
+`), 0644)).To(Succeed())
+		props, err := libjvm.NewManifest(ctx.Application.Path)
+		Expect(err).NotTo(HaveOccurred())
+
+		config, err := boot.NewPerformanceConfig()
+		Expect(err).NotTo(HaveOccurred())
+
+		s := boot.NewSpringPerformance(dc, ctx.Application.Path, props, aotEnabled, cdsEnabled, "", config)
+		s.Executor = executor
+
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = s.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(executor.Calls).To(HaveLen(3))
+		e, ok := executor.Calls[1].Arguments[0].(effect.Execution)
+		Expect(ok).To(BeTrue())
+
+		Expect(e.Command).To(Equal("/that/does/not/exist/bin/java"))
+		Expect(layer.Build).To(BeTrue())
+
+		Expect(os.Unsetenv("JRE_HOME")).To(Succeed())
+	})
+
+	context("BP_SPRING_AOT_GENERATE", func() {
+		var s boot.SpringPerformance
+
+		it.Before(func() {
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "BOOT-INF/classes"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "BOOT-INF", "classpath.idx"), []byte(`- "BOOT-INF/lib/spring-boot-jarmode-tools-3.3.1.jar"
+`), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "META-INF", "MANIFEST.MF"), []byte(`
+Spring-Boot-Version: 3.3.1
+Spring-Boot-Classes: BOOT-INF/classes
+Spring-Boot-Lib: BOOT-INF/lib
+Spring-Boot-Classpath-Index: BOOT-INF/classpath.idx
+Start-Class: com.example.Application
+`), 0644)).To(Succeed())
+			props, err := libjvm.NewManifest(ctx.Application.Path)
+			Expect(err).NotTo(HaveOccurred())
+
+			s = boot.NewSpringPerformance(libpak.DependencyCache{CachePath: "testdata"}, ctx.Application.Path, props, true, false, "", boot.PerformanceConfig{JavaHome: "/jdk"})
+			s.GenerateAOT = true
+			s.Executor = executor
+		})
+
+		processor := func(e effect.Execution) bool {
+			return slices.Contains(e.Args, boot.AOTProcessorClass)
+		}
+
+		// the processor writes the generated sources and resources of the fixture
+		generate := func() {
+			executor.On("Execute", mock.MatchedBy(processor)).Run(func(args mock.Arguments) {
+				e := args.Get(0).(effect.Execution)
+				i := slices.Index(e.Args, boot.AOTProcessorClass)
+				Expect(sherpa.CopyDir("testdata/aot/sources", e.Args[i+2])).To(Succeed())
+				Expect(sherpa.CopyDir("testdata/aot/resources", e.Args[i+3])).To(Succeed())
+			}).Return(nil)
+		}
+
+		it("generates and compiles the AOT sources into the application classes", func() {
+			generate()
+			// javac writes the compiled classes into its -d directory
+			executor.On("Execute", mock.MatchedBy(func(e effect.Execution) bool {
+				return e.Command == "/jdk/bin/javac"
+			})).Run(func(args mock.Arguments) {
+				e := args.Get(0).(effect.Execution)
+				dir := filepath.Join(e.Args[slices.Index(e.Args, "-d")+1], "com", "example")
+				Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(dir, "Application__BeanDefinitions.class"), []byte("class"), 0644)).To(Succeed())
+			}).Return(nil)
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(layer.LaunchEnvironment).To(HaveKeyWithValue("BPL_SPRING_AOT_ENABLED.default", "true"))
+
+			Expect(executor.Calls).To(HaveLen(2))
+			e := executor.Calls[0].Arguments[0].(effect.Execution)
+			Expect(e.Command).To(Equal("/jdk/bin/java"))
+			Expect(e.Args[0:2]).To(Equal([]string{"-cp", strings.Join([]string{
+				filepath.Join(ctx.Application.Path, "BOOT-INF/classes"),
+				filepath.Join(ctx.Application.Path, "BOOT-INF/lib/spring-boot-jarmode-tools-3.3.1.jar"),
+			}, string(filepath.ListSeparator))}))
+			Expect(e.Args[2:4]).To(Equal([]string{boot.AOTProcessorClass, "com.example.Application"}))
+			Expect(e.Args[7:]).To(Equal([]string{"com.example", "application"}))
+
+			e = executor.Calls[1].Arguments[0].(effect.Execution)
+			Expect(e.Args).To(ContainElement("-parameters"))
+			argFile, ok := strings.CutPrefix(e.Args[len(e.Args)-1], "@")
+			Expect(ok).To(BeTrue())
+			Expect(argFile).NotTo(BeAnExistingFile())
+
+			classes := filepath.Join(ctx.Application.Path, "BOOT-INF", "classes")
+			Expect(filepath.Join(classes, "com", "example", "Application__BeanDefinitions.class")).To(BeARegularFile())
+			Expect(filepath.Join(classes, "META-INF", "native-image", "com.example", "application", "reflect-config.json")).To(BeARegularFile())
+			Expect(filepath.Join(classes, "com", "example", "Application__BeanDefinitions.java")).NotTo(BeAnExistingFile())
+		})
+
+		it("adds the AOT classes to the re-zipped jar of the training run", func() {
+			generate()
+			executor.On("Execute", mock.Anything).Return(nil)
+			s.DoTrainingRun = true
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			r, err := zip.OpenReader(filepath.Join(layer.Path, "runner.jar"))
+			Expect(err).NotTo(HaveOccurred())
+			defer CloseOrPanic(r)()
+			var names []string
+			for _, f := range r.File {
+				names = append(names, f.Name)
+			}
+			Expect(names).To(ContainElement("BOOT-INF/classes/META-INF/native-image/com.example/application/reflect-config.json"))
+
+			e := executor.Calls[3].Arguments[0].(effect.Execution)
+			Expect(e.Args).To(ContainElement("-Dspring.aot.enabled=true"))
+		})
+
+		it("fails with a JDK hint when javac cannot be found", func() {
+			generate()
+			executor.On("Execute", mock.Anything).Return(fmt.Errorf("unable to start\n%w", exec.ErrNotFound))
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("error compiling the AOT sources")))
+			Expect(err).To(MatchError(boot.JavaNotFound))
+			hint, ok := boot.ErrorHint(err)
+			Expect(ok).To(BeTrue())
+			Expect(hint).To(Equal(boot.HintJDK))
+		})
+
+		it("fails when the AOT processor fails", func() {
+			executor.On("Execute", mock.Anything).Return(fmt.Errorf("exit status 1"))
+
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Contribute(layer)
+			Expect(err).To(MatchError(ContainSubstring("error running the AOT processor")))
+			Expect(err).To(MatchError(boot.ValidationFailed))
+			Expect(executor.Calls).To(HaveLen(1))
+		})
+	})
+
+}
+
+type recordingMetricsSink struct {
+	durations map[string]time.Duration
+	sizes     map[string]int64
+	events    []string
+}
+
+func (r *recordingMetricsSink) RecordDuration(name string, duration time.Duration) {
+	if r.durations == nil {
+		r.durations = map[string]time.Duration{}
+	}
+	r.durations[name] = duration
+}
+
+func (r *recordingMetricsSink) RecordSize(name string, bytes int64) {
+	if r.sizes == nil {
+		r.sizes = map[string]int64{}
+	}
+	r.sizes[name] = bytes
+}
+
+func (r *recordingMetricsSink) RecordEvent(name string) {
+	r.events = append(r.events, name)
 }
 
 func unzip(src, dest string) error {
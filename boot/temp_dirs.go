@@ -0,0 +1,67 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// TempDirs are the temporary directories created by a contribution, removed once it is done, interrupted or not.
+type TempDirs struct {
+	mu   sync.Mutex
+	dirs []string
+}
+
+// MkdirTemp creates a directory in the temporary directory as os.MkdirTemp does, and registers it to be removed.
+func (t *TempDirs) MkdirTemp(pattern string) (string, error) {
+	dir, err := os.MkdirTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dirs = append(t.dirs, dir)
+	return dir, nil
+}
+
+// RemoveAll removes the registered directories, which are forgotten.
+func (t *TempDirs) RemoveAll() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var errs []error
+	for _, dir := range t.dirs {
+		if err := os.RemoveAll(dir); err != nil {
+			errs = append(errs, fmt.Errorf("unable to remove %s\n%w", dir, err))
+		}
+	}
+	t.dirs = nil
+	return errors.Join(errs...)
+}
+
+// InterruptContext returns a context done once the build process receives SIGINT or SIGTERM, which no longer terminate
+// it until the returned function is called.
+func InterruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
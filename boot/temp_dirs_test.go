@@ -0,0 +1,66 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testTempDirs(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect     = NewWithT(t).Expect
+		Eventually = NewWithT(t).Eventually
+
+		temp *boot.TempDirs
+	)
+
+	it.Before(func() {
+		t.Setenv("TMPDIR", t.TempDir())
+		temp = &boot.TempDirs{}
+	})
+
+	it("removes the directories it created", func() {
+		first, err := temp.MkdirTemp("first")
+		Expect(err).NotTo(HaveOccurred())
+		second, err := temp.MkdirTemp("second")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(first).To(BeADirectory())
+
+		Expect(temp.RemoveAll()).To(Succeed())
+
+		Expect(first).NotTo(BeAnExistingFile())
+		Expect(second).NotTo(BeAnExistingFile())
+	})
+
+	it("returns a context done once the process is interrupted", func() {
+		ctx, stop := boot.InterruptContext()
+		defer stop()
+
+		p, err := os.FindProcess(os.Getpid())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Signal(os.Interrupt)).To(Succeed())
+
+		Eventually(ctx.Done(), 5*time.Second).Should(BeClosed())
+	})
+}
@@ -0,0 +1,12 @@
+package com.example;
+
+import org.springframework.beans.factory.config.BeanDefinition;
+import org.springframework.beans.factory.support.RootBeanDefinition;
+
+public class Application__BeanDefinitions {
+  public static BeanDefinition getApplicationBeanDefinition() {
+    RootBeanDefinition beanDefinition = new RootBeanDefinition(Application.class);
+    beanDefinition.setInstanceSupplier(Application::new);
+    return beanDefinition;
+  }
+}
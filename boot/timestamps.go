@@ -0,0 +1,60 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// DefaultTimestamp is the time files are reset to when SOURCE_DATE_EPOCH is not set. It is close to the earliest time
+// an MS-DOS timestamp, and therefore a zip entry, can represent.
+var DefaultTimestamp = time.Date(1980, time.January, 1, 0, 0, 1, 0, time.UTC)
+
+// ParseSourceDateEpoch parses a SOURCE_DATE_EPOCH value, the number of seconds since the Unix epoch as defined by
+// https://reproducible-builds.org/specs/source-date-epoch/.
+func ParseSourceDateEpoch(value string) (time.Time, error) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to parse SOURCE_DATE_EPOCH %q\n%w", value, err)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// ResetTimestamps sets the access and modification times of root and every file and directory below it to t.
+//
+// Symbolic links are skipped rather than followed, so that files outside of root are never modified.
+func ResetTimestamps(root string, t time.Time) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("unable to walk %s\n%w", path, err)
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+
+		if err := os.Chtimes(path, t, t); err != nil {
+			return fmt.Errorf("unable to reset file times of %s\n%w", path, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testTimestamps(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		root      string
+		baseTime  = time.Date(1980, 1, 1, 0, 0, 1, 0, time.UTC)
+		outside   string
+		modTimeOf = func(path string) time.Time {
+			info, err := os.Lstat(path)
+			Expect(err).NotTo(HaveOccurred())
+			return info.ModTime().UTC()
+		}
+	)
+
+	it.Before(func() {
+		var err error
+		root, err = os.MkdirTemp("", "timestamps")
+		Expect(err).NotTo(HaveOccurred())
+		outside, err = os.MkdirTemp("", "timestamps-outside")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(root, "BOOT-INF", "lib"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "BOOT-INF", "lib", "test.jar"), []byte{}, 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "runner.jar"), []byte{}, 0644)).To(Succeed())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+		Expect(os.RemoveAll(outside)).To(Succeed())
+	})
+
+	it("resets the root, directories and files", func() {
+		Expect(boot.ResetTimestamps(root, baseTime)).To(Succeed())
+
+		for _, path := range []string{
+			root,
+			filepath.Join(root, "BOOT-INF"),
+			filepath.Join(root, "BOOT-INF", "lib"),
+			filepath.Join(root, "BOOT-INF", "lib", "test.jar"),
+			filepath.Join(root, "runner.jar"),
+		} {
+			Expect(modTimeOf(path)).To(Equal(baseTime), path)
+		}
+	})
+
+	it("does not follow symbolic links", func() {
+		target := filepath.Join(outside, "outside.jar")
+		Expect(os.WriteFile(target, []byte{}, 0644)).To(Succeed())
+		before := modTimeOf(target)
+		Expect(os.Symlink(target, filepath.Join(root, "BOOT-INF", "lib", "link.jar"))).To(Succeed())
+
+		Expect(boot.ResetTimestamps(root, baseTime)).To(Succeed())
+
+		Expect(modTimeOf(target)).To(Equal(before))
+		Expect(modTimeOf(filepath.Join(root, "runner.jar"))).To(Equal(baseTime))
+	})
+
+	it("resets every file to the same time rather than recomputing it per file", func() {
+		for i := 0; i < 10; i++ {
+			Expect(os.WriteFile(filepath.Join(root, "BOOT-INF", "lib", fmt.Sprintf("test-%d.jar", i)), []byte{}, 0644)).To(Succeed())
+		}
+		custom := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+
+		Expect(boot.ResetTimestamps(root, custom)).To(Succeed())
+
+		Expect(filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(modTimeOf(path)).To(Equal(custom), path)
+			return nil
+		})).To(Succeed())
+	})
+
+	it("defaults to the time previously parsed from 1980-01-01 00:00:01", func() {
+		expected, err := time.Parse(time.DateTime, "1980-01-01 00:00:01")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(boot.DefaultTimestamp).To(Equal(expected))
+	})
+
+	context("ParseSourceDateEpoch", func() {
+		it("parses seconds since the Unix epoch", func() {
+			epoch, err := boot.ParseSourceDateEpoch("1700000000")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(epoch).To(Equal(time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)))
+		})
+
+		it("fails with an invalid value", func() {
+			_, err := boot.ParseSourceDateEpoch("yesterday")
+			Expect(err).To(MatchError(ContainSubstring("unable to parse SOURCE_DATE_EPOCH")))
+		})
+	})
+
+	it("returns walk errors", func() {
+		Expect(boot.ResetTimestamps(filepath.Join(root, "does-not-exist"), baseTime)).
+			To(MatchError(ContainSubstring("unable to walk")))
+	})
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// EmbeddedDataSource is the BP_JVM_CDS_TRAINING_DATASOURCE connecting the datasource of the application to an
+	// in-memory H2 database during the training run, when the database of the application is not reachable at build time.
+	EmbeddedDataSource = "embedded"
+
+	// EmbeddedDataSourceURL is the JDBC URL of the in-memory database of the training run, kept open until the JVM exits.
+	EmbeddedDataSourceURL = "jdbc:h2:mem:cds-training;DB_CLOSE_DELAY=-1"
+
+	// h2Artifact is the name of the artifact of the H2 database.
+	h2Artifact = "h2"
+)
+
+// ParseTrainingDataSource returns the datasource of the training run in value, ignoring case: empty for the datasource
+// of the application, or EmbeddedDataSource.
+func ParseTrainingDataSource(value string) (string, error) {
+	switch value = strings.ToLower(strings.TrimSpace(value)); value {
+	case "", EmbeddedDataSource:
+		return value, nil
+	default:
+		return "", fmt.Errorf("%q is not %s", value, EmbeddedDataSource)
+	}
+}
+
+// EmbeddedDataSourceArgs returns the system properties connecting the datasource of a Spring Boot application to the
+// in-memory database at EmbeddedDataSourceURL. They take precedence over the configuration of the application.
+func EmbeddedDataSourceArgs() []string {
+	return []string{
+		fmt.Sprintf("-Dspring.datasource.url=%s", EmbeddedDataSourceURL),
+		"-Dspring.datasource.driver-class-name=org.h2.Driver",
+		"-Dspring.datasource.username=sa",
+		"-Dspring.datasource.password=",
+	}
+}
+
+// HasH2Database returns whether an entry of classpath is a jar of the H2 database, named after its artifact and
+// version.
+func HasH2Database(classpath []string) bool {
+	for _, entry := range classpath {
+		if artifact, ok := artifactName(entry); ok && artifact == h2Artifact {
+			return true
+		}
+	}
+	return false
+}
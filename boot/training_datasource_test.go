@@ -0,0 +1,66 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testTrainingDataSource(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	context("ParseTrainingDataSource", func() {
+		it("parses the embedded datasource", func() {
+			Expect(boot.ParseTrainingDataSource(" Embedded")).To(Equal(boot.EmbeddedDataSource))
+		})
+
+		it("keeps the datasource of the application by default", func() {
+			Expect(boot.ParseTrainingDataSource("")).To(BeEmpty())
+		})
+
+		it("fails with an unknown datasource", func() {
+			_, err := boot.ParseTrainingDataSource("postgres")
+			Expect(err).To(MatchError(`"postgres" is not embedded`))
+		})
+	})
+
+	it("connects the datasource to the in-memory database", func() {
+		Expect(boot.EmbeddedDataSourceArgs()).To(ConsistOf(
+			"-Dspring.datasource.url=jdbc:h2:mem:cds-training;DB_CLOSE_DELAY=-1",
+			"-Dspring.datasource.driver-class-name=org.h2.Driver",
+			"-Dspring.datasource.username=sa",
+			"-Dspring.datasource.password=",
+		))
+	})
+
+	context("HasH2Database", func() {
+		it("finds the H2 jar", func() {
+			Expect(boot.HasH2Database([]string{"runner.jar", "lib/h2-2.2.224.jar"})).To(BeTrue())
+		})
+
+		it("does not mistake another artifact for H2", func() {
+			Expect(boot.HasH2Database([]string{"runner.jar", "lib/h2-console-1.0.0.jar", "lib/postgresql-42.7.3.jar"})).To(BeFalse())
+		})
+	})
+}
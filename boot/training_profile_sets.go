@@ -0,0 +1,87 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// classListID matches the identifier of a class in a class list, specific to the list it was dumped to.
+var classListID = regexp.MustCompile(` id: \d+$`)
+
+// ProfileSetArgs returns the arguments of the training run activating profiles, which are args with the classes
+// loaded dumped to classList rather than archived, whatever the ArchiveFormat. The archive is dumped once the classes of
+// all the sets are listed.
+func ProfileSetArgs(args []string, profiles []string, classList string) []string {
+	var setArgs []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-XX:ArchiveClassesAtExit=") || strings.HasPrefix(arg, "-XX:DumpLoadedClassList=") {
+			setArgs = append(setArgs, fmt.Sprintf("-Dspring.profiles.active=%s", strings.Join(profiles, ",")),
+				fmt.Sprintf("-XX:DumpLoadedClassList=%s", classList))
+			continue
+		}
+		setArgs = append(setArgs, arg)
+	}
+	return setArgs
+}
+
+// StaticDumpArgs returns the arguments dumping to archive the classes listed in classList, loaded from classpath.
+func StaticDumpArgs(classList string, archive string, classpath string) []string {
+	return []string{"-Xshare:dump", fmt.Sprintf("-XX:SharedClassListFile=%s", classList),
+		fmt.Sprintf("-XX:SharedArchiveFile=%s", archive), "-cp", classpath}
+}
+
+// MergeClassLists writes to file the classes listed by lists, in the order they are first listed, and returns their
+// number. The identifiers of the classes are removed, they are specific to a list, as are the classes loaded by other
+// class loaders than the ones of the JDK, which are identified by their source.
+func MergeClassLists(file string, lists ...string) (int, error) {
+	seen := map[string]bool{}
+	var merged []string
+	for _, list := range lists {
+		f, err := os.Open(list)
+		if err != nil {
+			return 0, fmt.Errorf("unable to open %s\n%w", list, err)
+		}
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") || strings.Contains(line, " source: ") {
+				continue
+			}
+			if line = classListID.ReplaceAllString(line, ""); !seen[line] {
+				seen[line] = true
+				merged = append(merged, line)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return 0, fmt.Errorf("unable to read %s\n%w", list, err)
+		}
+	}
+
+	if err := os.WriteFile(file, []byte(strings.Join(merged, "\n")+"\n"), 0644); err != nil {
+		return 0, fmt.Errorf("unable to write %s\n%w", file, err)
+	}
+	return len(merged), nil
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testTrainingProfileSets(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	context("ProfileSetArgs", func() {
+		it("lists the classes loaded rather than archiving them", func() {
+			Expect(boot.ProfileSetArgs(
+				[]string{"-Dspring.context.exit=onRefresh", "-XX:ArchiveClassesAtExit=application.jsa", "-cp", "runner.jar", "com.example.Application"},
+				[]string{"cloud", "kafka"}, "/layer/training/classes-2.lst",
+			)).To(Equal([]string{"-Dspring.context.exit=onRefresh", "-Dspring.profiles.active=cloud,kafka",
+				"-XX:DumpLoadedClassList=/layer/training/classes-2.lst", "-cp", "runner.jar", "com.example.Application"}))
+		})
+
+		it("lists the classes of the set rather than the ones of the classic archive", func() {
+			Expect(boot.ProfileSetArgs(
+				[]string{"-XX:DumpLoadedClassList=/layer/training/loaded-classes.lst", "-cp", "runner.jar", "com.example.Application"},
+				[]string{"default"}, "/layer/training/classes-1.lst",
+			)).To(Equal([]string{"-Dspring.profiles.active=default", "-XX:DumpLoadedClassList=/layer/training/classes-1.lst",
+				"-cp", "runner.jar", "com.example.Application"}))
+		})
+	})
+
+	context("MergeClassLists", func() {
+		it("merges the classes of the lists in the order they are first listed", func() {
+			dir := t.TempDir()
+			first, second := filepath.Join(dir, "classes-1.lst"), filepath.Join(dir, "classes-2.lst")
+			Expect(os.WriteFile(first, []byte("# NOTE: Do not modify this file.\njava/lang/Object id: 0\ncom/example/Application id: 1\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(second, []byte("java/lang/Object id: 0\ncom/example/Plugin id: 7 super: 0 source: /plugins/plugin.jar\n"+
+				"com/example/KafkaConfiguration id: 2\n@lambda-proxy com/example/Application run ()Ljava/lang/Runnable;\n"), 0644)).To(Succeed())
+
+			merged := filepath.Join(dir, "classes.lst")
+			Expect(boot.MergeClassLists(merged, first, second)).To(Equal(4))
+
+			Expect(os.ReadFile(merged)).To(Equal([]byte("java/lang/Object\ncom/example/Application\ncom/example/KafkaConfiguration\n" +
+				"@lambda-proxy com/example/Application run ()Ljava/lang/Runnable;\n")))
+		})
+
+		it("fails when a list is missing", func() {
+			dir := t.TempDir()
+
+			_, err := boot.MergeClassLists(filepath.Join(dir, "classes.lst"), filepath.Join(dir, "classes-1.lst"))
+			Expect(err).To(MatchError(ContainSubstring("unable to open")))
+		})
+	})
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+// DefaultNetworkSandbox is the command wrapping the training run when BP_JVM_CDS_TRAINING_NETWORK is false and
+// BP_JVM_CDS_TRAINING_SANDBOX is not set. It runs the JVM in a new network namespace, which has no interface but a
+// loopback one, within a new user namespace so that it needs no privilege where the platform allows unprivileged user
+// namespaces.
+var DefaultNetworkSandbox = []string{"unshare", "--net", "--map-root-user"}
+
+// Sandboxed returns execution run by the sandbox command, the command of execution and its arguments being appended
+// to the ones of sandbox. It returns execution unchanged if sandbox is empty.
+func Sandboxed(sandbox []string, execution effect.Execution) effect.Execution {
+	if len(sandbox) == 0 {
+		return execution
+	}
+
+	args := make([]string, 0, len(sandbox)+len(execution.Args))
+	args = append(args, sandbox[1:]...)
+	args = append(args, execution.Command)
+	args = append(args, execution.Args...)
+
+	execution.Command = sandbox[0]
+	execution.Args = args
+	return execution
+}
+
+// trainingSandbox returns the command wrapping the executions of the application at build time, logging why.
+func (s SpringPerformance) trainingSandbox() []string {
+	sandbox := s.Config.TrainingSandboxCommand()
+	switch {
+	case len(sandbox) == 0:
+	case len(s.Config.TrainingSandbox) > 0:
+		s.log().Bodyf("Training run will be wrapped by the BP_JVM_CDS_TRAINING_SANDBOX command %s", strings.Join(sandbox, " "))
+	default:
+		s.log().Bodyf("Training run will not have network access, it will be wrapped by %s", strings.Join(sandbox, " "))
+	}
+	return sandbox
+}
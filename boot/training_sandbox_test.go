@@ -0,0 +1,56 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/spring-boot/v5/boot"
+)
+
+func testSandboxed(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		execution = effect.Execution{Command: "java", Args: []string{"-Xshare:off", "-jar", "runner.jar"}, Dir: "/workspace"}
+	)
+
+	it("wraps the execution in the sandbox command", func() {
+		Expect(boot.Sandboxed([]string{"bwrap", "--unshare-net", "--"}, execution)).To(Equal(effect.Execution{
+			Command: "bwrap",
+			Args:    []string{"--unshare-net", "--", "java", "-Xshare:off", "-jar", "runner.jar"},
+			Dir:     "/workspace",
+		}))
+	})
+
+	it("wraps the execution in a sandbox command without arguments", func() {
+		Expect(boot.Sandboxed([]string{"sandbox"}, execution).Args).To(Equal([]string{"java", "-Xshare:off", "-jar", "runner.jar"}))
+	})
+
+	it("returns the execution unchanged without a sandbox", func() {
+		Expect(boot.Sandboxed(nil, execution)).To(Equal(execution))
+	})
+
+	it("does not modify the arguments of the execution", func() {
+		boot.Sandboxed([]string{"unshare", "--net"}, execution)
+		Expect(execution.Args).To(Equal([]string{"-Xshare:off", "-jar", "runner.jar"}))
+	})
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package boot
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+)
+
+// WarmupHarnessName is the name of the source of the harness refreshing the application context several times during
+// the training run.
+const WarmupHarnessName = "CdsWarmup.java"
+
+// warmupHarness is the source of the harness, launched in source-file mode so that the classpath of the training run,
+// recorded in the archive, is the one of the application.
+//
+//go:embed warmup/CdsWarmup.java
+var warmupHarness []byte
+
+// WarShutdownDriverName is the name of the source of the driver starting the embedded Tomcat of a war during the
+// training run and stopping it once the application is ready and warm.
+const WarShutdownDriverName = "CdsWarShutdown.java"
+
+// warShutdownDriver is the source of the driver, launched in source-file mode like the warmup harness.
+//
+//go:embed warmup/CdsWarShutdown.java
+var warShutdownDriver []byte
+
+// ShutdownWatchdogName is the name of the source of the watchdog reporting, and if forced ending, a shutdown of the
+// training run blocked by a shutdown hook of the application, the dynamic archive being dumped once the hooks are done.
+const ShutdownWatchdogName = "CdsShutdownWatchdog.java"
+
+// shutdownWatchdog is the source of the watchdog, launched in source-file mode like the warmup harness.
+//
+//go:embed warmup/CdsShutdownWatchdog.java
+var shutdownWatchdog []byte
+
+// writeWarmupHarness writes the source of the warmup harness to the training directory of layer, returning its path.
+func (s SpringPerformance) writeWarmupHarness(layer libcnb.Layer) (string, error) {
+	return writeTrainingSource(layer, WarmupHarnessName, warmupHarness)
+}
+
+// writeWarShutdownDriver writes the source of the war shutdown driver to the training directory of layer, returning
+// its path.
+func (s SpringPerformance) writeWarShutdownDriver(layer libcnb.Layer) (string, error) {
+	return writeTrainingSource(layer, WarShutdownDriverName, warShutdownDriver)
+}
+
+// writeShutdownWatchdog writes the source of the shutdown watchdog to the training directory of layer, returning its
+// path.
+func (s SpringPerformance) writeShutdownWatchdog(layer libcnb.Layer) (string, error) {
+	return writeTrainingSource(layer, ShutdownWatchdogName, shutdownWatchdog)
+}
+
+// writeTrainingSource writes the source named name to the training directory of layer, returning its path.
+func writeTrainingSource(layer libcnb.Layer, name string, source []byte) (string, error) {
+	dir := filepath.Join(layer.Path, "training")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("unable to create %s\n%w", dir, err)
+	}
+
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, source, 0644); err != nil {
+		return "", fmt.Errorf("unable to write %s\n%w", file, err)
+	}
+	return file, nil
+}
+
+// EmbeddedTomcat returns whether classpath contains the embedded Tomcat, whose server a war starts once refreshed.
+func EmbeddedTomcat(classpath []string) bool {
+	for _, entry := range classpath {
+		if name := filepath.Base(entry); strings.HasPrefix(name, "tomcat-embed-core-") && strings.HasSuffix(name, ".jar") {
+			return true
+		}
+	}
+	return false
+}
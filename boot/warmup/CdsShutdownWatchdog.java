@@ -0,0 +1,69 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import java.lang.reflect.InvocationTargetException;
+import java.lang.reflect.Method;
+import java.util.Arrays;
+import java.util.concurrent.CountDownLatch;
+
+/**
+ * Runs the main method of a Spring Boot application during the CDS training run and watches the shutdown of the JVM
+ * once the application context exits on refresh, the dynamic archive being dumped once the shutdown hooks are done.
+ *
+ * <p>It is launched in source-file mode on the classpath of the application, with the timeout of the shutdown in
+ * milliseconds, whether to halt the JVM once it elapsed, the main class of the application and its arguments. A
+ * shutdown taking longer than the timeout is reported and, if forced, ended by halting the JVM, which still dumps the
+ * archive of the classes loaded so far.
+ */
+public final class CdsShutdownWatchdog {
+
+    public static void main(String[] args) throws Throwable {
+        long timeout = Long.parseLong(args[0]);
+        boolean halt = Boolean.parseBoolean(args[1]);
+        ClassLoader loader = CdsShutdownWatchdog.class.getClassLoader();
+        Method main = Class.forName(args[2], false, loader).getMethod("main", String[].class);
+        String[] applicationArgs = Arrays.copyOfRange(args, 3, args.length);
+
+        // the JVM waits for its shutdown hooks, the watchdog is a daemon thread released by one so that it does not
+        // delay a shutdown completing in time
+        CountDownLatch shutdown = new CountDownLatch(1);
+        Thread watchdog = new Thread(() -> {
+            try {
+                shutdown.await();
+                Thread.sleep(timeout);
+            } catch (InterruptedException e) {
+                return;
+            }
+            if (halt) {
+                System.err.printf("CDS training run halted after a shutdown longer than %d ms%n", timeout);
+                Runtime.getRuntime().halt(0);
+            }
+            System.err.printf("CDS training run shutdown is taking longer than %d ms, a shutdown hook may block the dump "
+                    + "of the archive, set BP_JVM_CDS_FORCE_DUMP=true to halt the JVM once it elapsed%n", timeout);
+        }, "cds-shutdown-watchdog");
+        watchdog.setDaemon(true);
+        watchdog.start();
+        Runtime.getRuntime().addShutdownHook(new Thread(shutdown::countDown, "cds-shutdown-started"));
+
+        try {
+            main.invoke(null, (Object) applicationArgs);
+        } catch (InvocationTargetException e) {
+            throw e.getCause();
+        }
+    }
+
+}
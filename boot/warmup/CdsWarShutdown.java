@@ -0,0 +1,153 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import java.io.IOException;
+import java.io.InputStream;
+import java.lang.reflect.InvocationTargetException;
+import java.lang.reflect.Method;
+import java.lang.reflect.Proxy;
+import java.net.HttpURLConnection;
+import java.net.URI;
+import java.util.Arrays;
+
+/**
+ * Runs the main method of a Spring Boot war with an embedded Tomcat during the CDS training run, stopping the server
+ * cleanly once the application is ready and warm, so that the classes of the server and of the request processing are
+ * archived as well.
+ *
+ * <p>It is launched in source-file mode on the classpath of the application, with the main class of the application
+ * and its arguments. Once the application is ready, a request is sent to the port of the embedded server, which is then
+ * stopped with the application context, and the JVM exits so that the archive is dumped. Spring Boot is accessed
+ * reflectively, the driver being compiled without the classpath of the application.
+ */
+public final class CdsWarShutdown {
+
+    private static final int TIMEOUT_MILLIS = 10_000;
+
+    public static void main(String[] args) throws Throwable {
+        ClassLoader loader = CdsWarShutdown.class.getClassLoader();
+        Method main = Class.forName(args[0], false, loader).getMethod("main", String[].class);
+        String[] applicationArgs = Arrays.copyOfRange(args, 1, args.length);
+
+        Class<?> springApplication = Class.forName("org.springframework.boot.SpringApplication", false, loader);
+        Class<?> hookType = Class.forName("org.springframework.boot.SpringApplicationHook", false, loader);
+        Class<?> listenerType = Class.forName("org.springframework.boot.SpringApplicationRunListener", false, loader);
+        Method withHook = springApplication.getMethod("withHook", hookType, Runnable.class);
+
+        // the server is warmed and the context closed once ready, the default methods of the listener do nothing
+        Object listener = Proxy.newProxyInstance(listenerType.getClassLoader(), new Class<?>[] { listenerType },
+                (proxy, method, methodArgs) -> {
+                    switch (method.getName()) {
+                        case "ready":
+                            warm(methodArgs[0]);
+                            ((AutoCloseable) methodArgs[0]).close();
+                            System.out.println("CDS training run stopped the embedded server");
+                            return null;
+                        case "hashCode":
+                            return System.identityHashCode(proxy);
+                        case "equals":
+                            return proxy == methodArgs[0];
+                        case "toString":
+                            return "CdsWarShutdown listener";
+                        default:
+                            return null;
+                    }
+                });
+        Object hook = Proxy.newProxyInstance(hookType.getClassLoader(), new Class<?>[] { hookType },
+                (proxy, method, methodArgs) -> {
+                    switch (method.getName()) {
+                        case "getRunListener":
+                            return listener;
+                        case "hashCode":
+                            return System.identityHashCode(proxy);
+                        case "equals":
+                            return proxy == methodArgs[0];
+                        case "toString":
+                            return "CdsWarShutdown hook";
+                        default:
+                            return null;
+                    }
+                });
+
+        Runnable run = () -> {
+            try {
+                main.invoke(null, (Object) applicationArgs);
+            } catch (IllegalAccessException e) {
+                throw new IllegalStateException(e);
+            } catch (InvocationTargetException e) {
+                throw new ShutdownException(e.getCause());
+            }
+        };
+        try {
+            withHook.invoke(null, hook, run);
+        } catch (InvocationTargetException e) {
+            Throwable cause = e.getCause();
+            throw cause instanceof ShutdownException ? cause.getCause() : cause;
+        }
+        System.exit(0);
+    }
+
+    /**
+     * Sends a request to the embedded server of context, if it has one, so that the request processing is loaded. The
+     * status of the response does not matter, the application may not map the root.
+     */
+    private static void warm(Object context) throws ReflectiveOperationException {
+        Object server;
+        try {
+            server = context.getClass().getMethod("getWebServer").invoke(context);
+        } catch (NoSuchMethodException e) {
+            System.out.println("CDS training run found no embedded server, the application is not a servlet web application");
+            return;
+        }
+        if (server == null) {
+            return;
+        }
+
+        int port = (int) server.getClass().getMethod("getPort").invoke(server);
+        System.out.printf("CDS training run started %s on port %d%n", server.getClass().getSimpleName(), port);
+        if (port <= 0) {
+            return;
+        }
+        try {
+            HttpURLConnection connection = (HttpURLConnection) URI.create("http://127.0.0.1:" + port + "/").toURL().openConnection();
+            connection.setConnectTimeout(TIMEOUT_MILLIS);
+            connection.setReadTimeout(TIMEOUT_MILLIS);
+            int status = connection.getResponseCode();
+            InputStream body = status < 400 ? connection.getInputStream() : connection.getErrorStream();
+            if (body != null) {
+                body.readAllBytes();
+                body.close();
+            }
+            connection.disconnect();
+            System.out.printf("CDS training run warmed the embedded server, status %d%n", status);
+        } catch (IOException e) {
+            System.out.printf("CDS training run was unable to warm the embedded server: %s%n", e);
+        }
+    }
+
+    /**
+     * Carries the exception thrown by the main method of the application out of the hooked run.
+     */
+    private static final class ShutdownException extends RuntimeException {
+
+        ShutdownException(Throwable cause) {
+            super(cause);
+        }
+
+    }
+
+}
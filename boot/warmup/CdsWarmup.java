@@ -0,0 +1,109 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import java.lang.reflect.InvocationTargetException;
+import java.lang.reflect.Method;
+import java.lang.reflect.Proxy;
+import java.util.Arrays;
+
+/**
+ * Runs the main method of a Spring Boot application several times during the CDS training run, closing the application
+ * context once it is ready, so that the classes loaded by the later refreshes are archived as well.
+ *
+ * <p>It is launched in source-file mode on the classpath of the application, with the number of iterations, the main
+ * class of the application and its arguments, and exits the JVM once the last iteration is done so that the archive is
+ * dumped. Spring Boot is accessed reflectively, the harness being compiled without the classpath of the application.
+ */
+public final class CdsWarmup {
+
+    public static void main(String[] args) throws Throwable {
+        int iterations = Integer.parseInt(args[0]);
+        ClassLoader loader = CdsWarmup.class.getClassLoader();
+        Method main = Class.forName(args[1], false, loader).getMethod("main", String[].class);
+        String[] applicationArgs = Arrays.copyOfRange(args, 2, args.length);
+
+        Class<?> springApplication = Class.forName("org.springframework.boot.SpringApplication", false, loader);
+        Class<?> hookType = Class.forName("org.springframework.boot.SpringApplicationHook", false, loader);
+        Class<?> listenerType = Class.forName("org.springframework.boot.SpringApplicationRunListener", false, loader);
+        Method withHook = springApplication.getMethod("withHook", hookType, Runnable.class);
+
+        // the context is closed once ready, the default methods of the listener do nothing otherwise
+        Object listener = Proxy.newProxyInstance(listenerType.getClassLoader(), new Class<?>[] { listenerType },
+                (proxy, method, methodArgs) -> {
+                    switch (method.getName()) {
+                        case "ready":
+                            ((AutoCloseable) methodArgs[0]).close();
+                            return null;
+                        case "hashCode":
+                            return System.identityHashCode(proxy);
+                        case "equals":
+                            return proxy == methodArgs[0];
+                        case "toString":
+                            return "CdsWarmup listener";
+                        default:
+                            return null;
+                    }
+                });
+        Object hook = Proxy.newProxyInstance(hookType.getClassLoader(), new Class<?>[] { hookType },
+                (proxy, method, methodArgs) -> {
+                    switch (method.getName()) {
+                        case "getRunListener":
+                            return listener;
+                        case "hashCode":
+                            return System.identityHashCode(proxy);
+                        case "equals":
+                            return proxy == methodArgs[0];
+                        case "toString":
+                            return "CdsWarmup hook";
+                        default:
+                            return null;
+                    }
+                });
+
+        for (int i = 1; i <= iterations; i++) {
+            System.out.printf("CDS warmup iteration %d of %d%n", i, iterations);
+            Runnable run = () -> {
+                try {
+                    main.invoke(null, (Object) applicationArgs);
+                } catch (IllegalAccessException e) {
+                    throw new IllegalStateException(e);
+                } catch (InvocationTargetException e) {
+                    throw new WarmupException(e.getCause());
+                }
+            };
+            try {
+                withHook.invoke(null, hook, run);
+            } catch (InvocationTargetException e) {
+                Throwable cause = e.getCause();
+                throw cause instanceof WarmupException ? cause.getCause() : cause;
+            }
+        }
+        System.exit(0);
+    }
+
+    /**
+     * Carries the exception thrown by the main method of the application out of the hooked run.
+     */
+    private static final class WarmupException extends RuntimeException {
+
+        WarmupException(Throwable cause) {
+            super(cause);
+        }
+
+    }
+
+}
@@ -17,6 +17,7 @@
 package helper
 
 import (
+	"fmt"
 
 	"github.com/paketo-buildpacks/libpak/bard"
 	"github.com/paketo-buildpacks/libpak/sherpa"
@@ -40,8 +41,9 @@ func (s SpringPerformance) Execute() (map[string]string, error) {
 	}
 
 	if cds {
-		s.Logger.Info("Spring CDS Enabled, contributing -XX:SharedArchiveFile=application.jsa to JAVA_TOOL_OPTIONS")
-		values = append(values, "-XX:SharedArchiveFile=application.jsa")
+		archive := sherpa.GetEnvWithDefault("BPL_JVM_CDS_ARCHIVE_FILE", "application.jsa")
+		s.Logger.Infof("Spring CDS Enabled, contributing -XX:SharedArchiveFile=%s to JAVA_TOOL_OPTIONS", archive)
+		values = append(values, fmt.Sprintf("-XX:SharedArchiveFile=%s", archive))
 	}
 	opts := sherpa.AppendToEnvVar("JAVA_TOOL_OPTIONS", " ", values...)
 	return map[string]string{"JAVA_TOOL_OPTIONS": opts}, nil
@@ -103,6 +103,24 @@ func testSpringPerformance(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("$BPL_JVM_CDS_ARCHIVE_FILE", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BPL_JVM_CDS_ENABLED", "true")).To(Succeed())
+			Expect(os.Setenv("BPL_JVM_CDS_ARCHIVE_FILE", "/cds/application.jsa")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BPL_JVM_CDS_ENABLED")).To(Succeed())
+			Expect(os.Unsetenv("BPL_JVM_CDS_ARCHIVE_FILE")).To(Succeed())
+		})
+
+		it("uses the archive at $BPL_JVM_CDS_ARCHIVE_FILE", func() {
+			Expect(s.Execute()).To(Equal(map[string]string{
+				"JAVA_TOOL_OPTIONS": "-XX:SharedArchiveFile=/cds/application.jsa",
+			}))
+		})
+	})
+
 	context("$JAVA_TOOL_OPTIONS", func() {
 		it.Before(func() {
 			Expect(os.Setenv("JAVA_TOOL_OPTIONS", "test-java-tool-options")).To(Succeed())